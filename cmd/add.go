@@ -4,7 +4,7 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/samakintunde/bujo/internal/git"
+	"github.com/samakintunde/bujo/internal/clock"
 	"github.com/samakintunde/bujo/internal/models"
 	"github.com/samakintunde/bujo/internal/service"
 	"github.com/samakintunde/bujo/internal/storage"
@@ -20,6 +20,8 @@ type EntryTypeFlags struct {
 
 var entryTypeFlags EntryTypeFlags
 
+var addDue string
+
 var addCmd = &cobra.Command{
 	Use:   "add <text> [flags]",
 	Short: "Add a task/event/note",
@@ -31,11 +33,8 @@ var addCmd = &cobra.Command{
 			return err
 		}
 
-		if git.IsPresent() && !git.IsRepo(cfg.GetJournalPath()) {
-			err = git.Init(cfg.GetJournalPath())
-			if err != nil {
-				return err
-			}
+		if err := ensureJournalRepo(); err != nil {
+			return err
 		}
 
 		db, err := storage.NewDBStore(cfg.GetDBPath())
@@ -51,16 +50,37 @@ var addCmd = &cobra.Command{
 
 		syncer := sync.NewSyncer(cfg.GetJournalPath(), db)
 		svc := service.NewJournalService(fs, db, syncer)
+		committer, err := startCommitter(svc)
+		if err != nil {
+			return err
+		}
+		defer stopCommitter(committer)
 
 		entryType := inferEntryType(entryTypeFlags)
 		entryContent := args[0]
+		if addDue != "" {
+			if entryType != models.EntryTypeTask {
+				return fmt.Errorf("only tasks can have a due date")
+			}
+			if _, err := time.Parse(time.DateOnly, addDue); err != nil {
+				return fmt.Errorf("invalid due date %q, use YYYY-MM-DD", addDue)
+			}
+			switch existing := models.ParseDueDate(entryContent); existing {
+			case "":
+				entryContent += " due:" + addDue
+			case addDue:
+			default:
+				return fmt.Errorf("the text already says due:%s", existing)
+			}
+		}
 
-		entry, err := svc.AddEntry(entryContent, entryType, time.Now())
+		entry, err := svc.AddEntry(entryContent, entryType, clock.Today())
 		if err != nil {
 			return err
 		}
 
 		fmt.Printf("Added %s #%s\n", entryType, entry.ID)
+		autoSync(svc)
 		return nil
 	},
 }
@@ -74,7 +94,7 @@ func inferEntryType(args EntryTypeFlags) models.EntryType {
 	case args.isNote:
 		return models.EntryTypeNote
 	default:
-		return models.EntryTypeTask
+		return models.EntryType(cfg.Journal.GetDefaultEntryType())
 	}
 }
 
@@ -83,6 +103,8 @@ func init() {
 	addCmd.Flags().BoolVar(&entryTypeFlags.isEvent, "event", false, "Add an event")
 	addCmd.Flags().BoolVar(&entryTypeFlags.isNote, "note", false, "Add a note")
 
+	addCmd.Flags().StringVar(&addDue, "due", "", "deadline for a task (YYYY-MM-DD)")
+
 	addCmd.MarkFlagsMutuallyExclusive("task", "event", "note")
 
 	rootCmd.AddCommand(addCmd)
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/samakintunde/bujo/internal/clock"
+	"github.com/samakintunde/bujo/internal/config"
+	"github.com/samakintunde/bujo/internal/service"
+	"github.com/samakintunde/bujo/internal/storage"
+	"github.com/samakintunde/bujo/internal/sync"
+	"github.com/spf13/cobra"
+)
+
+var agendaDays int
+
+var agendaCmd = &cobra.Command{
+	Use:   "agenda",
+	Short: "Show today across all journals",
+	Long:  "Show today's events and open tasks, and upcoming deadlines, in every configured journal. Journals other than the one in use are shown as of the last time they were opened.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := initializeConfig(cmd)
+		if err != nil {
+			return err
+		}
+
+		db, err := storage.NewDBStore(cfg.GetDBPath())
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		fs, err := storage.NewFSStore(cfg.GetJournalPath())
+		if err != nil {
+			return err
+		}
+		syncer := sync.NewSyncer(cfg.GetJournalPath(), db)
+		if err := syncer.Sync(); err != nil {
+			return err
+		}
+
+		today := clock.Today()
+		agendas, err := journalAgendas(service.NewJournalService(fs, db, syncer), today, agendaDays)
+		if err != nil {
+			return err
+		}
+
+		header := fmt.Sprintf("Agenda (%s):", today.Format("2 January, 2006"))
+		fmt.Printf("%s\n%s\n", header, strings.Repeat("-", len(header)))
+		for _, agenda := range agendas {
+			fmt.Printf("\n[%s]\n", agenda.Journal)
+			if agenda.IsEmpty() {
+				fmt.Println("Nothing on.")
+				continue
+			}
+			for _, e := range agenda.Events {
+				fmt.Println(e.DisplayString())
+			}
+			for _, e := range agenda.Tasks {
+				fmt.Println(e.DisplayString())
+			}
+			if len(agenda.Due) > 0 {
+				fmt.Println("Due soon:")
+				for _, e := range agenda.Due {
+					fmt.Println("  " + formatDeadline(e, today))
+				}
+			}
+		}
+		return nil
+	},
+}
+
+// journalAgendas reads every journal's agenda. Journals other than current
+// are read from their index, so nothing is written to them.
+func journalAgendas(current *service.JournalService, date time.Time, days int) ([]*service.Agenda, error) {
+	names := baseCfg.WorkspaceNames()
+	if len(names) == 0 {
+		names = []string{""}
+	}
+
+	var agendas []*service.Agenda
+	for _, name := range names {
+		c, err := baseCfg.ForWorkspace(name)
+		if err != nil {
+			return nil, err
+		}
+		var agenda *service.Agenda
+		if name == cfg.Workspace {
+			agenda, err = current.GetAgenda(date, days)
+		} else {
+			agenda, err = readAgenda(c, date, days)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("journal %s: %w", c.Path, err)
+		}
+		agenda.Journal = name
+		if agenda.Journal == "" {
+			agenda.Journal = "journal"
+		}
+		agendas = append(agendas, agenda)
+	}
+	return agendas, nil
+}
+
+// readAgenda reads the agenda of a journal that isn't open from its index,
+// without syncing it.
+func readAgenda(c config.Config, date time.Time, days int) (*service.Agenda, error) {
+	db, err := storage.NewDBStore(c.GetDBPath())
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	fs, err := storage.NewFSStore(c.GetJournalPath())
+	if err != nil {
+		return nil, err
+	}
+	return service.NewJournalService(fs, db, nil).ReadAgenda(date, days)
+}
+
+func init() {
+	agendaCmd.Flags().IntVarP(&agendaDays, "days", "d", 7, "how many days ahead to look for deadlines")
+
+	rootCmd.AddCommand(agendaCmd)
+}
@@ -0,0 +1,308 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/samakintunde/bujo/internal/config"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"go.yaml.in/yaml/v3"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Read and change settings",
+	Long: `Read and change settings. Keys are dotted paths into the config file,
+like journal.week_start or workspaces.work.path.`,
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print a setting",
+	Long:  "Print a setting as bujo sees it, from the config file, the environment or the defaults. Prints nothing if it isn't set and has no default.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := readConfig(); err != nil {
+			return err
+		}
+		key := strings.ToLower(args[0])
+		if _, ok := config.KeyType(key); !ok {
+			return unknownKey(key)
+		}
+
+		value := viper.Get(key)
+		if value == nil {
+			return nil
+		}
+		switch reflect.ValueOf(value).Kind() {
+		case reflect.Map, reflect.Slice, reflect.Struct:
+			return printYAML(value)
+		}
+		fmt.Println(value)
+		return nil
+	},
+}
+
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Print every setting",
+	Long:  "Print every setting that is set, from the config file, the environment or the defaults, as YAML",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := readConfig(); err != nil {
+			return err
+		}
+		if file := viper.ConfigFileUsed(); file != "" {
+			fmt.Printf("# %s\n", file)
+		} else {
+			fmt.Println("# no config file")
+		}
+		return printYAML(viper.AllSettings())
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Change a setting in the config file",
+	Long: `Change a setting in the config file, creating the file if needed. Lists
+like journal.status_cycle take comma-separated values. The change is not
+saved if it would make the config invalid.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := readConfig(); err != nil {
+			return err
+		}
+		key := strings.ToLower(args[0])
+		t, ok := config.KeyType(key)
+		if !ok {
+			return unknownKey(key)
+		}
+		value, err := valueNode(key, t, args[1])
+		if err != nil {
+			return err
+		}
+
+		file, err := configFile()
+		if err != nil {
+			return err
+		}
+		before, err := os.ReadFile(file)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		var doc yaml.Node
+		if err := yaml.Unmarshal(before, &doc); err != nil {
+			return fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		if err := setKey(&doc, strings.Split(key, "."), value); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		var after bytes.Buffer
+		if err := encodeYAML(&after, &doc); err != nil {
+			return err
+		}
+
+		// Only complain about problems this change brings in, so that a
+		// broken config can be fixed one setting at a time.
+		if added := slices.DeleteFunc(configProblems(after.Bytes()), func(p string) bool {
+			return slices.Contains(configProblems(before), p)
+		}); len(added) > 0 {
+			return fmt.Errorf("not saved, the config would be invalid:\n%s", strings.Join(added, "\n"))
+		}
+
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(file, after.Bytes(), 0644); err != nil {
+			return err
+		}
+		warnRelayout(key, args[1])
+		return nil
+	},
+}
+
+// warnRelayout tells how to move the existing daily logs when key changes
+// where they go. It runs before viper sees the change, so viper still holds
+// the old layout.
+func warnRelayout(key, value string) {
+	if key != "journal.dir_pattern" && key != "journal.file_pattern" {
+		return
+	}
+	old, err := decodeConfig(viper.GetViper())
+	if err != nil {
+		return
+	}
+	dir, file := old.Journal.GetDirPattern(), old.Journal.GetFilePattern()
+	from := path.Join(dir, file)
+	if key == "journal.dir_pattern" {
+		dir = value
+	} else {
+		file = value
+	}
+	if path.Join(dir, file) == from {
+		return
+	}
+
+	fmt.Fprintln(os.Stderr, "Existing daily logs stay where they are. To move them to the new layout, run:")
+	names := old.WorkspaceNames()
+	if len(names) == 0 {
+		fmt.Fprintf(os.Stderr, "  bujo reindex --relayout --from %s\n", from)
+	}
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  bujo reindex --relayout --from %s --journal %s\n", from, name)
+	}
+}
+
+// encodeYAML writes v indented like the config examples.
+func encodeYAML(w io.Writer, v any) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func printYAML(v any) error {
+	return encodeYAML(os.Stdout, v)
+}
+
+func unknownKey(key string) error {
+	return fmt.Errorf("unknown setting %q", key)
+}
+
+// configFile is the file settings are written to: the one in use, or the
+// default location if there is none yet.
+func configFile() (string, error) {
+	if file := viper.ConfigFileUsed(); file != "" {
+		return file, nil
+	}
+	if cfgFilePath != "" {
+		return cfgFilePath, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "bujo", "config.yaml"), nil
+}
+
+// configProblems lists what Validate finds wrong with a config file's
+// content, one problem per entry.
+func configProblems(content []byte) []string {
+	v := viper.New()
+	v.SetConfigType("yaml")
+	v.SetDefault("path", viper.GetString("path"))
+	if err := v.ReadConfig(bytes.NewReader(content)); err != nil {
+		return []string{err.Error()}
+	}
+	c, err := decodeConfig(v)
+	if err == nil {
+		err = c.Validate()
+	}
+	if err == nil {
+		return nil
+	}
+	return strings.Split(err.Error(), "\n")
+}
+
+// valueNode parses raw as the value of key, of type t.
+func valueNode(key string, t reflect.Type, raw string) (*yaml.Node, error) {
+	scalar := func(tag, value string) *yaml.Node {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: value}
+	}
+	switch {
+	case t == reflect.TypeOf(time.Duration(0)):
+		if _, err := time.ParseDuration(raw); err != nil {
+			return nil, fmt.Errorf("%s: %q is not a duration like 30s or 5m", key, raw)
+		}
+		return scalar("!!str", raw), nil
+	case t.Kind() == reflect.String:
+		return scalar("!!str", raw), nil
+	case t.Kind() == reflect.Int:
+		if _, err := strconv.Atoi(raw); err != nil {
+			return nil, fmt.Errorf("%s: %q is not a whole number", key, raw)
+		}
+		return scalar("!!int", raw), nil
+	case t.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %q is not true or false", key, raw)
+		}
+		return scalar("!!bool", strconv.FormatBool(b)), nil
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.String:
+		list := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Style: yaml.FlowStyle}
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list.Content = append(list.Content, scalar("!!str", item))
+			}
+		}
+		return list, nil
+	case t.Kind() == reflect.Slice:
+		return nil, fmt.Errorf("%s is a list of settings, edit the config file to change it", key)
+	default:
+		return nil, fmt.Errorf("%s is a section, set one of its keys like %s.<key>", key, key)
+	}
+}
+
+// setKey sets the value at path in a YAML document, adding the mappings on
+// the way as needed and keeping comments.
+func setKey(doc *yaml.Node, path []string, value *yaml.Node) error {
+	if doc.Kind == 0 {
+		doc.Kind = yaml.DocumentNode
+		doc.Content = []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}
+	}
+	m := doc.Content[0]
+	for i, part := range path {
+		if m.Kind == yaml.ScalarNode && m.Tag == "!!null" {
+			*m = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		}
+		if m.Kind != yaml.MappingNode {
+			if i == 0 {
+				return errors.New("the file is not a YAML mapping")
+			}
+			return errors.New(strings.Join(path[:i], ".") + " is not a section")
+		}
+
+		var child *yaml.Node
+		for j := 0; j+1 < len(m.Content); j += 2 {
+			if strings.EqualFold(m.Content[j].Value, part) {
+				child = m.Content[j+1]
+				break
+			}
+		}
+		if i == len(path)-1 {
+			if child != nil {
+				value.HeadComment, value.LineComment, value.FootComment = child.HeadComment, child.LineComment, child.FootComment
+				*child = *value
+			} else {
+				m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: part}, value)
+			}
+			return nil
+		}
+		if child == nil {
+			child = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: part}, child)
+		}
+		m = child
+	}
+	return nil
+}
+
+func init() {
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configListCmd)
+	rootCmd.AddCommand(configCmd)
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/samakintunde/bujo/internal/storage"
+	"github.com/samakintunde/bujo/internal/sync"
+	"github.com/spf13/cobra"
+)
+
+var doctorFix bool
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the journal and its index for problems",
+	Long: `Check the journal for duplicate IDs, malformed metadata comments, parents
+that don't exist or form a cycle, and an index that doesn't match the files.
+
+Exits with an error when it finds problems, unless --fix repairs them. Copies
+get fresh IDs, broken metadata is rewritten keeping the fields that can still
+be read, bad parent links are removed, and the index is rebuilt.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := initializeConfig(cmd)
+		if err != nil {
+			return err
+		}
+
+		db, err := storage.NewDBStore(cfg.GetDBPath())
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		syncer := sync.NewSyncer(cfg.GetJournalPath(), db)
+		diagnosis, err := syncer.Diagnose()
+		if err != nil {
+			return err
+		}
+		if len(diagnosis.Problems) == 0 {
+			fmt.Println("No problems found")
+			return nil
+		}
+
+		for _, p := range diagnosis.Problems {
+			where := p.Path
+			if rel, err := filepath.Rel(cfg.GetJournalPath(), p.Path); err == nil {
+				where = rel
+			}
+			if p.Line > 0 {
+				where = fmt.Sprintf("%s:%d", where, p.Line)
+			}
+			detail := p.Detail
+			if p.ID != "" && p.Kind == sync.ProblemDuplicateID {
+				detail = "#" + p.ID + " " + detail
+			}
+			fmt.Printf("%s  %s: %s\n", where, p.Kind, detail)
+		}
+
+		if !doctorFix {
+			fmt.Println()
+			return fmt.Errorf("%d problem(s) found; run \"bujo doctor --fix\" to repair them", len(diagnosis.Problems))
+		}
+		if err := syncer.Repair(diagnosis); err != nil {
+			return err
+		}
+		fmt.Printf("\nRepaired %d problem(s)\n", len(diagnosis.Problems))
+		return nil
+	},
+}
+
+func init() {
+	doctorCmd.Flags().BoolVar(&doctorFix, "fix", false, "repair the problems found")
+
+	rootCmd.AddCommand(doctorCmd)
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/samakintunde/bujo/internal/clock"
+	"github.com/samakintunde/bujo/internal/models"
+	"github.com/samakintunde/bujo/internal/service"
+	"github.com/samakintunde/bujo/internal/storage"
+	"github.com/samakintunde/bujo/internal/sync"
+	"github.com/spf13/cobra"
+)
+
+var dueDays int
+
+var dueCmd = &cobra.Command{
+	Use:   "due",
+	Short: "List upcoming deadlines",
+	Long:  "List open tasks that are overdue or due in the next few days, across every day and collection",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := initializeConfig(cmd)
+		if err != nil {
+			return err
+		}
+
+		db, err := storage.NewDBStore(cfg.GetDBPath())
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		fs, err := storage.NewFSStore(cfg.GetJournalPath())
+		if err != nil {
+			return err
+		}
+
+		syncer := sync.NewSyncer(cfg.GetJournalPath(), db)
+		if err := syncer.Sync(); err != nil {
+			return err
+		}
+		svc := service.NewJournalService(fs, db, syncer)
+
+		today := clock.Today()
+		entries, err := svc.GetDeadlines(today.AddDate(0, 0, dueDays))
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			fmt.Printf("Nothing due in the next %d days\n", dueDays)
+			return nil
+		}
+
+		for _, e := range entries {
+			fmt.Println(formatDeadline(e, today))
+		}
+		return nil
+	},
+}
+
+// formatDeadline describes a task by when it is due, relative to today.
+func formatDeadline(e models.Entry, today time.Time) string {
+	label := e.DueDate
+	switch {
+	case e.IsOverdue(today):
+		label += " overdue"
+	case e.IsDueOn(today):
+		label += " today"
+	default:
+		due, _ := time.Parse(time.DateOnly, e.DueDate)
+		label += " " + due.Format("Mon")
+	}
+	return fmt.Sprintf("%-18s %s  (on %s, #%s)", label, e.Content, e.Date.Format(time.DateOnly), e.ID)
+}
+
+func init() {
+	dueCmd.Flags().IntVarP(&dueDays, "days", "d", 7, "how many days ahead to look")
+
+	rootCmd.AddCommand(dueCmd)
+}
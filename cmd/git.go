@@ -0,0 +1,227 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/samakintunde/bujo/internal/git"
+	"github.com/samakintunde/bujo/internal/service"
+	"github.com/samakintunde/bujo/internal/storage"
+	"github.com/samakintunde/bujo/internal/sync"
+	"github.com/spf13/cobra"
+)
+
+var gitCmd = &cobra.Command{
+	Use:   "git",
+	Short: "Version control for the journal",
+	Long:  "Manage the git repository that versions the journal",
+}
+
+var gitSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Pull and push the journal",
+	Long:  "Commit pending changes, fetch and integrate the remote branch, push, and re-index the files that changed",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := initializeConfig(cmd)
+		if err != nil {
+			return err
+		}
+
+		db, err := storage.NewDBStore(cfg.GetDBPath())
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		fs, err := storage.NewFSStore(cfg.GetJournalPath())
+		if err != nil {
+			return err
+		}
+
+		syncer := sync.NewSyncer(cfg.GetJournalPath(), db)
+		svc := service.NewJournalService(fs, db, syncer)
+
+		opts := gitSyncOptions()
+		if cmd.Flags().Changed("remote") {
+			opts.Remote, _ = cmd.Flags().GetString("remote")
+		}
+		if cmd.Flags().Changed("branch") {
+			opts.Branch, _ = cmd.Flags().GetString("branch")
+		}
+		if cmd.Flags().Changed("strategy") {
+			opts.Strategy, _ = cmd.Flags().GetString("strategy")
+		}
+
+		result, err := svc.SyncRemote(opts)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Synced with %s/%s: %d files updated\n", opts.Remote, result.Branch, len(result.Changed))
+		return nil
+	},
+}
+
+var gitStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show uncommitted journal changes",
+	Long:  "Summarise the journal repository: its branch, how it compares to the remote, and the journal files not yet committed",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := initializeConfig(cmd)
+		if err != nil {
+			return err
+		}
+
+		db, err := storage.NewDBStore(cfg.GetDBPath())
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		fs, err := storage.NewFSStore(cfg.GetJournalPath())
+		if err != nil {
+			return err
+		}
+
+		syncer := sync.NewSyncer(cfg.GetJournalPath(), db)
+		svc := service.NewJournalService(fs, db, syncer)
+
+		status, err := svc.GitStatus()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case status.Detached:
+			fmt.Println("HEAD is detached: journal changes will not be committed until you check out a branch")
+		case status.Upstream != "":
+			fmt.Printf("On %s, tracking %s (%d ahead, %d behind)\n", status.Branch, status.Upstream, status.Ahead, status.Behind)
+		default:
+			fmt.Printf("On %s, not tracking a remote\n", status.Branch)
+		}
+
+		if len(status.Files) == 0 {
+			fmt.Println("No uncommitted changes")
+			return nil
+		}
+		fmt.Printf("Uncommitted changes (%d):\n", len(status.Files))
+		for _, f := range status.Files {
+			fmt.Printf("  %-10s %s\n", fileStatusLabel(f.Code), f.Path)
+		}
+		return nil
+	},
+}
+
+// fileStatusLabel describes a porcelain status code.
+func fileStatusLabel(code string) string {
+	switch {
+	case code == "??":
+		return "new"
+	case strings.Contains(code, "U") || code == "AA" || code == "DD":
+		return "conflict"
+	case strings.Contains(code, "D"):
+		return "deleted"
+	case strings.Contains(code, "R"):
+		return "renamed"
+	case strings.Contains(code, "A"):
+		return "added"
+	default:
+		return "modified"
+	}
+}
+
+func gitSyncOptions() git.SyncOptions {
+	return git.SyncOptions{
+		Remote:   cfg.Git.GetRemote(),
+		Branch:   cfg.Git.Branch,
+		Strategy: cfg.Git.GetStrategy(),
+	}
+}
+
+// sqliteIgnores keeps the index out of the journal repository should the
+// database be configured to live inside it.
+var sqliteIgnores = []string{"*.sqlite", "*.sqlite-journal", "*.sqlite-wal", "*.sqlite-shm"}
+
+func gitIdentity() git.Identity {
+	return git.Identity{Name: cfg.Git.User.Name, Email: cfg.Git.User.Email}
+}
+
+// ensureJournalRepo makes the journal a git repository whose commits have
+// an author. It only prompts when stdin is a terminal.
+func ensureJournalRepo() error {
+	if !git.IsPresent() || service.CommitPolicy(cfg.Git.Commit.Policy) == service.CommitNever {
+		return nil
+	}
+	dir := cfg.GetJournalPath()
+	if !git.IsRepo(dir) {
+		if err := git.Init(dir, gitIdentity()); err != nil {
+			return err
+		}
+		return git.Ignore(dir, sqliteIgnores...)
+	}
+	if id := gitIdentity(); id.Name != "" || id.Email != "" {
+		return git.EnsureIdentity(dir, id)
+	}
+	return nil
+}
+
+// startCommitter hands the service's commits to a background committer
+// configured under git.commit.
+func startCommitter(svc *service.JournalService) (*service.Committer, error) {
+	c, err := service.NewCommitter(cfg.GetJournalPath(), service.CommitOptions{
+		Policy:       service.CommitPolicy(cfg.Git.Commit.Policy),
+		Debounce:     cfg.Git.Commit.Debounce,
+		Message:      cfg.Git.Commit.Message,
+		BatchMessage: cfg.Git.Commit.BatchMessage,
+	})
+	if err != nil {
+		return nil, err
+	}
+	svc.SetCommitter(c)
+	return c, nil
+}
+
+// stopCommitter commits whatever is still pending. Failures only warn, as
+// the journal itself is already saved.
+func stopCommitter(c *service.Committer) {
+	if err := c.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: failed to commit journal: %v\n", err)
+	}
+}
+
+// autoSyncTimeout bounds each fetch and push of an automatic sync, so that
+// an unreachable remote can't hold bujo up for long.
+const autoSyncTimeout = 30 * time.Second
+
+func autoSyncOptions() git.SyncOptions {
+	opts := gitSyncOptions()
+	opts.Timeout = autoSyncTimeout
+	return opts
+}
+
+// autoSync syncs the journal when git.auto_sync is on, and reports whether
+// it did. Failures only warn so that offline use keeps working.
+func autoSync(svc *service.JournalService) bool {
+	if !cfg.Git.GetAutoSync() {
+		return false
+	}
+	if _, err := svc.SyncRemote(autoSyncOptions()); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		return false
+	}
+	return true
+}
+
+func init() {
+	gitSyncCmd.Flags().String("remote", "", "remote to sync with (default from config, or origin)")
+	gitSyncCmd.Flags().String("branch", "", "branch to sync (default: current branch)")
+	gitSyncCmd.Flags().String("strategy", "", "rebase or merge (default from config, or rebase)")
+
+	gitCmd.AddCommand(gitSyncCmd)
+	gitCmd.AddCommand(gitStatusCmd)
+	rootCmd.AddCommand(gitCmd)
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/samakintunde/bujo/internal/clock"
+	"github.com/samakintunde/bujo/internal/service"
+	"github.com/samakintunde/bujo/internal/storage"
+	"github.com/samakintunde/bujo/internal/sync"
+	"github.com/spf13/cobra"
+)
+
+var historyNoDiff bool
+
+var historyCmd = &cobra.Command{
+	Use:   "history <id>",
+	Short: "Show an entry's history",
+	Long:  "Show when an entry, and the entries it was migrated or scheduled from, was created, changed and moved, from the journal's git log",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := initializeConfig(cmd)
+		if err != nil {
+			return err
+		}
+
+		db, err := storage.NewDBStore(cfg.GetDBPath())
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		fs, err := storage.NewFSStore(cfg.GetJournalPath())
+		if err != nil {
+			return err
+		}
+
+		syncer := sync.NewSyncer(cfg.GetJournalPath(), db)
+		if err := syncer.Sync(); err != nil {
+			return err
+		}
+		svc := service.NewJournalService(fs, db, syncer)
+
+		id := strings.TrimPrefix(args[0], "#")
+		log, err := svc.EntryHistory(id)
+		if err != nil {
+			return err
+		}
+		if len(log) == 0 {
+			fmt.Printf("No history for #%s\n", id)
+			return nil
+		}
+
+		for _, c := range log {
+			fmt.Printf("%s  %s  %s\n", c.Date.In(clock.Location()).Format("2006-01-02 15:04"), c.Hash[:7], service.HistorySummary(c))
+			if historyNoDiff {
+				continue
+			}
+			for _, line := range c.Diff {
+				fmt.Printf("    %s\n", line)
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	historyCmd.Flags().BoolVar(&historyNoDiff, "no-diff", false, "only list the commits")
+
+	rootCmd.AddCommand(historyCmd)
+}
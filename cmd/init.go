@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/samakintunde/bujo/internal/git"
+	"github.com/samakintunde/bujo/internal/service"
+	"github.com/samakintunde/bujo/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+type InitFlags struct {
+	remote      string
+	mergeDriver bool
+}
+
+var initFlags InitFlags
+
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Set up the journal",
+	Long: `Create the journal and database directories, make the journal a git
+repository with an author and a .gitignore for the SQLite files, and
+optionally add a remote and the journal merge driver. Safe to run again.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := initializeConfig(cmd)
+		if err != nil {
+			return err
+		}
+
+		journalPath := cfg.GetJournalPath()
+		if err := os.MkdirAll(journalPath, 0755); err != nil {
+			return err
+		}
+		db, err := storage.NewDBStore(cfg.GetDBPath())
+		if err != nil {
+			return err
+		}
+		db.Close()
+		fmt.Println("Journal:", journalPath)
+		fmt.Println("Database:", cfg.GetDBPath())
+
+		if !git.IsPresent() {
+			fmt.Println("git not found; the journal will not be versioned")
+			return nil
+		}
+		if service.CommitPolicy(cfg.Git.Commit.Policy) == service.CommitNever {
+			fmt.Println("git.commit.policy is never; the journal will not be versioned")
+			return nil
+		}
+
+		if err := ensureJournalRepo(); err != nil {
+			return err
+		}
+		if err := git.Ignore(journalPath, sqliteIgnores...); err != nil {
+			return err
+		}
+		fmt.Println("Git repository ready")
+
+		if initFlags.remote != "" {
+			name := cfg.Git.GetRemote()
+			if git.HasRemote(journalPath, name) {
+				err = git.SetRemoteURL(journalPath, name, initFlags.remote)
+			} else {
+				err = git.AddRemote(journalPath, name, initFlags.remote)
+			}
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Remote %s: %s\n", name, initFlags.remote)
+		}
+
+		if initFlags.mergeDriver {
+			if err := installJournalMergeDriver(); err != nil {
+				return err
+			}
+			fmt.Println("Installed merge driver")
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	initCmd.Flags().StringVar(&initFlags.remote, "remote", "", "URL of the remote to sync with (named by git.remote, default origin)")
+	initCmd.Flags().BoolVar(&initFlags.mergeDriver, "merge-driver", false, "register the journal merge driver")
+
+	rootCmd.AddCommand(initCmd)
+}
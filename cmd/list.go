@@ -6,6 +6,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/samakintunde/bujo/internal/clock"
+	"github.com/samakintunde/bujo/internal/models"
+	"github.com/samakintunde/bujo/internal/service"
 	"github.com/samakintunde/bujo/internal/storage"
 	"github.com/samakintunde/bujo/internal/sync"
 	"github.com/spf13/cobra"
@@ -27,19 +30,18 @@ var listCmd = &cobra.Command{
 		return nil
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		parsedDate := time.Now()
-		date := parsedDate.Format(time.DateOnly)
+		err := initializeConfig(cmd)
+		if err != nil {
+			return err
+		}
+
+		parsedDate := clock.Today()
 		if len(args) > 0 {
 			parsed, err := time.Parse(time.DateOnly, args[0])
 			if err != nil {
 				return err
 			}
 			parsedDate = parsed
-			date = parsed.Format(time.DateOnly)
-		}
-		err := initializeConfig(cmd)
-		if err != nil {
-			return err
 		}
 
 		if err := os.MkdirAll(cfg.GetDBPath(), 0755); err != nil {
@@ -62,9 +64,8 @@ var listCmd = &cobra.Command{
 			return err
 		}
 
-		dayLog := fsStore.GetDayPath(date)
-
-		entries, err := dbStore.GetEntriesByFile(dayLog)
+		svc := service.NewJournalService(fsStore, dbStore, syncer)
+		entries, err := svc.GetEntriesByDate(parsedDate)
 		if err != nil {
 			return err
 		}
@@ -74,6 +75,7 @@ var listCmd = &cobra.Command{
 		var body strings.Builder
 		for _, entry := range entries {
 			body.WriteString(entry.DisplayString())
+			body.WriteString(completedNote(entry))
 			body.WriteString("\n")
 		}
 		fmt.Printf("%s%s\n%s", header, border, body.String())
@@ -81,6 +83,19 @@ var listCmd = &cobra.Command{
 	},
 }
 
+// completedNote says when a completed task was done: the time if it was on
+// the log's day, the date and time otherwise.
+func completedNote(e models.Entry) string {
+	if e.Status != models.EntryStatusCompleted || e.CompletedAt.IsZero() {
+		return ""
+	}
+	done := e.CompletedAt.In(clock.Location())
+	if clock.Day(done).Format(time.DateOnly) == e.Date.Format(time.DateOnly) {
+		return fmt.Sprintf(" (done %s)", clock.TimeOfDay(done))
+	}
+	return fmt.Sprintf(" (done %s)", done.Format("2006-01-02 15:04"))
+}
+
 func init() {
 	rootCmd.AddCommand(listCmd)
 }
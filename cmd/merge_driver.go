@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/samakintunde/bujo/internal/git"
+	"github.com/samakintunde/bujo/internal/merge"
+	"github.com/spf13/cobra"
+)
+
+const mergeDriverName = "bujo"
+
+var installMergeDriver bool
+
+var mergeDriverCmd = &cobra.Command{
+	Use:   "merge-driver <base> <ours> <theirs>",
+	Short: "Merge journal files entry by entry",
+	Long: `Three-way merge for journal files, for use as a git merge driver.
+Entries are matched on their ID, entries added on either side are kept, and
+entries changed on both sides keep the status furthest along
+(completed > cancelled > migrated > scheduled > open). Custom statuses
+rank after open if they are open, and with cancelled if they aren't.
+
+Git calls it as "bujo merge-driver %O %A %B" and expects the result in %A.
+Run "bujo merge-driver --install" to register it for the journal.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if installMergeDriver {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(3)(cmd, args)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if installMergeDriver {
+			if err := initializeConfig(cmd); err != nil {
+				return err
+			}
+			if err := installJournalMergeDriver(); err != nil {
+				return err
+			}
+			fmt.Println("Installed merge driver for", cfg.GetJournalPath())
+			return nil
+		}
+
+		// Custom statuses decide which side wins, so the driver needs the
+		// journal's config like any other command.
+		if err := initializeConfig(cmd); err != nil {
+			return err
+		}
+
+		base, err := os.ReadFile(args[0])
+		if err != nil {
+			return err
+		}
+		ours, err := os.ReadFile(args[1])
+		if err != nil {
+			return err
+		}
+		theirs, err := os.ReadFile(args[2])
+		if err != nil {
+			return err
+		}
+
+		merged := merge.Merge(string(base), string(ours), string(theirs))
+		return os.WriteFile(args[1], []byte(merged), 0644)
+	},
+}
+
+// installJournalMergeDriver registers this binary as the journal's merge
+// driver.
+func installJournalMergeDriver() error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	driver := shellQuote(exe) + " merge-driver %O %A %B"
+	return git.InstallMergeDriver(cfg.GetJournalPath(), mergeDriverName, driver)
+}
+
+// shellQuote quotes s as a single word for sh, which git runs drivers with.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func init() {
+	mergeDriverCmd.Flags().BoolVar(&installMergeDriver, "install", false, "register the driver in the journal's .gitattributes and git config")
+
+	rootCmd.AddCommand(mergeDriverCmd)
+}
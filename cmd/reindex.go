@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/samakintunde/bujo/internal/storage"
+	"github.com/samakintunde/bujo/internal/sync"
+	"github.com/spf13/cobra"
+)
+
+var (
+	reindexRelayout bool
+	reindexFrom     string
+)
+
+var reindexCmd = &cobra.Command{
+	Use:   "reindex",
+	Short: "Rebuild the index from the Markdown files",
+	Long: `Drop the SQLite index and rebuild it from the journal's Markdown files.
+Review history is kept.
+
+With --relayout, daily logs are first moved to where journal.dir_pattern and
+journal.file_pattern put them. --from gives the layout they were written
+with, as the two patterns joined by a slash, like YYYY/MM/DD; logs named
+YYYY-MM-DD.md are found without it.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := initializeConfig(cmd)
+		if err != nil {
+			return err
+		}
+
+		db, err := storage.NewDBStore(cfg.GetDBPath())
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		if reindexRelayout {
+			if err := relayout(); err != nil {
+				return err
+			}
+		} else if reindexFrom != "" {
+			return fmt.Errorf("--from needs --relayout")
+		}
+
+		syncer := sync.NewSyncer(cfg.GetJournalPath(), db)
+		if err := syncer.Reindex(); err != nil {
+			return err
+		}
+		paths, err := db.GetIndexedPaths()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Reindexed %d files\n", len(paths))
+		return nil
+	},
+}
+
+// relayout moves the daily logs to where the configured layout puts them.
+func relayout() error {
+	root := cfg.GetJournalPath()
+	moved, skipped, err := storage.Relayout(root, reindexFrom)
+	for _, m := range moved {
+		fmt.Printf("Moved %s to %s\n", relPath(root, m.From), relPath(root, m.To))
+	}
+	if err != nil {
+		return err
+	}
+	for _, m := range skipped {
+		fmt.Fprintf(os.Stderr, "warning: left %s where it is, %s already exists\n", relPath(root, m.From), relPath(root, m.To))
+	}
+	return nil
+}
+
+func relPath(root, path string) string {
+	if rel, err := filepath.Rel(root, path); err == nil {
+		return rel
+	}
+	return path
+}
+
+func init() {
+	reindexCmd.Flags().BoolVar(&reindexRelayout, "relayout", false, "move daily logs to where the configured layout puts them first")
+	reindexCmd.Flags().StringVar(&reindexFrom, "from", "", "layout the logs were written with, like YYYY/MM/DD (with --relayout)")
+	rootCmd.AddCommand(reindexCmd)
+}
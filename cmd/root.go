@@ -5,9 +5,13 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/samakintunde/bujo/internal/clock"
 	"github.com/samakintunde/bujo/internal/config"
+	"github.com/samakintunde/bujo/internal/models"
+	"github.com/samakintunde/bujo/internal/service"
 	"github.com/samakintunde/bujo/internal/storage"
 	"github.com/samakintunde/bujo/internal/sync"
 	"github.com/samakintunde/bujo/internal/tui"
@@ -15,7 +19,12 @@ import (
 	"github.com/spf13/viper"
 )
 
-var cfg config.Config
+// baseCfg is the config as read; cfg is baseCfg resolved for the journal
+// in use.
+var (
+	baseCfg config.Config
+	cfg     config.Config
+)
 
 var rootCmd = &cobra.Command{
 	Use:   "bujo",
@@ -26,57 +35,167 @@ var rootCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		db, err := storage.NewDBStore(cfg.GetDBPath())
-		if err != nil {
-			return err
-		}
-		fs, err := storage.NewFSStore(cfg.GetJournalPath())
-		if err != nil {
-			return err
-		}
-		syncer := sync.NewSyncer(cfg.GetJournalPath(), db)
-		if err := syncer.Sync(); err != nil {
-			return err
-		}
-
-		app := tui.NewApp(db, fs, syncer)
-		p := tea.NewProgram(app, tea.WithAltScreen())
-		if _, err := p.Run(); err != nil {
-			fmt.Printf("Error running TUI: %v", err)
-			return err
+		// Switching journals in the TUI closes it and reopens it on the
+		// chosen one.
+		for {
+			next, err := runTUI()
+			if err != nil || next == "" {
+				return err
+			}
+			if cfg, err = forWorkspace(next); err != nil {
+				return err
+			}
 		}
-		return nil
 	},
 }
 
+// runTUI runs the TUI on the current journal and returns the journal the
+// user switched to, if any.
+func runTUI() (string, error) {
+	if err := ensureJournalRepo(); err != nil {
+		return "", err
+	}
+	db, err := storage.NewDBStore(cfg.GetDBPath())
+	if err != nil {
+		return "", err
+	}
+	defer db.Close()
+	fs, err := storage.NewFSStore(cfg.GetJournalPath())
+	if err != nil {
+		return "", err
+	}
+	syncer := sync.NewSyncer(cfg.GetJournalPath(), db)
+	svc := service.NewJournalService(fs, db, syncer)
+	committer, err := startCommitter(svc)
+	if err != nil {
+		return "", err
+	}
+	defer stopCommitter(committer)
+	// Pull before the app reads anything, so its edits start from the
+	// pulled files and no commit lands in the middle of the rebase.
+	pulled := autoSync(svc)
+	if err := syncer.Sync(); err != nil {
+		return "", err
+	}
+
+	app := tui.NewApp(&cfg, db, fs, syncer)
+	app.SetCommitter(committer)
+	app.SetJournals(baseCfg.WorkspaceNames(), func() ([]*service.Agenda, error) {
+		return journalAgendas(svc, clock.Today(), agendaDays)
+	})
+	p := tea.NewProgram(app, tea.WithAltScreen())
+	if _, err := p.Run(); err != nil {
+		fmt.Printf("Error running TUI: %v", err)
+		return "", err
+	}
+	// A remote that couldn't be reached on the way in isn't tried again.
+	if pulled {
+		autoSync(svc)
+	}
+	return app.SwitchTo(), nil
+}
+
 func Execute() error {
 	return rootCmd.Execute()
 }
 
 var cfgFilePath string
+var journalName string
 var verbose bool
+var gitName, gitEmail string
 
 func init() {
 	rootCmd.PersistentFlags().StringVar(&cfgFilePath, "config", "", "config file (default location: ./config.yaml, $HOME/.config/bujo/config.yaml)")
+	rootCmd.PersistentFlags().StringVar(&journalName, "journal", "", "workspace to use (config: workspaces, default_workspace)")
 	rootCmd.PersistentFlags().BoolVar(&verbose, "verbose", false, "verbose output")
+	rootCmd.PersistentFlags().StringVar(&gitName, "git-name", "", "author name for journal commits (config: git.user.name)")
+	rootCmd.PersistentFlags().StringVar(&gitEmail, "git-email", "", "author email for journal commits (config: git.user.email)")
+	_ = viper.BindPFlag("git.user.name", rootCmd.PersistentFlags().Lookup("git-name"))
+	_ = viper.BindPFlag("git.user.email", rootCmd.PersistentFlags().Lookup("git-email"))
 }
 
 func initializeConfig(cmd *cobra.Command) error {
+	if err := readConfig(); err != nil {
+		return err
+	}
+
+	var err error
+	baseCfg, err = decodeConfig(viper.GetViper())
+	if err != nil {
+		fmt.Print("unable to decode config")
+		return err
+	}
+	if err := baseCfg.Validate(); err != nil {
+		return invalidConfig(err)
+	}
+	if cfg, err = forWorkspace(journalName); err != nil {
+		return err
+	}
+
+	err = viper.BindPFlags(cmd.Flags())
+	if err != nil {
+		return err
+	}
+
+	if err := configureStatuses(); err != nil {
+		return err
+	}
+	if err := configureClock(); err != nil {
+		return err
+	}
+	if err := configureLayout(); err != nil {
+		return err
+	}
+
+	// TODO: Add a verbose logger
+	if verbose {
+		fmt.Println("Configuration initialized. Using config file:", viper.ConfigFileUsed())
+	}
+
+	return nil
+}
+
+// forWorkspace resolves the config for the named workspace. Flags win over
+// the workspace's own settings, as they do over the top-level ones.
+func forWorkspace(name string) (config.Config, error) {
+	c, err := baseCfg.ForWorkspace(name)
+	if err != nil {
+		return config.Config{}, err
+	}
+	if gitName != "" {
+		c.Git.User.Name = gitName
+	}
+	if gitEmail != "" {
+		c.Git.User.Email = gitEmail
+	}
+	return c, nil
+}
+
+// readConfig loads the config file, environment and defaults into viper,
+// without checking them.
+func readConfig() error {
 	viper.SetEnvPrefix("BUJO")
 	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "*", "-", "*"))
 	viper.AutomaticEnv()
 
+	home, err := os.UserHomeDir()
+	cobra.CheckErr(err)
+	viper.SetDefault("path", filepath.Join(home, ".bujo"))
+	for key, value := range config.Defaults() {
+		viper.SetDefault(key, value)
+	}
+	// The commit defaults belong to the committer.
+	viper.SetDefault("git.commit.policy", string(service.CommitPerAction))
+	viper.SetDefault("git.commit.debounce", service.DefaultCommitDebounce)
+	viper.SetDefault("git.commit.message", service.DefaultCommitMessage)
+	viper.SetDefault("git.commit.batch_message", service.DefaultCommitBatchMessage)
 	if cfgFilePath != "" {
 		viper.SetConfigFile(cfgFilePath)
 	} else {
-		home, err := os.UserHomeDir()
-		cobra.CheckErr(err)
 		viper.AddConfigPath(".")
 		viper.AddConfigPath(filepath.Join(home, ".config", "bujo"))
 		viper.SetConfigName("config")
 		viper.SetConfigType("yaml")
-
-		viper.SetDefault("path", filepath.Join(home, ".bujo"))
 	}
 
 	if err := viper.ReadInConfig(); err != nil {
@@ -85,22 +204,93 @@ func initializeConfig(cmd *cobra.Command) error {
 			return err
 		}
 	}
+	return nil
+}
 
-	err := viper.Unmarshal(&cfg)
-	if err != nil {
-		fmt.Print("unable to decode config")
-		return err
+// decodeConfig reads the config from v, with relative paths taken from the
+// config file's directory.
+func decodeConfig(v *viper.Viper) (config.Config, error) {
+	var c config.Config
+	if err := v.Unmarshal(&c); err != nil {
+		return config.Config{}, err
+	}
+	// Unmarshal drops workspaces with nothing set, like `personal: {}`.
+	for name := range v.GetStringMap("workspaces") {
+		if _, ok := c.Workspaces[name]; !ok {
+			if c.Workspaces == nil {
+				c.Workspaces = make(map[string]config.WorkspaceConfig)
+			}
+			c.Workspaces[name] = config.WorkspaceConfig{}
+		}
 	}
 
-	err = viper.BindPFlags(cmd.Flags())
+	home, err := os.UserHomeDir()
 	if err != nil {
-		return err
+		return config.Config{}, err
+	}
+	file, err := configFile()
+	if err != nil {
+		return config.Config{}, err
 	}
+	dir, err := filepath.Abs(filepath.Dir(file))
+	if err != nil {
+		return config.Config{}, err
+	}
+	c.ResolvePaths(dir, home)
+	return c, nil
+}
 
-	// TODO: Add a verbose logger
-	if verbose {
-		fmt.Println("Configuration initialized. Using config file:", viper.ConfigFileUsed())
+// invalidConfig lists what's wrong with the config, one setting per line.
+func invalidConfig(err error) error {
+	if file := viper.ConfigFileUsed(); file != "" {
+		return fmt.Errorf("invalid config in %s:\n%w", file, err)
 	}
+	return fmt.Errorf("invalid config:\n%w", err)
+}
 
+// configureStatuses registers the custom statuses and cycle from the config
+// before anything reads the journal.
+func configureStatuses() error {
+	var custom []models.StatusDef
+	for _, sc := range cfg.Journal.Statuses {
+		custom = append(custom, models.StatusDef{
+			Status:   models.EntryStatus(sc.Name),
+			Markdown: sc.Markdown,
+			Display:  sc.Display,
+			Open:     sc.Open,
+		})
+	}
+	var cycle []models.EntryStatus
+	for _, name := range cfg.Journal.StatusCycle {
+		cycle = append(cycle, models.EntryStatus(name))
+	}
+	if err := models.ConfigureStatuses(custom, cycle); err != nil {
+		return fmt.Errorf("invalid journal statuses: %w", err)
+	}
+	return nil
+}
+
+// configureClock sets the timezone and rollover hour that decide which day
+// it is, and the day weeks start on.
+func configureClock() error {
+	loc := time.Local
+	if cfg.Journal.Timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(cfg.Journal.Timezone); err != nil {
+			return fmt.Errorf("invalid journal timezone: %w", err)
+		}
+	}
+	if err := clock.Configure(loc, cfg.Journal.RolloverHour); err != nil {
+		return fmt.Errorf("invalid journal rollover_hour: %w", err)
+	}
+	clock.SetWeekStart(cfg.Journal.GetWeekStart())
+	return nil
+}
+
+// configureLayout sets where daily logs live in the journal.
+func configureLayout() error {
+	if err := storage.ConfigureLayout(cfg.Journal.GetDirPattern(), cfg.Journal.GetFilePattern()); err != nil {
+		return fmt.Errorf("invalid journal dir_pattern or file_pattern: %w", err)
+	}
 	return nil
 }
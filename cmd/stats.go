@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/samakintunde/bujo/internal/clock"
+	"github.com/samakintunde/bujo/internal/storage"
+	"github.com/samakintunde/bujo/internal/sync"
+	"github.com/spf13/cobra"
+)
+
+type StatsFlags struct {
+	json   bool
+	weeks  int
+	months int
+	top    int
+}
+
+var statsFlags StatsFlags
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show productivity statistics",
+	Long:  "Show completion rates, migration habits, open task ages and streaks",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := initializeConfig(cmd)
+		if err != nil {
+			return err
+		}
+
+		dbStore, err := storage.NewDBStore(cfg.GetDBPath())
+		if err != nil {
+			return err
+		}
+		defer dbStore.Close()
+
+		syncer := sync.NewSyncer(cfg.GetJournalPath(), dbStore)
+		if err := syncer.Sync(); err != nil {
+			return err
+		}
+
+		stats, err := dbStore.GetStats(storage.StatsOptions{
+			Now:    clock.Now(),
+			Weeks:  statsFlags.weeks,
+			Months: statsFlags.months,
+			Top:    statsFlags.top,
+		})
+		if err != nil {
+			return err
+		}
+
+		if statsFlags.json {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(stats)
+		}
+
+		fmt.Print(formatStats(stats))
+		return nil
+	},
+}
+
+func formatStats(stats *storage.Stats) string {
+	var b strings.Builder
+
+	writeSection := func(title string) {
+		b.WriteString(title + "\n")
+		b.WriteString(strings.Repeat("-", len(title)) + "\n")
+	}
+
+	writePeriods := func(periods []storage.PeriodStats) {
+		if len(periods) == 0 {
+			b.WriteString("No tasks yet.\n")
+		}
+		for _, p := range periods {
+			fmt.Fprintf(&b, "%-10s %3d/%-3d %5.1f%% %s\n",
+				p.Period, p.Completed, p.Total, p.CompletionRate*100, bar(p.CompletionRate, 20))
+		}
+		b.WriteString("\n")
+	}
+
+	writeSection("Completion rate (weekly)")
+	writePeriods(stats.Weekly)
+
+	writeSection("Completion rate (monthly)")
+	writePeriods(stats.Monthly)
+
+	writeSection("Habits")
+	fmt.Fprintf(&b, "Average migrations before completion: %.1f\n", stats.AvgMigrationsBeforeCompletion)
+	fmt.Fprintf(&b, "Average days to complete: %.1f\n", stats.AvgDaysToComplete)
+	fmt.Fprintf(&b, "Current streak: %d days (longest: %d)\n\n", stats.Streak.Current, stats.Streak.Longest)
+
+	writeSection("Open task age")
+	for _, bucket := range stats.OpenTaskAges {
+		fmt.Fprintf(&b, "%-10s %d\n", bucket.Label, bucket.Count)
+	}
+	b.WriteString("\n")
+
+	writeSection("Most rescheduled tasks")
+	if len(stats.MostRescheduled) == 0 {
+		b.WriteString("None. Nice!\n")
+	}
+	for _, t := range stats.MostRescheduled {
+		fmt.Fprintf(&b, "%2d× %s (%s) #%s\n", t.MigrationCount+t.RescheduleCount, t.Content, t.Status, t.ID)
+	}
+
+	return b.String()
+}
+
+func bar(ratio float64, width int) string {
+	filled := int(ratio*float64(width) + 0.5)
+	return strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+}
+
+func init() {
+	statsCmd.Flags().BoolVar(&statsFlags.json, "json", false, "Output as JSON")
+	statsCmd.Flags().IntVar(&statsFlags.weeks, "weeks", 8, "Number of weeks to report")
+	statsCmd.Flags().IntVar(&statsFlags.months, "months", 6, "Number of months to report")
+	statsCmd.Flags().IntVar(&statsFlags.top, "top", 5, "Number of most rescheduled tasks to show")
+
+	rootCmd.AddCommand(statsCmd)
+}
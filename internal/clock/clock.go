@@ -0,0 +1,113 @@
+// Package clock decides what day it is in the journal: in the journal's
+// timezone, with a day that may run on past midnight until the rollover
+// hour.
+package clock
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	location     = time.Local
+	rolloverHour = 0
+	weekStart    = time.Monday
+	now          = time.Now
+)
+
+// Configure sets the journal's timezone and the hour, from 0 to 12, at which
+// a new day starts. A rollover of 4 keeps 2am on the previous day.
+func Configure(loc *time.Location, rollover int) error {
+	if loc == nil {
+		loc = time.Local
+	}
+	if rollover < 0 || rollover > 12 {
+		return fmt.Errorf("rollover hour %d is outside 0-12", rollover)
+	}
+	location, rolloverHour = loc, rollover
+	return nil
+}
+
+// SetWeekStart sets the day weeks start on.
+func SetWeekStart(day time.Weekday) {
+	weekStart = day
+}
+
+// WeekStart is the day weeks start on, Monday unless set.
+func WeekStart() time.Weekday {
+	return weekStart
+}
+
+// WeekdayIndex is how many days into its week t's weekday is, 0 on the day
+// weeks start on.
+func WeekdayIndex(t time.Time) int {
+	return (int(t.Weekday()) - int(weekStart) + 7) % 7
+}
+
+// Location is the journal's timezone.
+func Location() *time.Location {
+	return location
+}
+
+// Now is the current time in the journal's timezone.
+func Now() time.Time {
+	return now().In(location)
+}
+
+// Today is the journal day it is now, at midnight.
+func Today() time.Time {
+	return Day(now())
+}
+
+// Day is the journal day t falls on, at midnight in the journal's timezone.
+func Day(t time.Time) time.Time {
+	t = t.In(location)
+	if t.Hour() < rolloverHour {
+		t = t.AddDate(0, 0, -1)
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, location)
+}
+
+// IsToday reports whether t falls on the current journal day.
+func IsToday(t time.Time) bool {
+	return SameDay(t, now())
+}
+
+// SameDay reports whether a and b fall on the same journal day.
+func SameDay(a, b time.Time) bool {
+	return Day(a).Equal(Day(b))
+}
+
+// TimeOfDay formats t as "15:04" on its journal day, so 01:30 before the
+// rollover is "25:30".
+func TimeOfDay(t time.Time) string {
+	t = t.In(location)
+	hour := t.Hour()
+	if hour < rolloverHour {
+		hour += 24
+	}
+	return fmt.Sprintf("%02d:%02d", hour, t.Minute())
+}
+
+// Minutes returns how many minutes into the journal day a "15:04" time is;
+// "01:30" and "25:30" are the same.
+func Minutes(hhmm string) (int, bool) {
+	hour, minute, ok := strings.Cut(hhmm, ":")
+	if !ok {
+		return 0, false
+	}
+	h, err := strconv.Atoi(hour)
+	if err != nil || h < 0 || h > 47 {
+		return 0, false
+	}
+	m, err := strconv.Atoi(minute)
+	if err != nil || m < 0 || m > 59 || len(minute) != 2 {
+		return 0, false
+	}
+	if h < rolloverHour {
+		h += 24
+	}
+	return h*60 + m, true
+}
@@ -0,0 +1,110 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func configure(t *testing.T, tz string, rollover int, at time.Time) {
+	t.Helper()
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Configure(loc, rollover); err != nil {
+		t.Fatal(err)
+	}
+	now = func() time.Time { return at }
+	t.Cleanup(func() {
+		_ = Configure(time.Local, 0)
+		now = time.Now
+	})
+}
+
+func TestTodayInTimezone(t *testing.T) {
+	// 02:00 UTC is still the evening before in New York.
+	configure(t, "America/New_York", 0, time.Date(2025, 3, 10, 2, 0, 0, 0, time.UTC))
+
+	if got := Today().Format(time.DateOnly); got != "2025-03-09" {
+		t.Errorf("Today() = %s, want 2025-03-09", got)
+	}
+	if got := Now().Format("15:04"); got != "22:00" {
+		t.Errorf("Now() = %s, want 22:00 local", got)
+	}
+}
+
+func TestRollover(t *testing.T) {
+	configure(t, "UTC", 4, time.Date(2025, 3, 10, 1, 30, 0, 0, time.UTC))
+
+	if got := Today().Format(time.DateOnly); got != "2025-03-09" {
+		t.Errorf("Today() at 01:30 = %s, want the previous day", got)
+	}
+	if got := TimeOfDay(Now()); got != "25:30" {
+		t.Errorf("TimeOfDay() = %s, want 25:30", got)
+	}
+	if !IsToday(time.Date(2025, 3, 9, 18, 0, 0, 0, time.UTC)) {
+		t.Errorf("the evening before should still be today")
+	}
+
+	later := time.Date(2025, 3, 10, 4, 0, 0, 0, time.UTC)
+	if got := Day(later).Format(time.DateOnly); got != "2025-03-10" {
+		t.Errorf("Day() at the rollover = %s, want 2025-03-10", got)
+	}
+	if got := TimeOfDay(later); got != "04:00" {
+		t.Errorf("TimeOfDay() = %s, want 04:00", got)
+	}
+}
+
+func TestMinutes(t *testing.T) {
+	configure(t, "UTC", 4, time.Date(2025, 3, 10, 1, 30, 0, 0, time.UTC))
+
+	tests := []struct {
+		in   string
+		want int
+		ok   bool
+	}{
+		{"04:00", 4 * 60, true},
+		{"23:15", 23*60 + 15, true},
+		{"01:30", 25*60 + 30, true},
+		{"25:30", 25*60 + 30, true},
+		{"9:05", 9*60 + 5, true},
+		{"", 0, false},
+		{"48:00", 0, false},
+		{"12:5", 0, false},
+		{"noon", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := Minutes(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Minutes(%q) = %d, %v, want %d, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+	if got, _ := Minutes(TimeOfDay(Now())); got != 25*60+30 {
+		t.Errorf("Minutes(TimeOfDay()) = %d, want the same key as 01:30", got)
+	}
+}
+
+func TestConfigureRejectsRollover(t *testing.T) {
+	t.Cleanup(func() { _ = Configure(time.Local, 0) })
+	for _, hour := range []int{-1, 13} {
+		if err := Configure(time.UTC, hour); err == nil {
+			t.Errorf("Configure(%d) accepted", hour)
+		}
+	}
+}
+
+func TestWeekdayIndex(t *testing.T) {
+	sunday := time.Date(2025, 3, 9, 0, 0, 0, 0, time.UTC)
+	if got := WeekdayIndex(sunday); got != 6 {
+		t.Errorf("WeekdayIndex(Sunday) = %d, want 6 with weeks starting Monday", got)
+	}
+
+	SetWeekStart(time.Sunday)
+	t.Cleanup(func() { SetWeekStart(time.Monday) })
+	if got := WeekdayIndex(sunday); got != 0 {
+		t.Errorf("WeekdayIndex(Sunday) = %d, want 0 with weeks starting Sunday", got)
+	}
+	if got := WeekdayIndex(sunday.AddDate(0, 0, -1)); got != 6 {
+		t.Errorf("WeekdayIndex(Saturday) = %d, want 6 with weeks starting Sunday", got)
+	}
+}
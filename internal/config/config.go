@@ -1,23 +1,380 @@
 package config
 
-import "path/filepath"
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
 
+const (
+	DefaultMigrationThreshold  = 3
+	DefaultRescheduleThreshold = 3
+	DefaultGitRemote           = "origin"
+	DefaultGitStrategy         = "rebase"
+	DefaultDirPattern          = "YYYY/MM"
+	DefaultFilePattern         = "YYYY-MM-DD"
+	DefaultEntryType           = "task"
+)
+
+// DBConfig locates the database, which also holds review history that
+// can't be rebuilt from the journal.
 type DBConfig struct {
+	// Path is the database's directory. Empty keeps it in <path>/db. With
+	// workspaces, each gets a directory named after it inside Path.
+	Path string `mapstructure:"path" yaml:"path"`
 }
 
 type JournalConfig struct {
+	// Statuses adds task statuses to the built-in ones.
+	Statuses []StatusConfig `mapstructure:"statuses" yaml:"statuses"`
+	// StatusCycle is the order the TUI steps a task through. Empty uses
+	// open, completed, cancelled.
+	StatusCycle []string `mapstructure:"status_cycle" yaml:"status_cycle"`
+	// Timezone is the IANA name of the timezone days are counted in. Empty
+	// uses the system's.
+	Timezone string `mapstructure:"timezone" yaml:"timezone"`
+	// RolloverHour is when a new day starts, from 0 (midnight) to 12. Until
+	// then, entries still go to the previous day.
+	RolloverHour int `mapstructure:"rollover_hour" yaml:"rollover_hour"`
+	// DirPattern and FilePattern place a day's log, with YYYY, MM and DD for
+	// the date. A DirPattern of "." keeps logs in the journal's root.
+	DirPattern  string `mapstructure:"dir_pattern" yaml:"dir_pattern"`
+	FilePattern string `mapstructure:"file_pattern" yaml:"file_pattern"`
+	// WeekStart is the day weeks start on, e.g. sunday. Empty uses monday.
+	WeekStart string `mapstructure:"week_start" yaml:"week_start"`
+	// DefaultEntryType is what an entry added without a type is: task
+	// (default), event or note.
+	DefaultEntryType string `mapstructure:"default_entry_type" yaml:"default_entry_type"`
+}
+
+// StatusConfig is a custom task status, written as "- [<Markdown>]". Open
+// statuses are still to be done; others count as done.
+type StatusConfig struct {
+	Name     string `mapstructure:"name" yaml:"name"`
+	Markdown string `mapstructure:"markdown" yaml:"markdown"`
+	Display  string `mapstructure:"display" yaml:"display"`
+	Open     bool   `mapstructure:"open" yaml:"open"`
+}
+
+// ReviewScope is a choice in the review scope picker. Days of 0 reviews all
+// stale tasks, 1 the last active day, and N the last N days.
+type ReviewScope struct {
+	Label string `mapstructure:"label" yaml:"label"`
+	Days  int    `mapstructure:"days" yaml:"days"`
+}
+
+// MaxReviewScopes is the number of scopes that fit on the 1-9 keys.
+const MaxReviewScopes = 9
+
+var DefaultReviewScopes = []ReviewScope{
+	{Label: "Last active day", Days: 1},
+	{Label: "Last 2 days", Days: 2},
+	{Label: "Last week", Days: 7},
+	{Label: "All stale", Days: 0},
+}
+
+type ReviewConfig struct {
+	// A task migrated or rescheduled at least this many times is chronic and
+	// needs an explicit decision in review. Zero uses the default.
+	MigrationThreshold  int `mapstructure:"migration_threshold" yaml:"migration_threshold"`
+	RescheduleThreshold int `mapstructure:"reschedule_threshold" yaml:"reschedule_threshold"`
+
+	Scopes []ReviewScope `mapstructure:"scopes" yaml:"scopes"`
+
+	// Optional Markdown file each finished review is appended to. Relative
+	// paths are resolved against the journal directory.
+	LogFile string `mapstructure:"log_file" yaml:"log_file"`
+}
+
+type GitConfig struct {
+	// Remote to sync with. Defaults to origin.
+	Remote string `mapstructure:"remote" yaml:"remote"`
+	// Branch to sync. Empty means the journal's current branch.
+	Branch string `mapstructure:"branch" yaml:"branch"`
+	// Strategy for integrating remote changes: rebase (default) or merge.
+	Strategy string `mapstructure:"strategy" yaml:"strategy"`
+	// AutoSync syncs when the TUI opens and closes and after `bujo add`.
+	// Unset is off, but lets a workspace inherit the top-level setting.
+	AutoSync *bool `mapstructure:"auto_sync" yaml:"auto_sync"`
+
+	Commit GitCommitConfig `mapstructure:"commit" yaml:"commit"`
+	// User is the author of journal commits. Empty keeps git's own identity.
+	User GitUserConfig `mapstructure:"user" yaml:"user"`
+}
+
+type GitUserConfig struct {
+	Name  string `mapstructure:"name" yaml:"name"`
+	Email string `mapstructure:"email" yaml:"email"`
+}
+
+type GitCommitConfig struct {
+	// Policy is when changes are committed: action (default), debounce,
+	// quit, daily or never.
+	Policy string `mapstructure:"policy" yaml:"policy"`
+	// Debounce is how long the debounce policy waits for more changes.
+	Debounce time.Duration `mapstructure:"debounce" yaml:"debounce"`
+	// Message and BatchMessage are text/template commit messages for a
+	// single change and for several changes at once. Empty uses the default.
+	Message      string `mapstructure:"message" yaml:"message"`
+	BatchMessage string `mapstructure:"batch_message" yaml:"batch_message"`
+}
+
+type ThemeConfig struct {
+	// Accent colours the date, cursor, prompts and priority tasks in the
+	// TUI, as a hex code or ANSI colour number. Empty keeps the default.
+	Accent string `mapstructure:"accent" yaml:"accent"`
+}
+
+// WorkspaceConfig is a named journal. The git and theme settings it sets
+// replace the top-level ones; the rest are inherited.
+type WorkspaceConfig struct {
+	// Path defaults to a directory named after the workspace in the
+	// top-level path.
+	Path  string       `mapstructure:"path" yaml:"path"`
+	Git   *GitConfig   `mapstructure:"git" yaml:"git"`
+	Theme *ThemeConfig `mapstructure:"theme" yaml:"theme"`
+	// Other collects any other settings, for Validate to reject: journal,
+	// review and db settings are shared by all workspaces.
+	Other map[string]any `mapstructure:",remain" yaml:"-"`
 }
 
 type Config struct {
 	Path    string        `mapstructure:"path" yaml:"path"`
 	DB      DBConfig      `mapstructure:"db" yaml:"db"`
 	Journal JournalConfig `mapstructure:"journal" yaml:"journal"`
+	Review  ReviewConfig  `mapstructure:"review" yaml:"review"`
+	Git     GitConfig     `mapstructure:"git" yaml:"git"`
+	Theme   ThemeConfig   `mapstructure:"theme" yaml:"theme"`
+
+	Workspaces map[string]WorkspaceConfig `mapstructure:"workspaces" yaml:"workspaces"`
+	// DefaultWorkspace is used when no workspace is asked for. Empty uses
+	// the first in alphabetical order.
+	DefaultWorkspace string `mapstructure:"default_workspace" yaml:"default_workspace"`
+
+	// Workspace is the workspace this config was resolved for, or empty
+	// without workspaces.
+	Workspace string `mapstructure:"-" yaml:"-"`
+}
+
+// WorkspaceNames lists the configured workspaces in alphabetical order.
+func (cfg *Config) WorkspaceNames() []string {
+	names := make([]string, 0, len(cfg.Workspaces))
+	for name := range cfg.Workspaces {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ForWorkspace returns the config for the named workspace, or the default
+// one if name is empty. Without workspaces the config is returned as is.
+func (cfg Config) ForWorkspace(name string) (Config, error) {
+	// Config keys, and so workspace names, are case-insensitive.
+	name = strings.ToLower(name)
+	if len(cfg.Workspaces) == 0 {
+		if name != "" {
+			return Config{}, fmt.Errorf("unknown journal %q: no workspaces are configured", name)
+		}
+		return cfg, nil
+	}
+	if name == "" {
+		name = strings.ToLower(cfg.DefaultWorkspace)
+	}
+	if name == "" {
+		name = cfg.WorkspaceNames()[0]
+	}
+	ws, ok := cfg.Workspaces[name]
+	if !ok {
+		return Config{}, fmt.Errorf("unknown journal %q (configured: %s)", name, strings.Join(cfg.WorkspaceNames(), ", "))
+	}
+
+	resolved := cfg
+	resolved.Workspace = name
+	resolved.Path = ws.Path
+	if resolved.Path == "" {
+		resolved.Path = filepath.Join(cfg.Path, name)
+	}
+	if ws.Git != nil {
+		resolved.Git = cfg.Git.with(*ws.Git)
+	}
+	if ws.Theme != nil && ws.Theme.Accent != "" {
+		resolved.Theme.Accent = ws.Theme.Accent
+	}
+	if cfg.DB.Path != "" {
+		resolved.DB.Path = filepath.Join(cfg.DB.Path, name)
+	}
+	return resolved, nil
+}
+
+// Defaults maps the settings that fall back to a default when unset, by
+// key, to that default.
+func Defaults() map[string]any {
+	return map[string]any{
+		"journal.dir_pattern":         DefaultDirPattern,
+		"journal.file_pattern":        DefaultFilePattern,
+		"journal.week_start":          "monday",
+		"journal.default_entry_type":  DefaultEntryType,
+		"review.migration_threshold":  DefaultMigrationThreshold,
+		"review.reschedule_threshold": DefaultRescheduleThreshold,
+		"review.scopes":               DefaultReviewScopes,
+		"git.remote":                  DefaultGitRemote,
+		"git.strategy":                DefaultGitStrategy,
+	}
+}
+
+// ResolvePaths makes the configured paths absolute. A leading ~ stands for
+// home, and relative paths are taken from dir, the config file's directory.
+func (cfg *Config) ResolvePaths(dir, home string) {
+	resolve := func(path string) string {
+		switch {
+		case path == "" || filepath.IsAbs(path):
+			return path
+		case path == "~":
+			return home
+		case strings.HasPrefix(path, "~/"):
+			return filepath.Join(home, path[2:])
+		case strings.HasPrefix(path, "~"):
+			// ~user is left for Validate to reject.
+			return path
+		}
+		return filepath.Join(dir, path)
+	}
+	cfg.Path = resolve(cfg.Path)
+	cfg.DB.Path = resolve(cfg.DB.Path)
+	for name, ws := range cfg.Workspaces {
+		ws.Path = resolve(ws.Path)
+		cfg.Workspaces[name] = ws
+	}
 }
 
 func (cfg *Config) GetDBPath() string {
+	if cfg.DB.Path != "" {
+		return cfg.DB.Path
+	}
 	return filepath.Join(cfg.Path, "db")
 }
 
 func (cfg *Config) GetJournalPath() string {
 	return filepath.Join(cfg.Path, "journal")
 }
+
+func (rc *ReviewConfig) GetMigrationThreshold() int {
+	if rc.MigrationThreshold <= 0 {
+		return DefaultMigrationThreshold
+	}
+	return rc.MigrationThreshold
+}
+
+func (rc *ReviewConfig) GetRescheduleThreshold() int {
+	if rc.RescheduleThreshold <= 0 {
+		return DefaultRescheduleThreshold
+	}
+	return rc.RescheduleThreshold
+}
+
+func (rc *ReviewConfig) GetScopes() []ReviewScope {
+	if len(rc.Scopes) == 0 {
+		return DefaultReviewScopes
+	}
+	if len(rc.Scopes) > MaxReviewScopes {
+		return rc.Scopes[:MaxReviewScopes]
+	}
+	return rc.Scopes
+}
+
+// GetReviewLogPath returns the review log location, or "" if logging is off.
+func (cfg *Config) GetReviewLogPath() string {
+	if cfg.Review.LogFile == "" {
+		return ""
+	}
+	if filepath.IsAbs(cfg.Review.LogFile) {
+		return cfg.Review.LogFile
+	}
+	return filepath.Join(cfg.GetJournalPath(), cfg.Review.LogFile)
+}
+
+// with returns gc with the settings over sets in place of its own.
+func (gc GitConfig) with(over GitConfig) GitConfig {
+	set := func(dst *string, value string) {
+		if value != "" {
+			*dst = value
+		}
+	}
+	set(&gc.Remote, over.Remote)
+	set(&gc.Branch, over.Branch)
+	set(&gc.Strategy, over.Strategy)
+	if over.AutoSync != nil {
+		gc.AutoSync = over.AutoSync
+	}
+	set(&gc.Commit.Policy, over.Commit.Policy)
+	if over.Commit.Debounce != 0 {
+		gc.Commit.Debounce = over.Commit.Debounce
+	}
+	set(&gc.Commit.Message, over.Commit.Message)
+	set(&gc.Commit.BatchMessage, over.Commit.BatchMessage)
+	set(&gc.User.Name, over.User.Name)
+	set(&gc.User.Email, over.User.Email)
+	return gc
+}
+
+func (gc *GitConfig) GetAutoSync() bool {
+	return gc.AutoSync != nil && *gc.AutoSync
+}
+
+func (gc *GitConfig) GetRemote() string {
+	if gc.Remote == "" {
+		return DefaultGitRemote
+	}
+	return gc.Remote
+}
+
+func (gc *GitConfig) GetStrategy() string {
+	if gc.Strategy == "" {
+		return DefaultGitStrategy
+	}
+	return gc.Strategy
+}
+
+func (jc *JournalConfig) GetDirPattern() string {
+	if jc.DirPattern == "" {
+		return DefaultDirPattern
+	}
+	return jc.DirPattern
+}
+
+func (jc *JournalConfig) GetFilePattern() string {
+	if jc.FilePattern == "" {
+		return DefaultFilePattern
+	}
+	return jc.FilePattern
+}
+
+// GetWeekStart returns the day weeks start on, Monday unless set.
+func (jc *JournalConfig) GetWeekStart() time.Weekday {
+	day, err := parseWeekday(jc.WeekStart)
+	if err != nil {
+		return time.Monday
+	}
+	return day
+}
+
+func (jc *JournalConfig) GetDefaultEntryType() string {
+	if jc.DefaultEntryType == "" {
+		return DefaultEntryType
+	}
+	return strings.ToLower(jc.DefaultEntryType)
+}
+
+func parseWeekday(name string) (time.Weekday, error) {
+	if name == "" {
+		return time.Monday, nil
+	}
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(name, day.String()) {
+			return day, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown day %q (use monday, tuesday, ... sunday)", name)
+}
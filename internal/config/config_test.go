@@ -2,7 +2,10 @@ package config
 
 import (
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestGetDBPath(t *testing.T) {
@@ -16,6 +19,155 @@ func TestGetDBPath(t *testing.T) {
 	}
 }
 
+func TestGetDBPathOutsideJournal(t *testing.T) {
+	cfg := Config{Path: "/home/user/.bujo", DB: DBConfig{Path: "/home/user/.cache/bujo"}}
+	if got := cfg.GetDBPath(); got != "/home/user/.cache/bujo" {
+		t.Errorf("GetDBPath() = %q, want db.path", got)
+	}
+
+	cfg.Workspaces = map[string]WorkspaceConfig{"work": {}, "home": {}}
+	work, err := cfg.ForWorkspace("work")
+	if err != nil {
+		t.Fatalf("ForWorkspace(\"work\") error: %v", err)
+	}
+	if got, want := work.GetDBPath(), filepath.Join("/home/user/.cache/bujo", "work"); got != want {
+		t.Errorf("workspace GetDBPath() = %q, want %q", got, want)
+	}
+}
+
+func TestJournalDefaults(t *testing.T) {
+	var jc JournalConfig
+	if jc.GetDirPattern() != DefaultDirPattern || jc.GetFilePattern() != DefaultFilePattern {
+		t.Errorf("patterns = %q, %q, want the defaults", jc.GetDirPattern(), jc.GetFilePattern())
+	}
+	if jc.GetWeekStart() != time.Monday {
+		t.Errorf("GetWeekStart() = %v, want Monday", jc.GetWeekStart())
+	}
+	if jc.GetDefaultEntryType() != "task" {
+		t.Errorf("GetDefaultEntryType() = %q, want task", jc.GetDefaultEntryType())
+	}
+
+	jc = JournalConfig{WeekStart: "Sunday", DefaultEntryType: "Note"}
+	if jc.GetWeekStart() != time.Sunday {
+		t.Errorf("GetWeekStart() = %v, want Sunday", jc.GetWeekStart())
+	}
+	if jc.GetDefaultEntryType() != "note" {
+		t.Errorf("GetDefaultEntryType() = %q, want note", jc.GetDefaultEntryType())
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := Config{
+		Path:    "/home/user/.bujo",
+		Journal: JournalConfig{Timezone: "Europe/Berlin", DirPattern: ".", FilePattern: "DD.MM.YYYY", WeekStart: "sunday"},
+		Git:     GitConfig{Strategy: "merge", Commit: GitCommitConfig{Policy: "never"}},
+	}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Validate() = %v, want nil", err)
+	}
+	if err := (&Config{Path: "/home/user/.bujo"}).Validate(); err != nil {
+		t.Errorf("Validate() of the defaults = %v, want nil", err)
+	}
+
+	invalid := Config{
+		Path: "~/.bujo",
+		DB:   DBConfig{Path: "cache/bujo"},
+		Journal: JournalConfig{
+			Timezone:         "Mars/Olympus",
+			RolloverHour:     13,
+			FilePattern:      "YYYY/MM",
+			WeekStart:        "someday",
+			DefaultEntryType: "todo",
+		},
+		Review: ReviewConfig{MigrationThreshold: -1},
+		Git:    GitConfig{Strategy: "squash", Commit: GitCommitConfig{Policy: "hourly"}},
+		Workspaces: map[string]WorkspaceConfig{
+			"work": {Git: &GitConfig{Strategy: "octopus"}, Other: map[string]any{"journal": map[string]any{"timezone": "UTC"}}},
+		},
+		DefaultWorkspace: "home",
+	}
+	err := invalid.Validate()
+	if err == nil {
+		t.Fatal("Validate() = nil, want errors")
+	}
+	for _, key := range []string{
+		"path:", "db.path:", "journal.timezone:", "journal.rollover_hour:", "journal.file_pattern:",
+		"journal.week_start:", "journal.default_entry_type:", "review.migration_threshold:",
+		"git.strategy:", "git.commit.policy:", "workspaces.work.git.strategy:", "workspaces.work.journal:", "default_workspace:",
+	} {
+		if !strings.Contains(err.Error(), "\n"+key) && !strings.HasPrefix(err.Error(), key) {
+			t.Errorf("Validate() = %v, want an error for %s", err, strings.TrimSuffix(key, ":"))
+		}
+	}
+}
+
+func TestKeyType(t *testing.T) {
+	tests := []struct {
+		key  string
+		want reflect.Kind
+		ok   bool
+	}{
+		{"path", reflect.String, true},
+		{"journal.Rollover_Hour", reflect.Int, true},
+		{"journal.status_cycle", reflect.Slice, true},
+		{"git.commit.debounce", reflect.Int64, true},
+		{"workspaces.work.git.auto_sync", reflect.Bool, true},
+		{"journal", reflect.Struct, true},
+		{"journal.nope", 0, false},
+		{"path.more", 0, false},
+		{"workspace", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := KeyType(tt.key)
+		if ok != tt.ok || (ok && got.Kind() != tt.want) {
+			t.Errorf("KeyType(%q) = %v, %v, want %v, %v", tt.key, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestDefaults(t *testing.T) {
+	var cfg Config
+	for key, value := range Defaults() {
+		typ, ok := KeyType(key)
+		if !ok {
+			t.Errorf("Defaults() has unknown key %q", key)
+			continue
+		}
+		if got := reflect.TypeOf(value); got != typ {
+			t.Errorf("Defaults()[%q] is a %v, want %v", key, got, typ)
+		}
+	}
+	// The defaults are what the getters fall back to.
+	if cfg.Journal.GetWeekStart() != time.Monday || cfg.Git.GetStrategy() != Defaults()["git.strategy"] {
+		t.Error("Defaults() disagrees with the getters")
+	}
+}
+
+func TestResolvePaths(t *testing.T) {
+	cfg := Config{
+		Path: "~/.bujo",
+		DB:   DBConfig{Path: "cache"},
+		Workspaces: map[string]WorkspaceConfig{
+			"work":     {Path: "/srv/work"},
+			"personal": {},
+			"other":    {Path: "~someone/bujo"},
+		},
+	}
+	cfg.ResolvePaths("/etc/bujo", "/home/user")
+
+	for _, tt := range []struct{ key, got, want string }{
+		{"path", cfg.Path, "/home/user/.bujo"},
+		{"db.path", cfg.DB.Path, "/etc/bujo/cache"},
+		{"workspaces.work.path", cfg.Workspaces["work"].Path, "/srv/work"},
+		{"workspaces.personal.path", cfg.Workspaces["personal"].Path, ""},
+		{"workspaces.other.path", cfg.Workspaces["other"].Path, "~someone/bujo"},
+	} {
+		if tt.got != filepath.FromSlash(tt.want) {
+			t.Errorf("%s = %q, want %q", tt.key, tt.got, tt.want)
+		}
+	}
+}
+
 func TestGetJournalPath(t *testing.T) {
 	cfg := &Config{Path: "/home/user/.bujo"}
 
@@ -26,3 +178,112 @@ func TestGetJournalPath(t *testing.T) {
 		t.Errorf("GetJournalPath() = %q, want %q", got, want)
 	}
 }
+
+func TestReviewThresholds(t *testing.T) {
+	var rc ReviewConfig
+	if got := rc.GetMigrationThreshold(); got != DefaultMigrationThreshold {
+		t.Errorf("GetMigrationThreshold() = %d, want default %d", got, DefaultMigrationThreshold)
+	}
+	if got := rc.GetRescheduleThreshold(); got != DefaultRescheduleThreshold {
+		t.Errorf("GetRescheduleThreshold() = %d, want default %d", got, DefaultRescheduleThreshold)
+	}
+
+	rc = ReviewConfig{MigrationThreshold: 5, RescheduleThreshold: 2}
+	if got := rc.GetMigrationThreshold(); got != 5 {
+		t.Errorf("GetMigrationThreshold() = %d, want 5", got)
+	}
+	if got := rc.GetRescheduleThreshold(); got != 2 {
+		t.Errorf("GetRescheduleThreshold() = %d, want 2", got)
+	}
+}
+
+func TestReviewScopes(t *testing.T) {
+	var rc ReviewConfig
+	if got := rc.GetScopes(); len(got) != len(DefaultReviewScopes) {
+		t.Errorf("GetScopes() = %v, want defaults", got)
+	}
+
+	for i := 0; i < MaxReviewScopes+2; i++ {
+		rc.Scopes = append(rc.Scopes, ReviewScope{Label: "Scope", Days: i})
+	}
+	if got := rc.GetScopes(); len(got) != MaxReviewScopes {
+		t.Errorf("GetScopes() returned %d scopes, want %d", len(got), MaxReviewScopes)
+	}
+}
+
+func TestGetReviewLogPath(t *testing.T) {
+	cfg := &Config{Path: "/home/user/.bujo"}
+	if got := cfg.GetReviewLogPath(); got != "" {
+		t.Errorf("GetReviewLogPath() = %q, want empty when unset", got)
+	}
+
+	cfg.Review.LogFile = "reviews.md"
+	want := filepath.Join("/home/user/.bujo", "journal", "reviews.md")
+	if got := cfg.GetReviewLogPath(); got != want {
+		t.Errorf("GetReviewLogPath() = %q, want %q", got, want)
+	}
+
+	cfg.Review.LogFile = "/tmp/reviews.md"
+	if got := cfg.GetReviewLogPath(); got != "/tmp/reviews.md" {
+		t.Errorf("GetReviewLogPath() = %q, want absolute path unchanged", got)
+	}
+}
+
+func TestForWorkspace(t *testing.T) {
+	on, off := true, false
+	cfg := Config{
+		Path:  "/home/user/.bujo",
+		Git:   GitConfig{Remote: "origin", AutoSync: &on, User: GitUserConfig{Name: "Ada", Email: "ada@home.example"}},
+		Theme: ThemeConfig{Accent: "#7C3AED"},
+		Workspaces: map[string]WorkspaceConfig{
+			"work": {
+				Path:  "/home/user/work-journal",
+				Git:   &GitConfig{Remote: "company", AutoSync: &off, User: GitUserConfig{Email: "ada@work.example"}},
+				Theme: &ThemeConfig{Accent: "#F59E0B"},
+			},
+			"personal": {},
+		},
+		DefaultWorkspace: "Work",
+	}
+
+	work, err := cfg.ForWorkspace("")
+	if err != nil {
+		t.Fatalf("ForWorkspace(\"\") error: %v", err)
+	}
+	if work.Workspace != "work" || work.Path != "/home/user/work-journal" {
+		t.Errorf("default workspace = %q at %q, want work at /home/user/work-journal", work.Workspace, work.Path)
+	}
+	if work.Git.Remote != "company" || work.Git.GetAutoSync() || work.Theme.Accent != "#F59E0B" {
+		t.Errorf("work git, theme = %+v, %+v, want the workspace's", work.Git, work.Theme)
+	}
+	// Settings the workspace leaves out are inherited.
+	if work.Git.User != (GitUserConfig{Name: "Ada", Email: "ada@work.example"}) {
+		t.Errorf("work git user = %+v, want the top-level name with the workspace's email", work.Git.User)
+	}
+	if *cfg.Git.AutoSync != true {
+		t.Error("ForWorkspace() changed the top-level config")
+	}
+
+	personal, err := cfg.ForWorkspace("Personal")
+	if err != nil {
+		t.Fatalf("ForWorkspace(\"Personal\") error: %v", err)
+	}
+	if personal.Path != filepath.Join("/home/user/.bujo", "personal") {
+		t.Errorf("personal path = %q, want it under the top-level path", personal.Path)
+	}
+	if personal.Git.Remote != "origin" || !personal.Git.GetAutoSync() || personal.Theme.Accent != "#7C3AED" {
+		t.Errorf("personal git, theme = %+v, %+v, want the top-level ones", personal.Git, personal.Theme)
+	}
+
+	if _, err := cfg.ForWorkspace("hobby"); err == nil {
+		t.Error("ForWorkspace(\"hobby\"): want error for an unknown workspace")
+	}
+
+	single := Config{Path: "/home/user/.bujo"}
+	if got, err := single.ForWorkspace(""); err != nil || got.Path != single.Path || got.Workspace != "" {
+		t.Errorf("ForWorkspace(\"\") without workspaces = %+v, %v, want the config as is", got, err)
+	}
+	if _, err := single.ForWorkspace("work"); err == nil {
+		t.Error("ForWorkspace(\"work\") without workspaces: want error")
+	}
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+)
+
+// KeyType returns the type of value key holds, for keys like
+// "journal.timezone" or "workspaces.work.path", and whether key names a
+// setting at all.
+func KeyType(key string) (reflect.Type, bool) {
+	t := reflect.TypeOf(Config{})
+	for _, part := range strings.Split(strings.ToLower(key), ".") {
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		switch t.Kind() {
+		case reflect.Struct:
+			field, ok := fieldByKey(t, part)
+			if !ok {
+				return nil, false
+			}
+			t = field.Type
+		case reflect.Map:
+			if part == "" {
+				return nil, false
+			}
+			t = t.Elem()
+		default:
+			return nil, false
+		}
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t, true
+}
+
+func fieldByKey(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if tag := field.Tag.Get("mapstructure"); tag == key && tag != "-" {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
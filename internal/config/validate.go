@@ -0,0 +1,122 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Validate checks every setting that can't be used as it is, naming each
+// by its config key so the problem is easy to find in the file.
+func (cfg *Config) Validate() error {
+	v := &validator{}
+
+	v.path("path", cfg.Path)
+	v.path("db.path", cfg.DB.Path)
+	if cfg.Path == "" {
+		v.fail("path", "must be set")
+	}
+
+	jc := cfg.Journal
+	if jc.Timezone != "" {
+		if _, err := time.LoadLocation(jc.Timezone); err != nil {
+			v.fail("journal.timezone", "unknown timezone %q (use an IANA name like Europe/Berlin)", jc.Timezone)
+		}
+	}
+	if jc.RolloverHour < 0 || jc.RolloverHour > 12 {
+		v.fail("journal.rollover_hour", "%d is outside 0-12", jc.RolloverHour)
+	}
+	v.dayPattern(jc.GetDirPattern(), jc.GetFilePattern())
+	if _, err := parseWeekday(jc.WeekStart); err != nil {
+		v.fail("journal.week_start", "%v", err)
+	}
+	v.oneOf("journal.default_entry_type", jc.GetDefaultEntryType(), "task", "event", "note")
+
+	rc := cfg.Review
+	if rc.MigrationThreshold < 0 {
+		v.fail("review.migration_threshold", "must not be negative")
+	}
+	if rc.RescheduleThreshold < 0 {
+		v.fail("review.reschedule_threshold", "must not be negative")
+	}
+	for i, scope := range rc.Scopes {
+		if scope.Days < 0 {
+			v.fail(fmt.Sprintf("review.scopes[%d].days", i), "must not be negative")
+		}
+	}
+
+	v.git("git", cfg.Git)
+	for _, name := range cfg.WorkspaceNames() {
+		ws := cfg.Workspaces[name]
+		v.path("workspaces."+name+".path", ws.Path)
+		if ws.Git != nil {
+			v.git("workspaces."+name+".git", *ws.Git)
+		}
+		for _, key := range slices.Sorted(maps.Keys(ws.Other)) {
+			v.fail("workspaces."+name+"."+key, "can't be set per workspace (only path, git and theme can); set it at the top level")
+		}
+	}
+	if cfg.DefaultWorkspace != "" {
+		if _, ok := cfg.Workspaces[strings.ToLower(cfg.DefaultWorkspace)]; !ok {
+			v.fail("default_workspace", "no workspace is named %q", cfg.DefaultWorkspace)
+		}
+	}
+
+	return errors.Join(v.errs...)
+}
+
+type validator struct {
+	errs []error
+}
+
+func (v *validator) fail(key, format string, args ...any) {
+	v.errs = append(v.errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+}
+
+func (v *validator) oneOf(key, value string, allowed ...string) {
+	if !slices.Contains(allowed, value) {
+		v.fail(key, "unknown value %q (use %s)", value, strings.Join(allowed, ", "))
+	}
+}
+
+// path flags paths that ResolvePaths couldn't make absolute, like ~user.
+func (v *validator) path(key, path string) {
+	switch {
+	case path == "":
+	case strings.HasPrefix(path, "~"):
+		v.fail(key, "only ~ and ~/ are expanded, use an absolute path")
+	case !filepath.IsAbs(path):
+		v.fail(key, "%q is not an absolute path", path)
+	}
+}
+
+func (v *validator) git(key string, gc GitConfig) {
+	v.oneOf(key+".strategy", gc.GetStrategy(), "rebase", "merge")
+	if gc.Commit.Policy != "" {
+		v.oneOf(key+".commit.policy", gc.Commit.Policy, "action", "debounce", "quit", "daily", "never")
+	}
+	if gc.Commit.Debounce < 0 {
+		v.fail(key+".commit.debounce", "must not be negative")
+	}
+}
+
+// dayPattern checks that a day's log path holds the whole date, so it can
+// be read back from the path, and that it stays inside the journal.
+func (v *validator) dayPattern(dir, file string) {
+	if strings.ContainsAny(file, `/\`) {
+		v.fail("journal.file_pattern", "%q must not contain a path separator, put directories in journal.dir_pattern", file)
+	}
+	pattern := filepath.Join(dir, file)
+	if !filepath.IsLocal(pattern) {
+		v.fail("journal.dir_pattern", "%q must stay inside the journal directory", dir)
+	}
+	for _, token := range []string{"YYYY", "MM", "DD"} {
+		if !strings.Contains(pattern, token) {
+			v.fail("journal.file_pattern", "%q under journal.dir_pattern %q has no %s, so the date can't be read back from the path", file, dir, token)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrorKind classifies git failures the user can act on.
+type ErrorKind int
+
+const (
+	ErrUnknown ErrorKind = iota
+	ErrNotRepo
+	ErrLocked
+	ErrDetachedHead
+	ErrHookFailed
+	ErrNoIdentity
+	ErrConflict
+	ErrTimeout
+)
+
+func (k ErrorKind) String() string {
+	switch k {
+	case ErrNotRepo:
+		return "not a git repository"
+	case ErrLocked:
+		return "repository is locked"
+	case ErrDetachedHead:
+		return "detached HEAD"
+	case ErrHookFailed:
+		return "hook failed"
+	case ErrNoIdentity:
+		return "no author identity"
+	case ErrConflict:
+		return "unresolved conflict"
+	case ErrTimeout:
+		return "timed out"
+	default:
+		return "git failed"
+	}
+}
+
+// Error is a failed git command together with what git printed on stderr.
+type Error struct {
+	Kind   ErrorKind
+	Args   []string
+	Stderr string
+	Err    error
+}
+
+func (e *Error) Error() string {
+	msg := e.Stderr
+	if msg == "" && e.Err != nil {
+		msg = e.Err.Error()
+	}
+	return fmt.Sprintf("git %s: %s", e.Args[0], msg)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Summary is a one-line description for status bars: the kind when known,
+// otherwise the first line git printed.
+func (e *Error) Summary() string {
+	if e.Kind != ErrUnknown {
+		return fmt.Sprintf("git %s: %s", e.Args[0], e.Kind)
+	}
+	first, _, _ := strings.Cut(e.Error(), "\n")
+	return first
+}
+
+// IsKind reports whether err is a git Error of the given kind.
+func IsKind(err error, kind ErrorKind) bool {
+	var gitErr *Error
+	return errors.As(err, &gitErr) && gitErr.Kind == kind
+}
+
+// hookFailure matches git's reports of a hook rejecting an operation, like
+// "pre-receive hook declined" or "the 'pre-rebase' hook refused to rebase".
+var hookFailure = regexp.MustCompile(`\bhook\b.*\b(declined|failed|refused)\b|\b(pre-commit|commit-msg|pre-push|pre-receive|pre-rebase)\b.*\b(declined|failed|refused|rejected)\b`)
+
+// classify guesses the kind of failure from git's stderr. Hints are left
+// out, as they mention hooks that were merely skipped.
+func classify(stderr string) ErrorKind {
+	var lines []string
+	for _, line := range strings.Split(strings.ToLower(stderr), "\n") {
+		if !strings.HasPrefix(line, "hint:") {
+			lines = append(lines, line)
+		}
+	}
+	s := strings.Join(lines, "\n")
+	switch {
+	case strings.Contains(s, "not a git repository"):
+		return ErrNotRepo
+	case strings.Contains(s, ".lock': file exists"), strings.Contains(s, "index.lock"):
+		return ErrLocked
+	case strings.Contains(s, "detached head"), strings.Contains(s, "not currently on a branch"):
+		return ErrDetachedHead
+	case hookFailure.MatchString(s):
+		return ErrHookFailed
+	case strings.Contains(s, "tell me who you are"), strings.Contains(s, "unable to auto-detect email"):
+		return ErrNoIdentity
+	case strings.Contains(s, "conflict"), strings.Contains(s, "unmerged"):
+		return ErrConflict
+	default:
+		return ErrUnknown
+	}
+}
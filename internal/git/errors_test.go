@@ -0,0 +1,21 @@
+package git
+
+import "testing"
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		stderr string
+		want   ErrorKind
+	}{
+		{"! [remote rejected] main -> main (pre-receive hook declined)", ErrHookFailed},
+		{"error: the 'pre-rebase' hook refused to rebase", ErrHookFailed},
+		{"hint: The '.git/hooks/pre-commit' hook was ignored because it's not set as executable.\nfatal: unable to auto-detect email address", ErrNoIdentity},
+		{"error: pathspec 'webhook.md' did not match any file(s) known to git", ErrUnknown},
+		{"fatal: Unable to create '/j/.git/index.lock': File exists.", ErrLocked},
+	}
+	for _, tt := range tests {
+		if got := classify(tt.stderr); got != tt.want {
+			t.Errorf("classify(%q) = %v, want %v", tt.stderr, got, tt.want)
+		}
+	}
+}
@@ -2,10 +2,14 @@ package git
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
+
+	"github.com/mattn/go-isatty"
 )
 
 func IsPresent() bool {
@@ -18,69 +22,250 @@ func IsRepo(dir string) bool {
 	return err == nil && info.IsDir()
 }
 
-func Init(dir string) error {
-	cmd := exec.Command("git", "init", "-b", "main")
-	cmd.Dir = dir
-	if err := cmd.Run(); err != nil {
+// Identity is the author recorded on journal commits.
+type Identity struct {
+	Name  string
+	Email string
+}
+
+// FallbackIdentity signs commits when no identity is configured. It is
+// never saved, so an identity set later takes over.
+var FallbackIdentity = Identity{Name: "bujo", Email: "bujo@localhost"}
+
+// Init creates a repository at dir, with main as its first branch, and makes
+// sure commits in it have an author.
+func Init(dir string, id Identity) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
 		return err
 	}
-	return ensureUserConfig(dir)
+	if _, err := run(dir, "init", "-b", "main"); err != nil {
+		return err
+	}
+	return EnsureIdentity(dir, id)
 }
 
-func ensureUserConfig(dir string) error {
-	cmd := exec.Command("git", "config", "user.email")
-	cmd.Dir = dir
-	if err := cmd.Run(); err == nil {
+// EnsureIdentity saves id as the repository's author, asking for anything
+// missing on a terminal. Scripts fall back to FallbackIdentity.
+func EnsureIdentity(dir string, id Identity) error {
+	if id.Name != "" {
+		if err := setConfig(dir, "user.name", id.Name); err != nil {
+			return err
+		}
+	}
+	if id.Email != "" {
+		if err := setConfig(dir, "user.email", id.Email); err != nil {
+			return err
+		}
+	}
+
+	name, _ := run(dir, "config", "user.name")
+	email, _ := run(dir, "config", "user.email")
+	if name != "" && email != "" {
+		return nil
+	}
+
+	if !isTerminal(os.Stdin) {
 		return nil
 	}
+	missing := Identity{Name: name, Email: email}
+	if err := promptIdentity(&missing); err != nil {
+		return err
+	}
+	if name == "" && missing.Name != "" {
+		if err := setConfig(dir, "user.name", missing.Name); err != nil {
+			return err
+		}
+	}
+	if email == "" && missing.Email != "" {
+		return setConfig(dir, "user.email", missing.Email)
+	}
+	return nil
+}
+
+// authorEnv returns the environment that fills in FallbackIdentity for the
+// parts of the author git has no value for.
+func authorEnv(dir string) []string {
+	var env []string
+	if name, _ := run(dir, "config", "user.name"); name == "" {
+		env = append(env, "GIT_AUTHOR_NAME="+FallbackIdentity.Name, "GIT_COMMITTER_NAME="+FallbackIdentity.Name)
+	}
+	if email, _ := run(dir, "config", "user.email"); email == "" {
+		env = append(env, "GIT_AUTHOR_EMAIL="+FallbackIdentity.Email, "GIT_COMMITTER_EMAIL="+FallbackIdentity.Email)
+	}
+	return env
+}
+
+// runAsAuthor runs a git command that creates commits, signing them with
+// FallbackIdentity where git has no identity.
+func runAsAuthor(dir string, args ...string) (string, error) {
+	return runEnv(dir, authorEnv(dir), args...)
+}
 
+// promptIdentity asks for the empty fields of id on stdin.
+func promptIdentity(id *Identity) error {
 	fmt.Println("Git user identity not configured.")
 	reader := bufio.NewReader(os.Stdin)
 
-	fmt.Print("Enter your name: ")
-	name, err := reader.ReadString('\n')
-	if err != nil {
-		return err
+	if id.Name == "" {
+		fmt.Print("Enter your name: ")
+		name, err := reader.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		id.Name = strings.TrimSpace(name)
 	}
-	name = strings.TrimSpace(name)
 
-	fmt.Print("Enter your email: ")
-	email, err := reader.ReadString('\n')
-	if err != nil {
-		return err
+	if id.Email == "" {
+		fmt.Print("Enter your email: ")
+		email, err := reader.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		id.Email = strings.TrimSpace(email)
 	}
-	email = strings.TrimSpace(email)
+	return nil
+}
 
-	if err := setConfig(dir, "user.name", name); err != nil {
-		return err
-	}
-	return setConfig(dir, "user.email", email)
+func isTerminal(f *os.File) bool {
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
 }
 
 func setConfig(dir, key, value string) error {
-	cmd := exec.Command("git", "config", key, value)
-	cmd.Dir = dir
-	return cmd.Run()
+	_, err := run(dir, "config", key, value)
+	return err
+}
+
+// Ignore adds patterns missing from the repository's .gitignore.
+func Ignore(dir string, patterns ...string) error {
+	return appendMissingLines(filepath.Join(dir, ".gitignore"), patterns...)
 }
 
+// Commit stages everything under dir and commits it. It refuses to commit
+// on a detached HEAD, where the commit would be lost on the next checkout.
 func Commit(dir string, message string) error {
-	statusCmd := exec.Command("git", "status", "--porcelain")
-	statusCmd.Dir = dir
-	output, err := statusCmd.Output()
+	status, err := run(dir, "status", "--porcelain")
 	if err != nil {
 		return err
 	}
-	if len(output) == 0 {
+	if status == "" {
 		return nil
 	}
 
-	addCmd := exec.Command("git", "add", ".")
-	addCmd.Dir = dir
-	if err := addCmd.Run(); err != nil {
+	if _, err := run(dir, "symbolic-ref", "--quiet", "HEAD"); err != nil {
+		return &Error{Kind: ErrDetachedHead, Args: []string{"commit"}, Stderr: "HEAD is detached; check out a branch", Err: err}
+	}
+
+	if _, err := run(dir, "add", "."); err != nil {
+		return err
+	}
+
+	if _, err := runAsAuthor(dir, "commit", "-m", message); err != nil {
+		var gitErr *Error
+		if errors.As(err, &gitErr) && gitErr.Kind == ErrUnknown && hasCommitHooks(dir) {
+			gitErr.Kind = ErrHookFailed
+		}
 		return err
 	}
+	return nil
+}
+
+// hasCommitHooks reports whether a hook that can reject a commit is
+// installed.
+func hasCommitHooks(dir string) bool {
+	for _, hook := range []string{"pre-commit", "commit-msg", "prepare-commit-msg"} {
+		path, err := run(dir, "rev-parse", "--git-path", "hooks/"+hook)
+		if err != nil {
+			continue
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		if info, err := os.Stat(path); err == nil && info.Mode()&0111 != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// HeadMessage returns the full message of the latest commit.
+func HeadMessage(dir string) (string, error) {
+	return run(dir, "log", "-1", "--format=%B")
+}
+
+// IsPushed reports whether the latest commit is on any remote-tracking
+// branch, in which case it must not be rewritten.
+func IsPushed(dir string) bool {
+	out, err := run(dir, "branch", "--remotes", "--contains", "HEAD")
+	return err == nil && out != ""
+}
 
-	commitCmd := exec.Command("git", "commit", "-m", message)
-	commitCmd.Dir = dir
-	return commitCmd.Run()
+// Amend folds pending changes into the latest commit with a new message.
+func Amend(dir string, message string) error {
+	if _, err := run(dir, "add", "."); err != nil {
+		return err
+	}
+	_, err := runAsAuthor(dir, "commit", "--amend", "--allow-empty", "-m", message)
+	return err
+}
+
+// FileStatus is a changed file in the working tree, with git's two-letter
+// porcelain code (e.g. " M", "??").
+type FileStatus struct {
+	Code string
+	Path string
+}
+
+// RepoStatus summarises the state of a repository.
+type RepoStatus struct {
+	Branch   string
+	Detached bool
+	Upstream string
+	Ahead    int
+	Behind   int
+	Files    []FileStatus
+}
+
+// Status reports the current branch, how it compares to its upstream and the
+// files with uncommitted changes.
+func Status(dir string) (*RepoStatus, error) {
+	out, err := run(dir, "status", "--porcelain=v1", "--branch", "--untracked-files=all")
+	if err != nil {
+		return nil, err
+	}
+
+	status := &RepoStatus{}
+	for _, line := range strings.Split(out, "\n") {
+		if header, ok := strings.CutPrefix(line, "## "); ok {
+			parseBranchHeader(header, status)
+			continue
+		}
+		if len(line) < 4 {
+			continue
+		}
+		status.Files = append(status.Files, FileStatus{Code: line[:2], Path: line[3:]})
+	}
+	return status, nil
+}
+
+// parseBranchHeader reads the "## main...origin/main [ahead 1, behind 2]"
+// line of porcelain status.
+func parseBranchHeader(header string, status *RepoStatus) {
+	if rest, ok := strings.CutPrefix(header, "No commits yet on "); ok {
+		status.Branch = rest
+		return
+	}
+	if strings.HasPrefix(header, "HEAD (no branch)") {
+		status.Detached = true
+		return
+	}
+
+	header, counts, _ := strings.Cut(header, " [")
+	status.Branch, status.Upstream, _ = strings.Cut(header, "...")
+	for _, part := range strings.Split(strings.TrimSuffix(counts, "]"), ", ") {
+		if n, ok := strings.CutPrefix(part, "ahead "); ok {
+			fmt.Sscan(n, &status.Ahead)
+		} else if n, ok := strings.CutPrefix(part, "behind "); ok {
+			fmt.Sscan(n, &status.Behind)
+		}
+	}
 }
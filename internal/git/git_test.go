@@ -1,9 +1,13 @@
 package git
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/samakintunde/bujo/internal/gittest"
 )
 
 func TestIsRepo(t *testing.T) {
@@ -22,3 +26,149 @@ func TestIsRepo(t *testing.T) {
 		t.Error("IsRepo() = false after .git created, want true")
 	}
 }
+
+func TestInitIdentity(t *testing.T) {
+	if !IsPresent() {
+		t.Skip("git not installed")
+	}
+	// Keep the user's own identity out of the way.
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	tests := []struct {
+		name   string
+		id     Identity
+		saved  Identity
+		author string
+	}{
+		{"configured", Identity{Name: "Ada", Email: "ada@example.com"}, Identity{Name: "Ada", Email: "ada@example.com"}, "Ada <ada@example.com>"},
+		{"partial", Identity{Email: "ada@example.com"}, Identity{Email: "ada@example.com"}, FallbackIdentity.Name + " <ada@example.com>"},
+		{"none without a terminal", Identity{}, Identity{}, FallbackIdentity.Name + " <" + FallbackIdentity.Email + ">"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "journal")
+			if err := Init(dir, tt.id); err != nil {
+				t.Fatalf("Init() error = %v", err)
+			}
+			// The fallback signs commits but is never saved.
+			name, _ := run(dir, "config", "user.name")
+			email, _ := run(dir, "config", "user.email")
+			if got := (Identity{Name: name, Email: email}); got != tt.saved {
+				t.Errorf("saved identity = %+v, want %+v", got, tt.saved)
+			}
+
+			gittest.WriteFile(t, dir, "a.md", "a\n")
+			if err := Commit(dir, "add a"); err != nil {
+				t.Fatalf("Commit() error = %v", err)
+			}
+			author, _ := run(dir, "log", "-1", "--format=%an <%ae>")
+			committer, _ := run(dir, "log", "-1", "--format=%cn <%ce>")
+			if author != tt.author || committer != tt.author {
+				t.Errorf("commit by %q, committed by %q, want %q", author, committer, tt.author)
+			}
+		})
+	}
+}
+
+func TestIgnore(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("*.tmp"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := Ignore(dir, "*.sqlite", "*.tmp", "*.sqlite-wal"); err != nil {
+			t.Fatalf("Ignore() error = %v", err)
+		}
+	}
+
+	got, err := os.ReadFile(filepath.Join(dir, ".gitignore"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "*.tmp\n*.sqlite\n*.sqlite-wal\n"; string(got) != want {
+		t.Errorf(".gitignore = %q, want %q", got, want)
+	}
+}
+
+func TestCommitErrors(t *testing.T) {
+	t.Run("locked index", func(t *testing.T) {
+		dir := gittest.NewRepo(t)
+		gittest.WriteFile(t, dir, ".git/index.lock", "")
+		gittest.WriteFile(t, dir, "a.md", "a\n")
+		err := Commit(dir, "add a")
+		if !IsKind(err, ErrLocked) {
+			t.Errorf("Commit() error = %v, want ErrLocked", err)
+		}
+		var gitErr *Error
+		if !errors.As(err, &gitErr) || !strings.Contains(gitErr.Stderr, "index.lock") {
+			t.Errorf("error does not carry git's stderr: %#v", err)
+		}
+	})
+
+	t.Run("detached HEAD", func(t *testing.T) {
+		dir := gittest.NewRepo(t)
+		gittest.WriteFile(t, dir, "a.md", "a\n")
+		if err := Commit(dir, "add a"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := run(dir, "checkout", "--detach"); err != nil {
+			t.Fatal(err)
+		}
+		gittest.WriteFile(t, dir, "b.md", "b\n")
+		if err := Commit(dir, "add b"); !IsKind(err, ErrDetachedHead) {
+			t.Errorf("Commit() error = %v, want ErrDetachedHead", err)
+		}
+	})
+
+	t.Run("failing hook", func(t *testing.T) {
+		dir := gittest.NewRepo(t)
+		gittest.WriteFile(t, dir, ".git/hooks/pre-commit", "#!/bin/sh\nexit 1\n")
+		if err := os.Chmod(filepath.Join(dir, ".git/hooks/pre-commit"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		gittest.WriteFile(t, dir, "a.md", "a\n")
+		if err := Commit(dir, "add a"); !IsKind(err, ErrHookFailed) {
+			t.Errorf("Commit() error = %v, want ErrHookFailed", err)
+		}
+	})
+}
+
+func TestStatus(t *testing.T) {
+	remote := gittest.NewBareRemote(t)
+	dir := gittest.NewClone(t, remote)
+	gittest.WriteFile(t, dir, "2025/03/2025-03-04.md", "- [ ] a\n")
+	if err := Commit(dir, "add a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := run(dir, "push", "--set-upstream", "origin", "main"); err != nil {
+		t.Fatal(err)
+	}
+	gittest.WriteFile(t, dir, "2025/03/2025-03-05.md", "- [ ] b\n")
+	if err := Commit(dir, "add b"); err != nil {
+		t.Fatal(err)
+	}
+	gittest.WriteFile(t, dir, "2025/03/2025-03-04.md", "- [x] a\n")
+	gittest.WriteFile(t, dir, "2025/03/2025-03-06.md", "- [ ] c\n")
+
+	status, err := Status(dir)
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if status.Branch != "main" || status.Upstream != "origin/main" || status.Ahead != 1 || status.Behind != 0 {
+		t.Errorf("branch = %+v", status)
+	}
+	want := []FileStatus{
+		{Code: " M", Path: "2025/03/2025-03-04.md"},
+		{Code: "??", Path: "2025/03/2025-03-06.md"},
+	}
+	if len(status.Files) != len(want) {
+		t.Fatalf("Files = %+v, want %+v", status.Files, want)
+	}
+	for i := range want {
+		if status.Files[i] != want[i] {
+			t.Errorf("Files[%d] = %+v, want %+v", i, status.Files[i], want[i])
+		}
+	}
+}
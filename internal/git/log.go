@@ -0,0 +1,75 @@
+package git
+
+import (
+	"strings"
+	"time"
+)
+
+// LogEntry is a commit that mentions one of the entry IDs being traced.
+type LogEntry struct {
+	Hash    string
+	Date    time.Time
+	Subject string
+	// Diff holds the added and removed lines that mention a traced ID.
+	Diff []string
+}
+
+const (
+	logSep    = "\x1f"
+	logRecord = "\x1e"
+)
+
+// EntryLog returns the commits whose messages mention any of ids as "#id",
+// oldest first, with the diff lines touching those IDs.
+func EntryLog(dir string, ids []string) ([]LogEntry, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	args := []string{"log", "--reverse", "--fixed-strings", "-p", "--unified=0",
+		"--format=" + logRecord + "%H" + logSep + "%aI" + logSep + "%s"}
+	for _, id := range ids {
+		args = append(args, "--grep=#"+id)
+	}
+	out, err := run(dir, args...)
+	if err != nil {
+		return nil, err
+	}
+	if out == "" {
+		return nil, nil
+	}
+
+	var entries []LogEntry
+	for _, record := range strings.Split(out, logRecord) {
+		header, diff, _ := strings.Cut(record, "\n")
+		parts := strings.SplitN(header, logSep, 3)
+		if len(parts) != 3 {
+			continue
+		}
+		date, _ := time.Parse(time.RFC3339, parts[1])
+		entries = append(entries, LogEntry{
+			Hash:    parts[0],
+			Date:    date,
+			Subject: parts[2],
+			Diff:    diffLinesMentioning(diff, ids),
+		})
+	}
+	return entries, nil
+}
+
+func diffLinesMentioning(diff string, ids []string) []string {
+	var lines []string
+	for _, line := range strings.Split(diff, "\n") {
+		if !strings.HasPrefix(line, "+") && !strings.HasPrefix(line, "-") ||
+			strings.HasPrefix(line, "+++") || strings.HasPrefix(line, "---") {
+			continue
+		}
+		for _, id := range ids {
+			if strings.Contains(line, `"id":"`+id+`"`) {
+				lines = append(lines, line)
+				break
+			}
+		}
+	}
+	return lines
+}
@@ -0,0 +1,51 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/samakintunde/bujo/internal/gittest"
+)
+
+func TestEntryLog(t *testing.T) {
+	dir := gittest.NewClone(t, t.TempDir())
+
+	commit := func(content, message string) {
+		t.Helper()
+		gittest.WriteFile(t, dir, "day.md", content)
+		if err := Commit(dir, message); err != nil {
+			t.Fatalf("Commit() error: %v", err)
+		}
+	}
+	commit("- [ ] A <!-- {\"id\":\"A1\"} -->\n", "feat(bujo): add task #A1")
+	commit("- [ ] A <!-- {\"id\":\"A1\"} -->\n- [ ] B <!-- {\"id\":\"B1\"} -->\n", "feat(bujo): add task #B1")
+	commit("- [x] A <!-- {\"id\":\"A1\"} -->\n- [ ] B <!-- {\"id\":\"B1\"} -->\n", "feat(bujo): update task #A1 to completed")
+
+	log, err := EntryLog(dir, []string{"A1"})
+	if err != nil {
+		t.Fatalf("EntryLog() error: %v", err)
+	}
+	if len(log) != 2 {
+		t.Fatalf("EntryLog() returned %d commits, want 2", len(log))
+	}
+	if log[0].Subject != "feat(bujo): add task #A1" {
+		t.Errorf("log[0].Subject = %q, want the add commit first", log[0].Subject)
+	}
+	if len(log[0].Diff) != 1 || log[0].Diff[0] != `+- [ ] A <!-- {"id":"A1"} -->` {
+		t.Errorf("log[0].Diff = %q, want only its own added line", log[0].Diff)
+	}
+	want := []string{
+		`-- [ ] A <!-- {"id":"A1"} -->`,
+		`+- [x] A <!-- {"id":"A1"} -->`,
+	}
+	if len(log[1].Diff) != 2 || log[1].Diff[0] != want[0] || log[1].Diff[1] != want[1] {
+		t.Errorf("log[1].Diff = %q, want %q", log[1].Diff, want)
+	}
+
+	log, err = EntryLog(dir, []string{"A1", "B1"})
+	if err != nil {
+		t.Fatalf("EntryLog() error: %v", err)
+	}
+	if len(log) != 3 {
+		t.Errorf("EntryLog() for both IDs returned %d commits, want 3", len(log))
+	}
+}
@@ -0,0 +1,243 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	StrategyRebase = "rebase"
+	StrategyMerge  = "merge"
+)
+
+// SyncOptions says where and how to synchronise a journal repository. An
+// empty Branch means the current one; Timeout bounds each fetch and push.
+type SyncOptions struct {
+	Remote   string
+	Branch   string
+	Strategy string
+	Timeout  time.Duration
+}
+
+// SyncResult lists the files that changed locally because of the pull,
+// relative to the repository root.
+type SyncResult struct {
+	Branch  string
+	Changed []string
+	Pushed  bool
+}
+
+// Sync commits pending changes, fetches the remote, integrates the remote
+// branch by rebasing or merging, and pushes the result.
+func Sync(dir string, opts SyncOptions) (*SyncResult, error) {
+	switch opts.Strategy {
+	case StrategyRebase, StrategyMerge, "":
+	default:
+		return nil, fmt.Errorf("unknown sync strategy %q (use rebase or merge)", opts.Strategy)
+	}
+	if !HasRemote(dir, opts.Remote) {
+		return nil, fmt.Errorf("remote %q is not configured", opts.Remote)
+	}
+
+	branch := opts.Branch
+	if branch == "" {
+		current, err := CurrentBranch(dir)
+		if err != nil {
+			return nil, err
+		}
+		branch = current
+	}
+
+	if err := Commit(dir, "chore(bujo): sync"); err != nil {
+		return nil, fmt.Errorf("commit pending changes: %w", err)
+	}
+
+	if _, err := runRemote(dir, opts.Timeout, "fetch", opts.Remote); err != nil {
+		return nil, err
+	}
+
+	result := &SyncResult{Branch: branch}
+	before, _ := run(dir, "rev-parse", "HEAD")
+
+	upstream := opts.Remote + "/" + branch
+	if _, err := run(dir, "rev-parse", "--verify", "--quiet", upstream); err == nil {
+		if err := integrate(dir, upstream, opts.Strategy); err != nil {
+			return nil, err
+		}
+		changed, err := changedFiles(dir, before)
+		if err != nil {
+			return nil, err
+		}
+		result.Changed = changed
+	}
+
+	if _, err := run(dir, "rev-parse", "--verify", "--quiet", "HEAD"); err != nil {
+		return result, nil
+	}
+	if _, err := runRemote(dir, opts.Timeout, "push", "--set-upstream", opts.Remote, "HEAD:"+branch); err != nil {
+		return nil, err
+	}
+	result.Pushed = true
+
+	return result, nil
+}
+
+// integrate brings upstream into the current branch. A branch without
+// commits simply adopts upstream; journals started separately on two
+// machines are allowed to merge.
+func integrate(dir, upstream, strategy string) error {
+	if _, err := run(dir, "rev-parse", "--verify", "--quiet", "HEAD"); err != nil {
+		_, err := run(dir, "reset", "--hard", upstream)
+		return err
+	}
+
+	switch strategy {
+	case StrategyMerge:
+		if _, err := runAsAuthor(dir, "merge", "--no-edit", "--allow-unrelated-histories", upstream); err != nil {
+			_, _ = run(dir, "merge", "--abort")
+			return err
+		}
+	default:
+		if _, err := runAsAuthor(dir, "rebase", upstream); err != nil {
+			_, _ = run(dir, "rebase", "--abort")
+			return err
+		}
+	}
+	return nil
+}
+
+// changedFiles lists files that differ between before and HEAD. An empty
+// before (no commits yet) lists every tracked file.
+func changedFiles(dir, before string) ([]string, error) {
+	var out string
+	var err error
+	if before == "" {
+		out, err = run(dir, "ls-files")
+	} else {
+		out, err = run(dir, "diff", "--name-only", before, "HEAD")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if out == "" {
+		return nil, nil
+	}
+	return strings.Split(out, "\n"), nil
+}
+
+func HasRemote(dir, remote string) bool {
+	_, err := run(dir, "remote", "get-url", remote)
+	return err == nil
+}
+
+func AddRemote(dir, name, url string) error {
+	_, err := run(dir, "remote", "add", name, url)
+	return err
+}
+
+func SetRemoteURL(dir, name, url string) error {
+	_, err := run(dir, "remote", "set-url", name, url)
+	return err
+}
+
+func CurrentBranch(dir string) (string, error) {
+	return run(dir, "symbolic-ref", "--short", "HEAD")
+}
+
+// run executes git in dir and returns its trimmed stdout. Failures are an
+// *Error carrying git's stderr so the cause is visible to the user.
+func run(dir string, args ...string) (string, error) {
+	return runEnv(dir, nil, args...)
+}
+
+// runEnv is run with env added to the environment.
+func runEnv(dir string, env []string, args ...string) (string, error) {
+	return runContext(context.Background(), dir, env, args...)
+}
+
+// runRemote runs a command that talks to a remote, killing it after a
+// non-zero timeout. git may not prompt.
+func runRemote(dir string, timeout time.Duration, args ...string) (string, error) {
+	if timeout <= 0 {
+		return run(dir, args...)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return runContext(ctx, dir, []string{"GIT_TERMINAL_PROMPT=0"}, args...)
+}
+
+func runContext(ctx context.Context, dir string, env []string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	if env != nil {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	// ssh may outlive a killed git and keep its output open.
+	cmd.WaitDelay = time.Second
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if ctx.Err() != nil {
+			return "", &Error{Kind: ErrTimeout, Args: args, Stderr: msg, Err: fmt.Errorf("timed out: %w", ctx.Err())}
+		}
+		return "", &Error{Kind: classify(msg), Args: args, Stderr: msg, Err: err}
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// InstallMergeDriver registers driver as the merge driver for Markdown
+// files in the repository at dir.
+func InstallMergeDriver(dir, name, driver string) error {
+	if _, err := run(dir, "config", "merge."+name+".name", "bujo journal entries"); err != nil {
+		return err
+	}
+	if _, err := run(dir, "config", "merge."+name+".driver", driver); err != nil {
+		return err
+	}
+
+	return appendMissingLines(filepath.Join(dir, ".gitattributes"), "*.md merge="+name)
+}
+
+// appendMissingLines appends each line that path does not already contain,
+// creating the file if needed.
+func appendMissingLines(path string, lines ...string) error {
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	present := make(map[string]bool)
+	for _, l := range strings.Split(string(existing), "\n") {
+		present[strings.TrimSpace(l)] = true
+	}
+
+	var missing []string
+	for _, l := range lines {
+		if !present[l] {
+			missing = append(missing, l)
+			present[l] = true
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	text := strings.Join(missing, "\n") + "\n"
+	if len(existing) > 0 && !strings.HasSuffix(string(existing), "\n") {
+		text = "\n" + text
+	}
+	_, err = f.WriteString(text)
+	return err
+}
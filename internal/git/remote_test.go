@@ -0,0 +1,129 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/samakintunde/bujo/internal/gittest"
+)
+
+func TestSync(t *testing.T) {
+	for _, strategy := range []string{StrategyRebase, StrategyMerge} {
+		t.Run(strategy, func(t *testing.T) {
+			remote := gittest.NewBareRemote(t)
+			laptop := gittest.NewClone(t, remote)
+			desktop := gittest.NewClone(t, remote)
+			opts := SyncOptions{Remote: "origin", Strategy: strategy}
+
+			gittest.WriteFile(t, laptop, "2024/03/2024-03-01.md", "- [ ] from laptop\n")
+			result, err := Sync(laptop, opts)
+			if err != nil {
+				t.Fatalf("laptop Sync() error: %v", err)
+			}
+			if !result.Pushed || len(result.Changed) != 0 {
+				t.Errorf("first sync = %+v, want pushed with nothing pulled", result)
+			}
+
+			gittest.WriteFile(t, desktop, "2024/03/2024-03-02.md", "- [ ] from desktop\n")
+			result, err = Sync(desktop, opts)
+			if err != nil {
+				t.Fatalf("desktop Sync() error: %v", err)
+			}
+			if len(result.Changed) != 1 || result.Changed[0] != "2024/03/2024-03-01.md" {
+				t.Errorf("desktop pulled %v, want [2024/03/2024-03-01.md]", result.Changed)
+			}
+
+			result, err = Sync(laptop, opts)
+			if err != nil {
+				t.Fatalf("laptop second Sync() error: %v", err)
+			}
+			if len(result.Changed) != 1 || result.Changed[0] != "2024/03/2024-03-02.md" {
+				t.Errorf("laptop pulled %v, want [2024/03/2024-03-02.md]", result.Changed)
+			}
+			if _, err := os.Stat(filepath.Join(laptop, "2024/03/2024-03-02.md")); err != nil {
+				t.Errorf("desktop file missing on laptop: %v", err)
+			}
+		})
+	}
+}
+
+func TestSyncConflictAborts(t *testing.T) {
+	remote := gittest.NewBareRemote(t)
+	laptop := gittest.NewClone(t, remote)
+	desktop := gittest.NewClone(t, remote)
+	opts := SyncOptions{Remote: "origin"}
+
+	gittest.WriteFile(t, laptop, "day.md", "- [ ] shared\n")
+	if _, err := Sync(laptop, opts); err != nil {
+		t.Fatalf("Sync() error: %v", err)
+	}
+	if _, err := Sync(desktop, opts); err != nil {
+		t.Fatalf("Sync() error: %v", err)
+	}
+
+	gittest.WriteFile(t, laptop, "day.md", "- [x] shared\n")
+	if _, err := Sync(laptop, opts); err != nil {
+		t.Fatalf("Sync() error: %v", err)
+	}
+	gittest.WriteFile(t, desktop, "day.md", "- [-] shared\n")
+	if _, err := Sync(desktop, opts); err == nil {
+		t.Fatal("Sync() with conflicting edits should fail")
+	}
+
+	if _, err := os.Stat(filepath.Join(desktop, ".git", "rebase-merge")); !os.IsNotExist(err) {
+		t.Error("failed rebase should be aborted")
+	}
+	content, _ := os.ReadFile(filepath.Join(desktop, "day.md"))
+	if string(content) != "- [-] shared\n" {
+		t.Errorf("local edit lost after aborted sync: %q", content)
+	}
+}
+
+func TestSyncWithoutRemote(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := run(dir, "init", "-b", "main"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Sync(dir, SyncOptions{Remote: "origin"}); err == nil {
+		t.Error("Sync() without a remote should fail")
+	}
+}
+
+func TestSyncTimesOut(t *testing.T) {
+	// A remote that never answers, like one behind a dead network.
+	dir := gittest.NewClone(t, "ext::sleep 30")
+	if _, err := run(dir, "config", "protocol.ext.allow", "always"); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	_, err := Sync(dir, SyncOptions{Remote: "origin", Branch: "main", Timeout: 100 * time.Millisecond})
+	if !IsKind(err, ErrTimeout) {
+		t.Fatalf("Sync() error = %v, want a timeout", err)
+	}
+	if took := time.Since(start); took > 5*time.Second {
+		t.Errorf("Sync() took %s to give up", took)
+	}
+}
+
+func TestInstallMergeDriver(t *testing.T) {
+	dir := gittest.NewClone(t, t.TempDir())
+	gittest.WriteFile(t, dir, ".gitattributes", "*.png binary")
+
+	for range 2 {
+		if err := InstallMergeDriver(dir, "bujo", "bujo merge-driver %O %A %B"); err != nil {
+			t.Fatalf("InstallMergeDriver() error: %v", err)
+		}
+	}
+
+	attrs, _ := os.ReadFile(filepath.Join(dir, ".gitattributes"))
+	if string(attrs) != "*.png binary\n*.md merge=bujo\n" {
+		t.Errorf(".gitattributes = %q", attrs)
+	}
+	driver, err := run(dir, "config", "merge.bujo.driver")
+	if err != nil || driver != "bujo merge-driver %O %A %B" {
+		t.Errorf("merge.bujo.driver = %q, %v", driver, err)
+	}
+}
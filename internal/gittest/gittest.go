@@ -0,0 +1,73 @@
+// Package gittest sets up git repositories for tests.
+package gittest
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// Run runs git in dir and returns its output, failing the test on error.
+func Run(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return string(out)
+}
+
+// Init makes dir a repository on main with a test identity. It skips the
+// test when git isn't installed.
+func Init(t *testing.T, dir string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	Run(t, dir, "init", "-b", "main")
+	Run(t, dir, "config", "user.name", "Test")
+	Run(t, dir, "config", "user.email", "test@example.com")
+}
+
+// NewRepo returns a new repository in a temporary directory.
+func NewRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	Init(t, dir)
+	return dir
+}
+
+// NewClone returns a new repository with remote as origin and nothing
+// fetched yet.
+func NewClone(t *testing.T, remote string) string {
+	t.Helper()
+	dir := NewRepo(t)
+	Run(t, dir, "remote", "add", "origin", remote)
+	return dir
+}
+
+// NewBareRemote returns an empty bare repository to push to.
+func NewBareRemote(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	Run(t, dir, "init", "--bare", "-b", "main")
+	return dir
+}
+
+// WriteFile writes content to name under dir, creating its directories.
+func WriteFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
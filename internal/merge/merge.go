@@ -0,0 +1,168 @@
+// Package merge implements a three-way merge of journal files that works
+// entry by entry instead of line by line, so that two machines appending to
+// the same day never produce conflict markers.
+package merge
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/samakintunde/bujo/internal/models"
+	"github.com/samakintunde/bujo/internal/parser"
+)
+
+// statusRank orders the built-in task statuses by how far along they are.
+// When both sides change the same entry, the one further along wins.
+var statusRank = map[models.EntryStatus]int{
+	models.EntryStatusOpen:      0,
+	models.EntryStatusScheduled: 2,
+	models.EntryStatusMigrated:  3,
+	models.EntryStatusCancelled: 4,
+	models.EntryStatusCompleted: 5,
+}
+
+// rank places a custom status by whether it is open: open ones just past
+// open, closed ones level with cancelled. Unknown statuses rank as open.
+func rank(status models.EntryStatus) int {
+	if r, ok := statusRank[status]; ok {
+		return r
+	}
+	def, ok := models.LookupStatus(status)
+	switch {
+	case !ok:
+		return statusRank[models.EntryStatusOpen]
+	case def.Open:
+		return statusRank[models.EntryStatusOpen] + 1
+	default:
+		return statusRank[models.EntryStatusCancelled]
+	}
+}
+
+type item struct {
+	key   string
+	line  string
+	entry models.Entry
+}
+
+// Merge combines ours and theirs, both derived from base, matching entries
+// on their ID and other lines on their text. It never conflicts.
+func Merge(base, ours, theirs string) string {
+	baseItems := index(split(base))
+	ourItems := split(ours)
+	theirItems := split(theirs)
+	theirIndex := index(theirItems)
+
+	var result []item
+	placed := make(map[string]int)
+	for _, o := range ourItems {
+		t, inTheirs := theirIndex[o.key]
+		b, inBase := baseItems[o.key]
+		switch {
+		case inTheirs:
+			o = resolve(b, o, t, inBase)
+		case inBase && b.line == o.line:
+			// They deleted it and we didn't touch it.
+			continue
+		}
+		placed[o.key] = len(result)
+		result = append(result, o)
+	}
+
+	anchor := -1
+	for _, t := range theirItems {
+		if pos, ok := placed[t.key]; ok {
+			anchor = pos
+			continue
+		}
+		if b, inBase := baseItems[t.key]; inBase && b.line == t.line {
+			// We deleted it and they didn't touch it.
+			continue
+		}
+
+		at := anchor + 1
+		result = append(result, item{})
+		copy(result[at+1:], result[at:])
+		result[at] = t
+		for key, pos := range placed {
+			if pos >= at {
+				placed[key] = pos + 1
+			}
+		}
+		placed[t.key] = at
+		anchor = at
+	}
+
+	lines := make([]string, len(result))
+	for i, it := range result {
+		lines[i] = it.line
+	}
+	merged := strings.Join(lines, "\n")
+	if strings.HasSuffix(ours, "\n") || strings.HasSuffix(theirs, "\n") {
+		merged += "\n"
+	}
+	return merged
+}
+
+// resolve picks the version of an entry present on both sides.
+func resolve(base, ours, theirs item, inBase bool) item {
+	switch {
+	case ours.line == theirs.line:
+		return ours
+	case inBase && ours.line == base.line:
+		return theirs
+	case inBase && theirs.line == base.line:
+		return ours
+	}
+
+	// Both changed it. Prefer the status further along, then the entry moved
+	// more often, then the larger line so both sides agree.
+	or, tr := rank(ours.entry.Status), rank(theirs.entry.Status)
+	if or != tr {
+		if or > tr {
+			return ours
+		}
+		return theirs
+	}
+	om := ours.entry.MigrationCount + ours.entry.RescheduleCount
+	tm := theirs.entry.MigrationCount + theirs.entry.RescheduleCount
+	if om != tm {
+		if om > tm {
+			return ours
+		}
+		return theirs
+	}
+	if ours.line >= theirs.line {
+		return ours
+	}
+	return theirs
+}
+
+// split parses content into keyed lines. Entries are keyed on their ID;
+// anything else on its text and how many times that text occurred before.
+func split(content string) []item {
+	content = strings.TrimSuffix(content, "\n")
+	if content == "" {
+		return nil
+	}
+
+	seen := make(map[string]int)
+	var items []item
+	for _, line := range strings.Split(content, "\n") {
+		entry := parser.ParseLine(line)
+		key := "id:" + entry.ID
+		if entry.Type == models.EntryTypeIgnore || entry.ID == "" {
+			key = fmt.Sprintf("line:%d:%s", seen[line], line)
+			seen[line]++
+		}
+		items = append(items, item{key: key, line: line, entry: entry})
+	}
+	return items
+}
+
+func index(items []item) map[string]item {
+	m := make(map[string]item, len(items))
+	for _, it := range items {
+		m[it.key] = it
+	}
+	return m
+}
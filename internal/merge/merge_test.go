@@ -0,0 +1,127 @@
+package merge
+
+import (
+	"testing"
+
+	"github.com/samakintunde/bujo/internal/models"
+)
+
+func line(status, content, id string) string {
+	return "- [" + status + "] " + content + ` <!-- {"id":"` + id + `"} -->`
+}
+
+func TestMerge(t *testing.T) {
+	a := line(" ", "A", "a")
+	b := line(" ", "B", "b")
+	c := line(" ", "C", "c")
+	d := line(" ", "D", "d")
+
+	tests := []struct {
+		name               string
+		base, ours, theirs string
+		want               string
+	}{
+		{
+			name:   "both appended",
+			base:   a + "\n",
+			ours:   a + "\n" + b + "\n",
+			theirs: a + "\n" + c + "\n",
+			want:   a + "\n" + c + "\n" + b + "\n",
+		},
+		{
+			name:   "theirs inserted in the middle",
+			base:   a + "\n" + b + "\n",
+			ours:   a + "\n" + b + "\n" + d + "\n",
+			theirs: a + "\n" + c + "\n" + b + "\n",
+			want:   a + "\n" + c + "\n" + b + "\n" + d + "\n",
+		},
+		{
+			name:   "one side changed status",
+			base:   a + "\n" + b + "\n",
+			ours:   a + "\n" + b + "\n",
+			theirs: line("x", "A", "a") + "\n" + b + "\n",
+			want:   line("x", "A", "a") + "\n" + b + "\n",
+		},
+		{
+			name:   "status conflict keeps completed",
+			base:   a + "\n",
+			ours:   line("x", "A", "a") + "\n",
+			theirs: line(">", "A", "a") + "\n",
+			want:   line("x", "A", "a") + "\n",
+		},
+		{
+			name:   "status conflict is symmetric",
+			base:   a + "\n",
+			ours:   line(">", "A", "a") + "\n",
+			theirs: line("x", "A", "a") + "\n",
+			want:   line("x", "A", "a") + "\n",
+		},
+		{
+			name:   "unchanged entry deleted on one side",
+			base:   a + "\n" + b + "\n",
+			ours:   a + "\n" + b + "\n",
+			theirs: a + "\n",
+			want:   a + "\n",
+		},
+		{
+			name:   "deleted entry changed on the other side is kept",
+			base:   a + "\n" + b + "\n",
+			ours:   a + "\n",
+			theirs: a + "\n" + line("x", "B", "b") + "\n",
+			want:   a + "\n" + line("x", "B", "b") + "\n",
+		},
+		{
+			name:   "headings and blank lines are kept once",
+			base:   "# Monday\n\n" + a + "\n",
+			ours:   "# Monday\n\n" + a + "\n" + b + "\n",
+			theirs: "# Monday\n\n" + a + "\n" + c + "\n",
+			want:   "# Monday\n\n" + a + "\n" + c + "\n" + b + "\n",
+		},
+		{
+			name:   "both added to a new file",
+			base:   "",
+			ours:   a + "\n",
+			theirs: b + "\n",
+			want:   b + "\n" + a + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Merge(tt.base, tt.ours, tt.theirs); got != tt.want {
+				t.Errorf("Merge() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeCustomStatuses(t *testing.T) {
+	custom := []models.StatusDef{
+		{Status: "waiting", Markdown: "w", Display: "w", Open: true},
+		{Status: "delegated", Markdown: "D", Display: "D"},
+	}
+	if err := models.ConfigureStatuses(custom, nil); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = models.ConfigureStatuses(nil, nil) })
+
+	base := line(" ", "A", "a") + "\n"
+	tests := []struct {
+		name         string
+		ours, theirs string
+		want         string
+	}{
+		{"closed custom beats open custom", line("w", "A", "a"), line("D", "A", "a"), line("D", "A", "a")},
+		{"scheduled beats open custom", line("w", "A", "a"), line("<", "A", "a"), line("<", "A", "a")},
+		{"completed beats closed custom", line("D", "A", "a"), line("x", "A", "a"), line("x", "A", "a")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, sides := range [][2]string{{tt.ours, tt.theirs}, {tt.theirs, tt.ours}} {
+				if got := Merge(base, sides[0]+"\n", sides[1]+"\n"); got != tt.want+"\n" {
+					t.Errorf("Merge() = %q, want %q", got, tt.want+"\n")
+				}
+			}
+		})
+	}
+}
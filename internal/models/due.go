@@ -0,0 +1,34 @@
+package models
+
+import (
+	"regexp"
+	"time"
+)
+
+// Matches a deadline in content: due:2025-03-07
+var dueRegex = regexp.MustCompile(`\bdue:(\d{4}-\d{2}-\d{2})\b`)
+
+// ParseDueDate returns the first valid due:YYYY-MM-DD date in content, or "".
+func ParseDueDate(content string) string {
+	for _, m := range dueRegex.FindAllStringSubmatch(content, -1) {
+		if _, err := time.Parse(time.DateOnly, m[1]); err == nil {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+// IsOpenTask reports whether the entry is a task still waiting to be done.
+func (e *Entry) IsOpenTask() bool {
+	return e.Type == EntryTypeTask && e.Status.IsOpen()
+}
+
+// IsOverdue reports whether an open task's deadline is before today.
+func (e *Entry) IsOverdue(today time.Time) bool {
+	return e.IsOpenTask() && e.DueDate != "" && e.DueDate < today.Format(time.DateOnly)
+}
+
+// IsDueOn reports whether an open task's deadline is the given day.
+func (e *Entry) IsDueOn(day time.Time) bool {
+	return e.IsOpenTask() && e.DueDate == day.Format(time.DateOnly)
+}
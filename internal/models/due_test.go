@@ -0,0 +1,62 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseDueDate(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"Submit report due:2025-03-07", "2025-03-07"},
+		{"due:2025-03-07 Submit report", "2025-03-07"},
+		{"Submit report", ""},
+		{"Invalid due:2025-13-40", ""},
+		{"Overdue:2025-03-07 is not a deadline", ""},
+		{"Pick the first due:2025-03-07 due:2025-04-01", "2025-03-07"},
+	}
+	for _, tt := range tests {
+		if got := ParseDueDate(tt.content); got != tt.want {
+			t.Errorf("ParseDueDate(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestDeadlineState(t *testing.T) {
+	today := time.Date(2025, 3, 5, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		entry       Entry
+		wantOverdue bool
+		wantToday   bool
+	}{
+		{"overdue", Entry{Type: EntryTypeTask, Status: EntryStatusOpen, DueDate: "2025-03-04"}, true, false},
+		{"due today", Entry{Type: EntryTypeTask, Status: EntryStatusOpen, DueDate: "2025-03-05"}, false, true},
+		{"upcoming", Entry{Type: EntryTypeTask, Status: EntryStatusOpen, DueDate: "2025-03-06"}, false, false},
+		{"done late", Entry{Type: EntryTypeTask, Status: EntryStatusCompleted, DueDate: "2025-03-04"}, false, false},
+		{"no deadline", Entry{Type: EntryTypeTask, Status: EntryStatusOpen}, false, false},
+	}
+	for _, tt := range tests {
+		if got := tt.entry.IsOverdue(today); got != tt.wantOverdue {
+			t.Errorf("%s: IsOverdue() = %v, want %v", tt.name, got, tt.wantOverdue)
+		}
+		if got := tt.entry.IsDueOn(today); got != tt.wantToday {
+			t.Errorf("%s: IsDueOn() = %v, want %v", tt.name, got, tt.wantToday)
+		}
+	}
+}
+
+func TestDueDateMetadata(t *testing.T) {
+	inContent := Entry{ID: "a", Type: EntryTypeTask, Status: EntryStatusOpen, Content: "Report due:2025-03-07", DueDate: "2025-03-07"}
+	if raw := inContent.RawString(); strings.Contains(raw, `"due"`) {
+		t.Errorf("deadline already in content was duplicated into metadata: %s", raw)
+	}
+
+	inMeta := Entry{ID: "b", Type: EntryTypeTask, Status: EntryStatusOpen, Content: "Report", DueDate: "2025-03-07"}
+	if raw := inMeta.RawString(); !strings.Contains(raw, `"due":"2025-03-07"`) {
+		t.Errorf("deadline missing from metadata: %s", raw)
+	}
+}
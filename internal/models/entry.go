@@ -38,17 +38,46 @@ type Entry struct {
 	MigrationCount  int
 	RescheduleCount int
 	ParentID        string
+	StartTime       string   // event start, "15:04"
+	EndTime         string   // event end, "15:04"
+	DueDate         string   // task deadline, "2006-01-02"
+	Priority        int      // task priority, 0 to MaxPriority
+	Tags            []string // lowercased, without "#"
 	IsDeleted       bool
+	Date            time.Time // day of the log the entry is on
 	CreatedAt       time.Time
 	UpdatedAt       time.Time
+	CompletedAt     time.Time // when a task was completed, if it is
 }
 
 func NewEntry(entryType EntryType, content string) *Entry {
+	now := Timestamp()
 	return &Entry{
-		ID:      id.New(),
-		Type:    entryType,
-		Status:  EntryStatusOpen,
-		Content: content,
+		ID:        id.New(),
+		Type:      entryType,
+		Status:    EntryStatusOpen,
+		Content:   content,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// Timestamp is the current time as it is kept in metadata: in UTC, to the
+// second.
+func Timestamp() time.Time {
+	return time.Now().UTC().Truncate(time.Second)
+}
+
+// SetStatus changes the entry's status and records when. Completing a task
+// records when it was completed; any other status clears it.
+func (e *Entry) SetStatus(status EntryStatus) {
+	now := Timestamp()
+	e.Status = status
+	e.UpdatedAt = now
+	if status != EntryStatusCompleted {
+		e.CompletedAt = time.Time{}
+	} else if e.CompletedAt.IsZero() {
+		e.CompletedAt = now
 	}
 }
 
@@ -57,12 +86,28 @@ func (e *Entry) String() string {
 }
 
 func (e *Entry) Metadata() Metadata {
-	return Metadata{
+	m := Metadata{
 		ID:   e.ID,
 		Mig:  e.MigrationCount,
 		PID:  e.ParentID,
 		Rsch: e.RescheduleCount,
+
+		Created:   formatTimestamp(e.CreatedAt),
+		Updated:   formatTimestamp(e.UpdatedAt),
+		Completed: formatTimestamp(e.CompletedAt),
+	}
+	// A time written in the content is the source of truth; only keep one
+	// in metadata when the content doesn't have it.
+	if e.Type == EntryTypeEvent && e.StartTime != "" {
+		if start, _ := ParseEventTime(e.Content); start == "" {
+			m.Start = e.StartTime
+			m.End = e.EndTime
+		}
 	}
+	if e.Type == EntryTypeTask && e.DueDate != "" && ParseDueDate(e.Content) == "" {
+		m.Due = e.DueDate
+	}
+	return m
 }
 
 func (e *Entry) RawString() string {
@@ -76,20 +121,11 @@ func (e *Entry) DisplayString() string {
 func (e *Entry) getDisplaySignifier() string {
 	switch e.Type {
 	case EntryTypeTask:
-		switch e.Status {
-		case EntryStatusOpen:
-			return "•"
-		case EntryStatusCompleted:
-			return "x"
-		case EntryStatusMigrated:
-			return ">"
-		case EntryStatusCancelled:
-			return "-"
-		case EntryStatusScheduled:
-			return "<"
-		default:
-			return ""
+		if def, ok := LookupStatus(e.Status); ok {
+			return def.Display
 		}
+		mark, _ := CheckboxMark(e.RawContent)
+		return mark
 	case EntryTypeEvent:
 		return "•"
 	case EntryTypeNote:
@@ -102,20 +138,13 @@ func (e *Entry) getDisplaySignifier() string {
 func (e *Entry) getMarkdownSignifier() string {
 	switch e.Type {
 	case EntryTypeTask:
-		switch e.Status {
-		case EntryStatusOpen:
-			return "- [ ]"
-		case EntryStatusCompleted:
-			return "- [x]"
-		case EntryStatusMigrated:
-			return "- [>]"
-		case EntryStatusCancelled:
-			return "- [-]"
-		case EntryStatusScheduled:
-			return "- [<]"
-		default:
-			return "- [ ]"
+		if def, ok := LookupStatus(e.Status); ok {
+			return "- [" + def.Markdown + "]"
+		}
+		if mark, ok := CheckboxMark(e.RawContent); ok {
+			return "- [" + mark + "]"
 		}
+		return "- [ ]"
 	case EntryTypeEvent:
 		return "- *"
 	case EntryTypeNote:
@@ -130,6 +159,43 @@ type Metadata struct {
 	Mig  int    `json:"mig,omitempty"`
 	PID  string `json:"pid,omitempty"`
 	Rsch int    `json:"rsch,omitempty"`
+	// Event start and end times, when they aren't in the content.
+	Start string `json:"st,omitempty"`
+	End   string `json:"et,omitempty"`
+	// Task deadline, when there's no due: in the content.
+	Due string `json:"due,omitempty"`
+	// When the entry was created, last changed and, for tasks, completed, in
+	// RFC 3339.
+	Created   string `json:"ct,omitempty"`
+	Updated   string `json:"ut,omitempty"`
+	Completed string `json:"done,omitempty"`
+}
+
+// Times parses the entry's timestamps. Missing ones are zero.
+func (m Metadata) Times() (created, updated, completed time.Time, err error) {
+	for _, ts := range []struct {
+		value string
+		dest  *time.Time
+	}{
+		{m.Created, &created},
+		{m.Updated, &updated},
+		{m.Completed, &completed},
+	} {
+		if ts.value == "" {
+			continue
+		}
+		if *ts.dest, err = time.Parse(time.RFC3339, ts.value); err != nil {
+			return time.Time{}, time.Time{}, time.Time{}, fmt.Errorf("invalid timestamp %q", ts.value)
+		}
+	}
+	return created, updated, completed, nil
+}
+
+func formatTimestamp(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
 }
 
 func (m Metadata) String() string {
@@ -3,6 +3,7 @@ package models
 import (
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestRawString(t *testing.T) {
@@ -128,3 +129,44 @@ func TestMetadataString_IncludesAllFields(t *testing.T) {
 		t.Errorf("String() = %q, missing HTML comment wrapper", got)
 	}
 }
+
+func TestSetStatus(t *testing.T) {
+	created := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	e := Entry{Type: EntryTypeTask, Status: EntryStatusOpen, CreatedAt: created, UpdatedAt: created}
+
+	e.SetStatus(EntryStatusCompleted)
+	if e.CompletedAt.IsZero() || !e.UpdatedAt.Equal(e.CompletedAt) {
+		t.Errorf("completed: UpdatedAt = %v, CompletedAt = %v, want both set to now", e.UpdatedAt, e.CompletedAt)
+	}
+	if !e.CreatedAt.Equal(created) {
+		t.Errorf("CreatedAt = %v, want %v", e.CreatedAt, created)
+	}
+
+	e.SetStatus(EntryStatusOpen)
+	if !e.CompletedAt.IsZero() {
+		t.Errorf("reopened: CompletedAt = %v, want zero", e.CompletedAt)
+	}
+}
+
+func TestMetadataTimes(t *testing.T) {
+	created := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	completed := time.Date(2024, 1, 16, 17, 30, 0, 0, time.FixedZone("CET", 3600))
+	e := Entry{ID: "abc", CreatedAt: created, UpdatedAt: completed, CompletedAt: completed}
+
+	meta := e.Metadata()
+	if meta.Created != "2024-01-15T09:00:00Z" || meta.Completed != "2024-01-16T16:30:00Z" {
+		t.Errorf("Metadata() = %+v, want UTC RFC 3339 timestamps", meta)
+	}
+
+	gotCreated, gotUpdated, gotCompleted, err := meta.Times()
+	if err != nil {
+		t.Fatalf("Times() error: %v", err)
+	}
+	if !gotCreated.Equal(created) || !gotUpdated.Equal(completed) || !gotCompleted.Equal(completed) {
+		t.Errorf("Times() = %v, %v, %v, want %v, %v, %v", gotCreated, gotUpdated, gotCompleted, created, completed, completed)
+	}
+
+	if _, _, _, err := (Metadata{Created: "yesterday"}).Times(); err == nil {
+		t.Error("Times() with an invalid timestamp: want error")
+	}
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"regexp"
+	"sort"
+)
+
+// MaxPriority is the highest priority a task can have: "!!!".
+const MaxPriority = 3
+
+// Matches a priority marker at the start of content: "* Call", "!! Call"
+var priorityRegex = regexp.MustCompile(`^(\*|!{1,3})(?:\s|$)`)
+
+// ParsePriority reads the priority marker at the start of a task's content.
+// The classic "*" signifier and "!" both mean 1, "!!" 2 and "!!!" 3; no
+// marker is 0.
+func ParsePriority(content string) int {
+	m := priorityRegex.FindStringSubmatch(content)
+	if m == nil {
+		return 0
+	}
+	if m[1] == "*" {
+		return 1
+	}
+	return len(m[1])
+}
+
+// SortTasksByPriority reorders open tasks highest priority first, in the
+// slots they already occupy.
+func SortTasksByPriority(entries []Entry) {
+	var slots []int
+	var tasks []Entry
+	for i, e := range entries {
+		if e.IsOpenTask() {
+			slots = append(slots, i)
+			tasks = append(tasks, e)
+		}
+	}
+	sort.SliceStable(tasks, func(i, j int) bool {
+		return tasks[i].Priority > tasks[j].Priority
+	})
+	for i, slot := range slots {
+		entries[slot] = tasks[i]
+	}
+}
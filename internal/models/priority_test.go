@@ -0,0 +1,48 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParsePriority(t *testing.T) {
+	tests := []struct {
+		content string
+		want    int
+	}{
+		{"* Call the bank", 1},
+		{"! Call the bank", 1},
+		{"!! Call the bank", 2},
+		{"!!! Call the bank", 3},
+		{"!!!! Call the bank", 0},
+		{"Call the bank!", 0},
+		{"*Call* the bank", 0},
+		{"Call the bank", 0},
+	}
+	for _, tt := range tests {
+		if got := ParsePriority(tt.content); got != tt.want {
+			t.Errorf("ParsePriority(%q) = %d, want %d", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestSortTasksByPriority(t *testing.T) {
+	entries := []Entry{
+		{ID: "note", Type: EntryTypeNote},
+		{ID: "plain", Type: EntryTypeTask, Status: EntryStatusOpen},
+		{ID: "done", Type: EntryTypeTask, Status: EntryStatusCompleted, Priority: 3},
+		{ID: "starred", Type: EntryTypeTask, Status: EntryStatusOpen, Priority: 1},
+		{ID: "urgent", Type: EntryTypeTask, Status: EntryStatusOpen, Priority: 3},
+		{ID: "plain2", Type: EntryTypeTask, Status: EntryStatusOpen},
+	}
+	SortTasksByPriority(entries)
+
+	var ids []string
+	for _, e := range entries {
+		ids = append(ids, e.ID)
+	}
+	want := "note urgent done starred plain plain2"
+	if got := strings.Join(ids, " "); got != want {
+		t.Errorf("order = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"github.com/samakintunde/bujo/internal/id"
+)
+
+type ReviewDecisionType string
+
+const (
+	ReviewDecisionMigrated  ReviewDecisionType = "migrated"
+	ReviewDecisionScheduled ReviewDecisionType = "scheduled"
+	ReviewDecisionCompleted ReviewDecisionType = "completed"
+	ReviewDecisionCancelled ReviewDecisionType = "cancelled"
+	ReviewDecisionSomeday   ReviewDecisionType = "someday"
+	ReviewDecisionSkipped   ReviewDecisionType = "skipped"
+)
+
+// ReviewDecision records what happened to one task during a review. Target
+// holds the scheduled date or collection name, when there is one.
+type ReviewDecision struct {
+	EntryID   string
+	Content   string
+	Decision  ReviewDecisionType
+	Target    string
+	DecidedAt time.Time
+}
+
+type ReviewSession struct {
+	ID         string
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Scope      string
+	Filter     string
+	Decisions  []ReviewDecision
+}
+
+func NewReviewSession(scope, filter string) *ReviewSession {
+	return &ReviewSession{
+		ID:        id.New(),
+		StartedAt: time.Now(),
+		Scope:     scope,
+		Filter:    filter,
+	}
+}
+
+// Count returns how many decisions of the given type the session holds.
+func (s *ReviewSession) Count(decision ReviewDecisionType) int {
+	n := 0
+	for _, d := range s.Decisions {
+		if d.Decision == decision {
+			n++
+		}
+	}
+	return n
+}
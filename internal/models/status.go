@@ -0,0 +1,148 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// StatusDef describes a task status, its checkbox character and the
+// signifier it is shown with. Open statuses are still to be done.
+type StatusDef struct {
+	Status   EntryStatus
+	Markdown string
+	Display  string
+	Open     bool
+}
+
+// BuiltinStatuses are the statuses bujo itself acts on. Custom statuses are
+// added after them and can't replace them.
+var BuiltinStatuses = []StatusDef{
+	{EntryStatusOpen, " ", "•", true},
+	{EntryStatusCompleted, "x", "x", false},
+	{EntryStatusMigrated, ">", ">", false},
+	{EntryStatusCancelled, "-", "-", false},
+	{EntryStatusScheduled, "<", "<", false},
+}
+
+// DefaultStatusCycle is the order the TUI steps a task through.
+var DefaultStatusCycle = []EntryStatus{EntryStatusOpen, EntryStatusCompleted, EntryStatusCancelled}
+
+var (
+	statuses    = BuiltinStatuses
+	statusCycle = DefaultStatusCycle
+)
+
+// Statuses returns every known task status, built-in ones first.
+func Statuses() []StatusDef {
+	return statuses
+}
+
+// LookupStatus returns the definition of a status.
+func LookupStatus(status EntryStatus) (StatusDef, bool) {
+	for _, def := range statuses {
+		if def.Status == status {
+			return def, true
+		}
+	}
+	return StatusDef{}, false
+}
+
+// IsOpen reports whether a task with status s is still to be done.
+func (s EntryStatus) IsOpen() bool {
+	def, ok := LookupStatus(s)
+	return ok && def.Open
+}
+
+// OpenStatuses returns the statuses of tasks still to be done.
+func OpenStatuses() []EntryStatus {
+	var open []EntryStatus
+	for _, def := range statuses {
+		if def.Open {
+			open = append(open, def.Status)
+		}
+	}
+	return open
+}
+
+var checkboxRegex = regexp.MustCompile(`^\s*-\s\[(.)\]`)
+
+// CheckboxMark returns the character between the checkbox brackets of a
+// task's raw line. It keeps statuses bujo doesn't know as they were written.
+func CheckboxMark(raw string) (string, bool) {
+	m := checkboxRegex.FindStringSubmatch(raw)
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
+}
+
+// StatusForMarkdown returns the status written as [c] in a checkbox.
+func StatusForMarkdown(c string) (EntryStatus, bool) {
+	for _, def := range statuses {
+		if def.Markdown == c {
+			return def.Status, true
+		}
+	}
+	return "", false
+}
+
+// ConfigureStatuses adds custom statuses to the built-in ones and sets the
+// order the TUI cycles through. An empty cycle keeps the default one.
+func ConfigureStatuses(custom []StatusDef, cycle []EntryStatus) error {
+	all := append([]StatusDef{}, BuiltinStatuses...)
+	for _, def := range custom {
+		def.Status = EntryStatus(strings.TrimSpace(string(def.Status)))
+		if def.Status == "" {
+			return fmt.Errorf("status without a name")
+		}
+		if utf8.RuneCountInString(def.Markdown) != 1 || def.Markdown == "]" {
+			return fmt.Errorf("status %q: markdown must be a single character other than ]", def.Status)
+		}
+		if def.Display == "" {
+			def.Display = def.Markdown
+		}
+		for _, other := range all {
+			if other.Status == def.Status {
+				return fmt.Errorf("status %q is defined twice", def.Status)
+			}
+			if other.Markdown == def.Markdown {
+				return fmt.Errorf("status %q: [%s] already means %q", def.Status, def.Markdown, other.Status)
+			}
+		}
+		all = append(all, def)
+	}
+
+	if len(cycle) == 0 {
+		cycle = DefaultStatusCycle
+	}
+	seen := make(map[EntryStatus]bool)
+	for _, status := range cycle {
+		if seen[status] {
+			return fmt.Errorf("status %q appears twice in the cycle", status)
+		}
+		seen[status] = true
+		found := false
+		for _, def := range all {
+			found = found || def.Status == status
+		}
+		if !found {
+			return fmt.Errorf("unknown status %q in the cycle", status)
+		}
+	}
+
+	statuses, statusCycle = all, cycle
+	return nil
+}
+
+// NextStatus returns the status after s in the cycle. Statuses outside the
+// cycle, like migrated, don't move.
+func NextStatus(s EntryStatus) (EntryStatus, bool) {
+	for i, status := range statusCycle {
+		if status == s {
+			return statusCycle[(i+1)%len(statusCycle)], true
+		}
+	}
+	return "", false
+}
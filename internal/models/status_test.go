@@ -0,0 +1,104 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConfigureStatuses(t *testing.T) {
+	t.Cleanup(func() { _ = ConfigureStatuses(nil, nil) })
+
+	custom := []StatusDef{
+		{Status: "waiting", Markdown: "w"},
+		{Status: "in_progress", Markdown: "/", Display: "◐"},
+	}
+	cycle := []EntryStatus{EntryStatusOpen, "in_progress", "waiting", EntryStatusCompleted}
+	if err := ConfigureStatuses(custom, cycle); err != nil {
+		t.Fatalf("ConfigureStatuses() error: %v", err)
+	}
+
+	if status, ok := StatusForMarkdown("/"); !ok || status != "in_progress" {
+		t.Errorf("StatusForMarkdown(/) = %q, %v", status, ok)
+	}
+	if def, _ := LookupStatus("waiting"); def.Display != "w" {
+		t.Errorf("waiting display = %q, want it to default to w", def.Display)
+	}
+
+	e := Entry{ID: "t1", Type: EntryTypeTask, Status: "in_progress", Content: "Draft"}
+	if got, want := e.RawString(), `- [/] Draft <!-- {"id":"t1"} -->`; got != want {
+		t.Errorf("RawString() = %q, want %q", got, want)
+	}
+	if got := e.DisplayString(); got != "◐ Draft" {
+		t.Errorf("DisplayString() = %q", got)
+	}
+
+	for from, want := range map[EntryStatus]EntryStatus{
+		EntryStatusOpen:      "in_progress",
+		"waiting":            EntryStatusCompleted,
+		EntryStatusCompleted: EntryStatusOpen,
+	} {
+		if got, ok := NextStatus(from); !ok || got != want {
+			t.Errorf("NextStatus(%s) = %q, want %q", from, got, want)
+		}
+	}
+	if _, ok := NextStatus(EntryStatusCancelled); ok {
+		t.Errorf("cancelled is not in the cycle but NextStatus moved it")
+	}
+}
+
+func TestConfigureStatusesRejectsInvalid(t *testing.T) {
+	t.Cleanup(func() { _ = ConfigureStatuses(nil, nil) })
+
+	tests := []struct {
+		name   string
+		custom []StatusDef
+		cycle  []EntryStatus
+	}{
+		{"no name", []StatusDef{{Markdown: "w"}}, nil},
+		{"long markdown", []StatusDef{{Status: "waiting", Markdown: "wt"}}, nil},
+		{"bracket", []StatusDef{{Status: "waiting", Markdown: "]"}}, nil},
+		{"builtin character", []StatusDef{{Status: "done", Markdown: "x"}}, nil},
+		{"builtin name", []StatusDef{{Status: EntryStatusOpen, Markdown: "o"}}, nil},
+		{"unknown in cycle", nil, []EntryStatus{EntryStatusOpen, "waiting"}},
+		{"repeated in cycle", nil, []EntryStatus{EntryStatusOpen, EntryStatusOpen}},
+	}
+	for _, tt := range tests {
+		if err := ConfigureStatuses(tt.custom, tt.cycle); err == nil {
+			t.Errorf("%s: ConfigureStatuses() accepted it", tt.name)
+		}
+	}
+	if _, ok := StatusForMarkdown("w"); ok {
+		t.Errorf("a rejected configuration was applied")
+	}
+}
+
+func TestOpenStatuses(t *testing.T) {
+	t.Cleanup(func() { _ = ConfigureStatuses(nil, nil) })
+
+	custom := []StatusDef{
+		{Status: "waiting", Markdown: "w", Open: true},
+		{Status: "delegated", Markdown: "d"},
+	}
+	if err := ConfigureStatuses(custom, nil); err != nil {
+		t.Fatalf("ConfigureStatuses() error: %v", err)
+	}
+	open := OpenStatuses()
+	if len(open) != 2 || open[0] != EntryStatusOpen || open[1] != "waiting" {
+		t.Errorf("OpenStatuses() = %v, want [open waiting]", open)
+	}
+	waiting := Entry{Type: EntryTypeTask, Status: "waiting"}
+	delegated := Entry{Type: EntryTypeTask, Status: "delegated"}
+	if !waiting.IsOpenTask() || delegated.IsOpenTask() {
+		t.Errorf("IsOpenTask() = %v for waiting and %v for delegated, want true and false", waiting.IsOpenTask(), delegated.IsOpenTask())
+	}
+}
+
+func TestUnknownStatusKeepsCheckbox(t *testing.T) {
+	e := Entry{Type: EntryTypeTask, Content: "Ask Sam", RawContent: "- [?] Ask Sam", ID: "abc"}
+	if got := e.RawString(); !strings.HasPrefix(got, "- [?] Ask Sam") {
+		t.Errorf("RawString() = %q, want the [?] kept", got)
+	}
+	if got := e.DisplayString(); got != "? Ask Sam" {
+		t.Errorf("DisplayString() = %q, want %q", got, "? Ask Sam")
+	}
+}
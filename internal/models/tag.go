@@ -0,0 +1,23 @@
+package models
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Matches a tag in content: "#work", "(#home)" or "#q3-plan," but not "a#b"
+var tagRegex = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_&#/])#([\p{L}\p{N}_][\p{L}\p{N}_/-]*)`)
+
+// ParseTags returns content's tags lowercased and without "#", each once, in
+// the order they first appear.
+func ParseTags(content string) []string {
+	var tags []string
+	for _, m := range tagRegex.FindAllStringSubmatch(content, -1) {
+		tag := strings.ToLower(m[1])
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseTags(t *testing.T) {
+	tests := []struct {
+		content string
+		want    []string
+	}{
+		{"Call the bank #finance", []string{"finance"}},
+		{"#Work: send the report, #q3-plan.", []string{"work", "q3-plan"}},
+		{"Pick up (#home) parcel #home", []string{"home"}},
+		{"Issue a#b and &#38; and #", nil},
+		{"Read #books/fiction!", []string{"books/fiction"}},
+	}
+	for _, tt := range tests {
+		if got := ParseTags(tt.content); !slices.Equal(got, tt.want) {
+			t.Errorf("ParseTags(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/samakintunde/bujo/internal/clock"
+)
+
+// TimeLayout is how event times are stored: 24-hour "15:04".
+const TimeLayout = "15:04"
+
+// Matches a clock time like 14:00, 2pm or 2:30 p.m.
+const clockPattern = `(\d{1,2})(?::(\d{2}))?(?:\s*([ap])\.?m\b\.?)?`
+
+// Matches a time or a range: 14:00-15:00, 2-3pm, 9am to 10:30am
+var timeRangeRegex = regexp.MustCompile(`(?i)\b` + clockPattern + `(?:\s*(?:-|–|to)\s*` + clockPattern + `)?`)
+
+// ParseEventTime finds the first time or time range in content and returns
+// it as "15:04" strings. A bare number is not a time: it needs minutes or
+// am/pm, which the start of a range may borrow from its end ("2-3pm").
+func ParseEventTime(content string) (start, end string) {
+	for _, m := range timeRangeRegex.FindAllStringSubmatch(content, -1) {
+		startHour, startMin, startAP := m[1], m[2], strings.ToLower(m[3])
+		endHour, endMin, endAP := m[4], m[5], strings.ToLower(m[6])
+
+		endExplicit := endHour != "" && (endMin != "" || endAP != "")
+		if endExplicit && startAP == "" && startMin == "" {
+			startAP = endAP
+		}
+		if startMin == "" && startAP == "" {
+			continue
+		}
+
+		s, ok := wallTime(startHour, startMin, startAP)
+		if !ok {
+			continue
+		}
+		if endExplicit {
+			if e, ok := wallTime(endHour, endMin, endAP); ok {
+				return s, e
+			}
+		}
+		return s, ""
+	}
+	return "", ""
+}
+
+func wallTime(hour, minute, ampm string) (string, bool) {
+	h, err := strconv.Atoi(hour)
+	if err != nil {
+		return "", false
+	}
+	m := 0
+	if minute != "" {
+		if m, err = strconv.Atoi(minute); err != nil || m > 59 {
+			return "", false
+		}
+	}
+
+	switch ampm {
+	case "a", "p":
+		if h < 1 || h > 12 {
+			return "", false
+		}
+		h %= 12
+		if ampm == "p" {
+			h += 12
+		}
+	default:
+		if h > 23 {
+			return "", false
+		}
+	}
+	return fmt.Sprintf("%02d:%02d", h, m), true
+}
+
+// TimeRange formats an event's time for display, or "" if it has none.
+func (e *Entry) TimeRange() string {
+	if e.EndTime == "" {
+		return e.StartTime
+	}
+	return e.StartTime + "-" + e.EndTime
+}
+
+// EventSpan returns a timed event's start and end in clock.Minutes. ok is
+// false for events without a time.
+func (e *Entry) EventSpan() (start, end int, ok bool) {
+	start, ok = clock.Minutes(e.StartTime)
+	if !ok {
+		return 0, 0, false
+	}
+	end, hasEnd := clock.Minutes(e.EndTime)
+	switch {
+	case !hasEnd:
+		end = start
+	case end < start:
+		end += 24 * 60
+	}
+	return start, end, true
+}
+
+// SortEventsByTime reorders timed events earliest first, in the slots they
+// already occupy.
+func SortEventsByTime(entries []Entry) {
+	var slots []int
+	var events []Entry
+	for i, e := range entries {
+		if e.Type == EntryTypeEvent && e.StartTime != "" {
+			slots = append(slots, i)
+			events = append(events, e)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		si, ei, _ := events[i].EventSpan()
+		sj, ej, _ := events[j].EventSpan()
+		if si != sj {
+			return si < sj
+		}
+		return ei < ej
+	})
+	for i, slot := range slots {
+		entries[slot] = events[i]
+	}
+}
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/samakintunde/bujo/internal/clock"
+)
+
+func TestParseEventTime(t *testing.T) {
+	tests := []struct {
+		content   string
+		wantStart string
+		wantEnd   string
+	}{
+		{"14:00-15:00 Sprint planning", "14:00", "15:00"},
+		{"Standup 9:30", "09:30", ""},
+		{"Sprint Planning at 2 PM", "14:00", ""},
+		{"Lunch 12pm - 1:30pm", "12:00", "13:30"},
+		{"Dentist 2-3pm", "14:00", "15:00"},
+		{"Call 9am to 10:15am", "09:00", "10:15"},
+		{"Midnight release 12 a.m.", "00:00", ""},
+		{"Flight 23:45–01:10", "23:45", "01:10"},
+		{"Buy 2 apples", "", ""},
+		{"2 amazing talks", "", ""},
+		{"Room 12 review", "", ""},
+		{"Release 2025-03-04", "", ""},
+		{"Bad time 25:00", "", ""},
+		{"14:00 amazing demo", "14:00", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.content, func(t *testing.T) {
+			start, end := ParseEventTime(tt.content)
+			if start != tt.wantStart || end != tt.wantEnd {
+				t.Errorf("ParseEventTime(%q) = %q, %q, want %q, %q", tt.content, start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestEventTimeMetadata(t *testing.T) {
+	inContent := Entry{ID: "a", Type: EntryTypeEvent, Content: "14:00 Retro", StartTime: "14:00"}
+	if raw := inContent.RawString(); strings.Contains(raw, `"st"`) {
+		t.Errorf("time already in content was duplicated into metadata: %s", raw)
+	}
+
+	inMeta := Entry{ID: "b", Type: EntryTypeEvent, Content: "Retro", StartTime: "14:00", EndTime: "15:00"}
+	if raw := inMeta.RawString(); !strings.Contains(raw, `"st":"14:00","et":"15:00"`) {
+		t.Errorf("time missing from metadata: %s", raw)
+	}
+	if got := inMeta.TimeRange(); got != "14:00-15:00" {
+		t.Errorf("TimeRange() = %q, want 14:00-15:00", got)
+	}
+}
+
+func TestSortEventsByTime(t *testing.T) {
+	entries := []Entry{
+		{ID: "retro", Type: EntryTypeEvent, StartTime: "16:00"},
+		{ID: "task", Type: EntryTypeTask},
+		{ID: "untimed", Type: EntryTypeEvent},
+		{ID: "standup", Type: EntryTypeEvent, StartTime: "09:30"},
+		{ID: "note", Type: EntryTypeNote},
+		{ID: "lunch", Type: EntryTypeEvent, StartTime: "12:00"},
+	}
+	SortEventsByTime(entries)
+
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.ID)
+	}
+	want := "standup task untimed lunch note retro"
+	if strings.Join(got, " ") != want {
+		t.Errorf("order = %v, want %s", got, want)
+	}
+}
+
+func TestSortEventsAcrossMidnight(t *testing.T) {
+	if err := clock.Configure(time.UTC, 4); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = clock.Configure(time.Local, 0) })
+
+	entries := []Entry{
+		{ID: "late", Type: EntryTypeEvent, StartTime: "01:00"},
+		{ID: "party", Type: EntryTypeEvent, StartTime: "22:00", EndTime: "02:00"},
+		{ID: "dinner", Type: EntryTypeEvent, StartTime: "22:00", EndTime: "23:00"},
+		{ID: "gym", Type: EntryTypeEvent, StartTime: "07:00"},
+	}
+	SortEventsByTime(entries)
+
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.ID)
+	}
+	want := "gym dinner party late"
+	if strings.Join(got, " ") != want {
+		t.Errorf("order = %v, want %s", got, want)
+	}
+}
@@ -2,10 +2,14 @@ package parser
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"reflect"
 	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/samakintunde/bujo/internal/models"
@@ -23,6 +27,27 @@ var noteRegex = regexp.MustCompile(`^-\s+(.*)`)
 // Matches hidden comment: <!-- {...} -->
 var metaRegex = regexp.MustCompile(`<!--\s*(\{.*\})\s*-->`)
 
+// Matches the start of a metadata comment, well-formed or not
+var metaStartRegex = regexp.MustCompile(`<!--\s*\{`)
+
+// Matches a string or number field in metadata, even when the JSON around
+// it is broken
+var metaFieldRegex = regexp.MustCompile(`"(\w+)"\s*:\s*("(?:[^"\\]|\\.)*"|-?\d+)`)
+
+// Matches a key in metadata, whatever follows it
+var metaKeyRegex = regexp.MustCompile(`"(\w+)"\s*:`)
+
+// metaKeys are the JSON keys of models.Metadata.
+var metaKeys = func() map[string]bool {
+	keys := make(map[string]bool)
+	t := reflect.TypeOf(models.Metadata{})
+	for i := range t.NumField() {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		keys[name] = true
+	}
+	return keys
+}()
+
 // Parse reads a markdown file and returns valid entries.
 // It filters out EntryTypeIgnore lines.
 // Use this for reading/indexing data (e.g. DB import).
@@ -69,10 +94,18 @@ func ParseRaw(path string) ([]models.Entry, error) {
 		return []models.Entry{}, err
 	}
 	defer f.Close()
+	return parseRaw(path, f)
+}
+
+// ParseRawData is ParseRaw on the already read contents of the file at path.
+func ParseRawData(path string, data []byte) ([]models.Entry, error) {
+	return parseRaw(path, bytes.NewReader(data))
+}
 
+func parseRaw(path string, r io.Reader) ([]models.Entry, error) {
 	entries := make([]models.Entry, 0)
 
-	scanner := bufio.NewScanner(f)
+	scanner := bufio.NewScanner(r)
 	i := 0
 	for scanner.Scan() {
 		entry := parseLine(scanner.Text())
@@ -87,16 +120,77 @@ func ParseRaw(path string) ([]models.Entry, error) {
 	return entries, nil
 }
 
+// ParseLine parses a single line. Lines that aren't entries come back as
+// EntryTypeIgnore with only RawContent set.
+func ParseLine(line string) models.Entry {
+	return parseLine(line)
+}
+
+// MetadataError reports a metadata comment on line that can't be read. It
+// returns nil when the line has no metadata or it is valid.
+func MetadataError(line string) error {
+	switch starts := metaStartRegex.FindAllStringIndex(line, -1); len(starts) {
+	case 0:
+		return nil
+	case 1:
+	default:
+		return fmt.Errorf("more than one metadata comment")
+	}
+	match := metaRegex.FindStringSubmatch(line)
+	if match == nil {
+		return fmt.Errorf("unterminated metadata comment")
+	}
+	var meta models.Metadata
+	if err := json.Unmarshal([]byte(match[1]), &meta); err != nil {
+		return fmt.Errorf("invalid metadata: %w", err)
+	}
+	if _, _, _, err := meta.Times(); err != nil {
+		return fmt.Errorf("invalid metadata: %w", err)
+	}
+	return nil
+}
+
+// SalvageMetadata strips a broken metadata comment from line, keeping the
+// fields that can still be read and naming those that can't.
+func SalvageMetadata(line string) (rest string, meta models.Metadata, dropped []string) {
+	start := metaStartRegex.FindStringIndex(line)
+	if start == nil {
+		return line, meta, nil
+	}
+	comment := line[start[0]:]
+
+	kept := make(map[string]bool)
+	for _, m := range metaFieldRegex.FindAllStringSubmatch(comment, -1) {
+		field := []byte(`{"` + m[1] + `":` + m[2] + `}`)
+		var one models.Metadata
+		if !metaKeys[m[1]] || json.Unmarshal(field, &one) != nil {
+			continue
+		}
+		if _, _, _, err := one.Times(); err != nil {
+			continue
+		}
+		json.Unmarshal(field, &meta)
+		kept[m[1]] = true
+	}
+	for _, m := range metaKeyRegex.FindAllStringSubmatch(comment, -1) {
+		if !kept[m[1]] && !slices.Contains(dropped, m[1]) {
+			dropped = append(dropped, m[1])
+		}
+	}
+	return strings.TrimRight(line[:start[0]], " \t"), meta, dropped
+}
+
 func parseLine(line string) models.Entry {
 	entry := models.Entry{RawContent: line}
+	var meta models.Metadata
 
 	if match := metaRegex.FindStringSubmatch(line); len(match) > 1 {
-		var meta models.Metadata
 		if err := json.Unmarshal([]byte(match[1]), &meta); err == nil {
 			entry.ID = meta.ID
 			entry.MigrationCount = meta.Mig
 			entry.ParentID = meta.PID
 			entry.RescheduleCount = meta.Rsch
+			entry.CreatedAt, entry.UpdatedAt, entry.CompletedAt, _ = meta.Times()
 		}
 
 		line = strings.Replace(line, match[0], "", 1)
@@ -108,22 +202,22 @@ func parseLine(line string) models.Entry {
 		entry.Type = models.EntryTypeTask
 		entry.Content = strings.TrimSpace(match[2])
 
-		switch match[1] {
-		case " ":
-			entry.Status = models.EntryStatusOpen
-		case "x":
-			entry.Status = models.EntryStatusCompleted
-		case ">":
-			entry.Status = models.EntryStatusMigrated
-		case "<":
-			entry.Status = models.EntryStatusScheduled
-		case "-":
-			entry.Status = models.EntryStatusCancelled
+		// Unknown checkbox characters leave the status empty; the entry
+		// keeps the character in its raw line.
+		entry.Status, _ = models.StatusForMarkdown(match[1])
+		entry.Priority = models.ParsePriority(entry.Content)
+		entry.DueDate = models.ParseDueDate(entry.Content)
+		if entry.DueDate == "" {
+			entry.DueDate = meta.Due
 		}
 	} else if match := eventRegex.FindStringSubmatch(line); len(match) > 1 {
 		entry.Type = models.EntryTypeEvent
 		entry.Content = strings.TrimSpace(match[1])
 		entry.Status = models.EntryStatusOpen
+		entry.StartTime, entry.EndTime = models.ParseEventTime(entry.Content)
+		if entry.StartTime == "" {
+			entry.StartTime, entry.EndTime = meta.Start, meta.End
+		}
 	} else if match := noteRegex.FindStringSubmatch(line); len(match) > 1 {
 		entry.Type = models.EntryTypeNote
 		entry.Content = strings.TrimSpace(match[1])
@@ -131,5 +225,6 @@ func parseLine(line string) models.Entry {
 	} else {
 		entry.Type = models.EntryTypeIgnore
 	}
+	entry.Tags = models.ParseTags(entry.Content)
 	return entry
 }
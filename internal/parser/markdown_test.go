@@ -3,7 +3,9 @@ package parser
 import (
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
+	"time"
 
 	"github.com/samakintunde/bujo/internal/models"
 )
@@ -74,9 +76,10 @@ func TestParseRaw(t *testing.T) {
 			name: "event",
 			line: "- * Meeting at 3pm",
 			want: models.Entry{
-				Type:    models.EntryTypeEvent,
-				Status:  models.EntryStatusOpen,
-				Content: "Meeting at 3pm",
+				Type:      models.EntryTypeEvent,
+				Status:    models.EntryStatusOpen,
+				Content:   "Meeting at 3pm",
+				StartTime: "15:00",
 			},
 		},
 		// Notes
@@ -89,6 +92,78 @@ func TestParseRaw(t *testing.T) {
 				Content: "Just a note",
 			},
 		},
+		{
+			name: "event with a time range in its content",
+			line: "- * 14:00-15:00 Sprint planning",
+			want: models.Entry{
+				Type:      models.EntryTypeEvent,
+				Status:    models.EntryStatusOpen,
+				Content:   "14:00-15:00 Sprint planning",
+				StartTime: "14:00",
+				EndTime:   "15:00",
+			},
+		},
+		{
+			name: "event with a time in metadata",
+			line: `- * Retro <!-- {"id":"ev1","st":"16:00"} -->`,
+			want: models.Entry{
+				ID:        "ev1",
+				Type:      models.EntryTypeEvent,
+				Status:    models.EntryStatusOpen,
+				Content:   "Retro",
+				StartTime: "16:00",
+			},
+		},
+		{
+			name: "task with a deadline in its content",
+			line: "- [ ] Submit report due:2025-03-07",
+			want: models.Entry{
+				Type:    models.EntryTypeTask,
+				Status:  models.EntryStatusOpen,
+				Content: "Submit report due:2025-03-07",
+				DueDate: "2025-03-07",
+			},
+		},
+		{
+			name: "task with a deadline in metadata",
+			line: `- [ ] Submit report <!-- {"id":"t1","due":"2025-03-07"} -->`,
+			want: models.Entry{
+				ID:      "t1",
+				Type:    models.EntryTypeTask,
+				Status:  models.EntryStatusOpen,
+				Content: "Submit report",
+				DueDate: "2025-03-07",
+			},
+		},
+		{
+			name: "task with the priority signifier",
+			line: "- [ ] * Call the bank",
+			want: models.Entry{
+				Type:     models.EntryTypeTask,
+				Status:   models.EntryStatusOpen,
+				Content:  "* Call the bank",
+				Priority: 1,
+			},
+		},
+		{
+			name: "task with a high priority",
+			line: "- [ ] !!! Renew passport",
+			want: models.Entry{
+				Type:     models.EntryTypeTask,
+				Status:   models.EntryStatusOpen,
+				Content:  "!!! Renew passport",
+				Priority: 3,
+			},
+		},
+		{
+			name: "task times are not parsed",
+			line: "- [ ] Call at 2pm",
+			want: models.Entry{
+				Type:    models.EntryTypeTask,
+				Status:  models.EntryStatusOpen,
+				Content: "Call at 2pm",
+			},
+		},
 		// Ignored lines
 		{
 			name: "heading is ignored",
@@ -188,6 +263,15 @@ func TestParseRaw(t *testing.T) {
 			if got.RescheduleCount != tt.want.RescheduleCount {
 				t.Errorf("RescheduleCount = %d, want %d", got.RescheduleCount, tt.want.RescheduleCount)
 			}
+			if got.StartTime != tt.want.StartTime || got.EndTime != tt.want.EndTime {
+				t.Errorf("time = %q-%q, want %q-%q", got.StartTime, got.EndTime, tt.want.StartTime, tt.want.EndTime)
+			}
+			if got.DueDate != tt.want.DueDate {
+				t.Errorf("DueDate = %q, want %q", got.DueDate, tt.want.DueDate)
+			}
+			if got.Priority != tt.want.Priority {
+				t.Errorf("Priority = %d, want %d", got.Priority, tt.want.Priority)
+			}
 			if got.LineNumber != 1 {
 				t.Errorf("LineNumber = %d, want 1", got.LineNumber)
 			}
@@ -230,6 +314,16 @@ func TestParseRaw_LineNumbers(t *testing.T) {
 	}
 }
 
+func TestParseRawData(t *testing.T) {
+	entries, err := ParseRawData("/journal/test.md", []byte("# Heading\n- [ ] Task\n"))
+	if err != nil {
+		t.Fatalf("ParseRawData error: %v", err)
+	}
+	if len(entries) != 2 || entries[1].Content != "Task" || entries[1].LineNumber != 2 || entries[1].FilePath != "/journal/test.md" {
+		t.Errorf("entries = %+v, want the heading and the task on line 2", entries)
+	}
+}
+
 func TestParse_FiltersIgnoredLines(t *testing.T) {
 	content := `# Heading
 - [ ] A task
@@ -283,3 +377,76 @@ Random text
 		t.Errorf("note LineNumber = %d, want 6", entries[2].LineNumber)
 	}
 }
+
+func TestParseLineCustomStatus(t *testing.T) {
+	if err := models.ConfigureStatuses([]models.StatusDef{{Status: "waiting", Markdown: "w"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = models.ConfigureStatuses(nil, nil) })
+
+	line := `- [w] Hear back from Sam <!-- {"id":"t1"} -->`
+	entry := ParseLine(line)
+	if entry.Type != models.EntryTypeTask || entry.Status != "waiting" {
+		t.Fatalf("ParseLine() = %s/%q, want a waiting task", entry.Type, entry.Status)
+	}
+	if got := entry.RawString(); got != line {
+		t.Errorf("RawString() = %q, want %q", got, line)
+	}
+}
+
+func TestParseLineTimestamps(t *testing.T) {
+	line := `- [x] Ship it <!-- {"id":"t1","ct":"2024-01-15T09:00:00Z","ut":"2024-01-16T17:30:00Z","done":"2024-01-16T17:30:00Z"} -->`
+	entry := ParseLine(line)
+
+	want := time.Date(2024, 1, 16, 17, 30, 0, 0, time.UTC)
+	if !entry.CreatedAt.Equal(time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)) || !entry.UpdatedAt.Equal(want) || !entry.CompletedAt.Equal(want) {
+		t.Errorf("ParseLine() times = %v, %v, %v", entry.CreatedAt, entry.UpdatedAt, entry.CompletedAt)
+	}
+	if got := entry.RawString(); got != line {
+		t.Errorf("RawString() = %q, want %q", got, line)
+	}
+}
+
+func TestMetadataError(t *testing.T) {
+	tests := []struct {
+		line    string
+		wantErr bool
+	}{
+		{`- [ ] Task <!-- {"id":"t1"} -->`, false},
+		{`- [ ] Task`, false},
+		{`- A note <!-- not metadata -->`, false},
+		{`- [ ] Task <!-- {"id":"t1", -->`, true},
+		{`- [ ] Task <!-- {"id":"t1"}`, true},
+		{`- [ ] Task <!-- {"id":"t1"} --> <!-- {"id":"t2"} -->`, true},
+		{`- [x] Task <!-- {"id":"t1","done":"2024-01-15T09:00:00Z"} -->`, false},
+		{`- [x] Task <!-- {"id":"t1","done":"yesterday"} -->`, true},
+	}
+	for _, tt := range tests {
+		if err := MetadataError(tt.line); (err != nil) != tt.wantErr {
+			t.Errorf("MetadataError(%q) = %v, want error: %v", tt.line, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSalvageMetadata(t *testing.T) {
+	tests := []struct {
+		line        string
+		wantMeta    models.Metadata
+		wantDropped []string
+	}{
+		{`- [ ] Task <!-- {"id":"t1","mig": -->`, models.Metadata{ID: "t1"}, []string{"mig"}},
+		{`- [ ] Task <!-- {broken -->`, models.Metadata{}, nil},
+		{
+			`- [ ] Task <!-- {"id":"t1","mig":2,"pid":"p1","ct":"2024-01-15T09:00:00Z","ut":"yesterday","done":"2024-01-16T10:00:00Z", -->`,
+			models.Metadata{ID: "t1", Mig: 2, PID: "p1", Created: "2024-01-15T09:00:00Z", Completed: "2024-01-16T10:00:00Z"},
+			[]string{"ut"},
+		},
+		{`- [ ] Task <!-- {"id":"t1","color":"red"} -->`, models.Metadata{ID: "t1"}, []string{"color"}},
+	}
+	for _, tt := range tests {
+		rest, meta, dropped := SalvageMetadata(tt.line)
+		if rest != "- [ ] Task" || meta != tt.wantMeta || !slices.Equal(dropped, tt.wantDropped) {
+			t.Errorf("SalvageMetadata(%q) = %q, %+v, %q; want %+v, %q", tt.line, rest, meta, dropped, tt.wantMeta, tt.wantDropped)
+		}
+	}
+}
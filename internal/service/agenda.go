@@ -0,0 +1,67 @@
+package service
+
+import (
+	"time"
+
+	"github.com/samakintunde/bujo/internal/models"
+)
+
+// Agenda is what's on in a journal: a day's events and open tasks, and the
+// open tasks due soon or overdue elsewhere in the journal.
+type Agenda struct {
+	Journal string
+	Date    time.Time
+	Events  []models.Entry
+	Tasks   []models.Entry
+	Due     []models.Entry
+}
+
+// GetAgenda gathers the agenda for date, with deadlines up to days after it.
+// The day's log is synced first.
+func (s *JournalService) GetAgenda(date time.Time, days int) (*Agenda, error) {
+	entries, err := s.GetEntriesByDate(date)
+	if err != nil {
+		return nil, err
+	}
+	return s.agenda(entries, date, days)
+}
+
+// ReadAgenda is GetAgenda from the index as it is, without syncing. It
+// never writes to the journal, so it suits journals that aren't open.
+func (s *JournalService) ReadAgenda(date time.Time, days int) (*Agenda, error) {
+	entries, err := s.dayEntries(date)
+	if err != nil {
+		return nil, err
+	}
+	return s.agenda(entries, date, days)
+}
+
+func (s *JournalService) agenda(entries []models.Entry, date time.Time, days int) (*Agenda, error) {
+	agenda := &Agenda{Date: date}
+	onDay := make(map[string]bool)
+	for _, e := range entries {
+		switch {
+		case e.Type == models.EntryTypeEvent:
+			agenda.Events = append(agenda.Events, e)
+		case e.IsOpenTask():
+			agenda.Tasks = append(agenda.Tasks, e)
+			onDay[e.ID] = true
+		}
+	}
+
+	due, err := s.GetDeadlines(date.AddDate(0, 0, days))
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range due {
+		if !onDay[e.ID] {
+			agenda.Due = append(agenda.Due, e)
+		}
+	}
+	return agenda, nil
+}
+
+// IsEmpty reports whether nothing is on.
+func (a *Agenda) IsEmpty() bool {
+	return len(a.Events) == 0 && len(a.Tasks) == 0 && len(a.Due) == 0
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/samakintunde/bujo/internal/models"
+)
+
+func TestGetAgenda(t *testing.T) {
+	svc, _, _, cleanup := setupTestService(t)
+	defer cleanup()
+
+	today := time.Now()
+	tomorrow := today.AddDate(0, 0, 1).Format(time.DateOnly)
+	for _, add := range []struct {
+		content   string
+		entryType models.EntryType
+		date      time.Time
+	}{
+		{"09:30 Standup", models.EntryTypeEvent, today},
+		{"Ship release due:" + tomorrow, models.EntryTypeTask, today},
+		{"A note", models.EntryTypeNote, today},
+		{"Submit report due:" + tomorrow, models.EntryTypeTask, today.AddDate(0, 0, -3)},
+		{"Far off due:2999-01-01", models.EntryTypeTask, today.AddDate(0, 0, -3)},
+	} {
+		if _, err := svc.AddEntry(add.content, add.entryType, add.date); err != nil {
+			t.Fatalf("AddEntry(%q) failed: %v", add.content, err)
+		}
+	}
+	agenda, err := svc.GetAgenda(today, 7)
+	if err != nil {
+		t.Fatalf("GetAgenda failed: %v", err)
+	}
+	if len(agenda.Events) != 1 || agenda.Events[0].Content != "09:30 Standup" {
+		t.Errorf("Events = %+v, want the standup", agenda.Events)
+	}
+	if len(agenda.Tasks) != 1 || agenda.Tasks[0].Content != "Ship release due:"+tomorrow {
+		t.Errorf("Tasks = %+v, want the release", agenda.Tasks)
+	}
+	// The release is due too, but is already on the day.
+	if len(agenda.Due) != 1 || agenda.Due[0].Content != "Submit report due:"+tomorrow {
+		t.Errorf("Due = %+v, want only the report", agenda.Due)
+	}
+	if agenda.IsEmpty() {
+		t.Error("IsEmpty() = true, want false")
+	}
+}
+
+func TestReadAgendaLeavesJournalAlone(t *testing.T) {
+	svc, fs, db, cleanup := setupTestService(t)
+	defer cleanup()
+
+	today := time.Now()
+	if _, err := svc.AddEntry("Indexed task", models.EntryTypeTask, today); err != nil {
+		t.Fatalf("AddEntry failed: %v", err)
+	}
+	path := fs.GetDayPath(today.Format(time.DateOnly))
+	// An edit made outside bujo, with no ID yet.
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited := string(before) + "- [ ] Typed by hand\n"
+	if err := os.WriteFile(path, []byte(edited), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	reader := NewJournalService(fs, db, nil)
+	agenda, err := reader.ReadAgenda(today, 7)
+	if err != nil {
+		t.Fatalf("ReadAgenda failed: %v", err)
+	}
+	if len(agenda.Tasks) != 1 || agenda.Tasks[0].Content != "Indexed task" {
+		t.Errorf("Tasks = %+v, want the indexed task only", agenda.Tasks)
+	}
+	if after, _ := os.ReadFile(path); string(after) != edited {
+		t.Errorf("ReadAgenda rewrote the log:\n%s", after)
+	}
+}
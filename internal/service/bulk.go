@@ -0,0 +1,126 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/samakintunde/bujo/internal/clock"
+	"github.com/samakintunde/bujo/internal/models"
+)
+
+// UpdateEntriesStatus sets the status of several entries, rewriting each
+// affected file once and making a single commit.
+func (s *JournalService) UpdateEntriesStatus(entries []models.Entry, newStatus models.EntryStatus) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	if err := s.rewriteEntries(entries, newStatus); err != nil {
+		return fmt.Errorf("failed to update entries: %w", err)
+	}
+
+	s.commit(fmt.Sprintf("update %d entries to %s\n\n%s", len(entries), newStatus, idList(entries)))
+
+	return nil
+}
+
+// MigrateTasks migrates several tasks to today in one pass.
+func (s *JournalService) MigrateTasks(entries []models.Entry) ([]*models.Entry, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	todayPath, err := s.fs.EnsureDayPath(clock.Today().Format(time.DateOnly))
+	if err != nil {
+		return nil, fmt.Errorf("failed to ensure today path: %w", err)
+	}
+
+	newEntries, err := s.moveTasks(entries, models.EntryStatusMigrated, todayPath)
+	if err != nil {
+		return nil, err
+	}
+
+	s.commit(fmt.Sprintf("migrate %d tasks\n\n%s", len(entries), idList(entries)))
+
+	return newEntries, nil
+}
+
+// ScheduleTasks schedules several tasks to targetDate in one pass.
+func (s *JournalService) ScheduleTasks(entries []models.Entry, targetDate time.Time) ([]*models.Entry, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	targetDateStr := targetDate.Format(time.DateOnly)
+	targetPath, err := s.fs.EnsureDayPath(targetDateStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to ensure target path: %w", err)
+	}
+
+	newEntries, err := s.moveTasks(entries, models.EntryStatusScheduled, targetPath)
+	if err != nil {
+		return nil, err
+	}
+
+	s.commit(fmt.Sprintf("schedule %d tasks to %s\n\n%s", len(entries), targetDateStr, idList(entries)))
+
+	return newEntries, nil
+}
+
+// moveTasks marks the originals with status and appends their copies to
+// targetPath, counting a migration or reschedule on each copy.
+func (s *JournalService) moveTasks(entries []models.Entry, status models.EntryStatus, targetPath string) ([]*models.Entry, error) {
+	if err := s.rewriteEntries(entries, status); err != nil {
+		return nil, fmt.Errorf("failed to update original entries: %w", err)
+	}
+
+	newEntries := make([]*models.Entry, 0, len(entries))
+	lines := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		newEntry := followUp(entry)
+		if status == models.EntryStatusScheduled {
+			newEntry.RescheduleCount = entry.RescheduleCount + 1
+		} else {
+			newEntry.MigrationCount = entry.MigrationCount + 1
+		}
+		newEntry.ParentID = entry.ID
+		newEntry.FilePath = targetPath
+
+		newEntries = append(newEntries, newEntry)
+		lines = append(lines, newEntry.RawString())
+	}
+
+	if err := s.fs.AppendLines(targetPath, lines); err != nil {
+		return nil, fmt.Errorf("failed to write moved entries: %w", err)
+	}
+	if err := s.syncer.SyncFile(targetPath); err != nil {
+		return nil, fmt.Errorf("failed to sync target file: %w", err)
+	}
+
+	return newEntries, nil
+}
+
+// rewriteEntries writes entries back with status, grouping the line updates
+// so each file is written and synced once.
+func (s *JournalService) rewriteEntries(entries []models.Entry, status models.EntryStatus) error {
+	var paths []string
+	updates := make(map[string]map[int]string)
+	for _, entry := range entries {
+		entry.SetStatus(status)
+		if updates[entry.FilePath] == nil {
+			updates[entry.FilePath] = make(map[int]string)
+			paths = append(paths, entry.FilePath)
+		}
+		updates[entry.FilePath][entry.LineNumber] = entry.RawString()
+	}
+
+	for _, path := range paths {
+		if err := s.fs.UpdateLines(path, updates[path]); err != nil {
+			return err
+		}
+		if err := s.syncer.SyncFile(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
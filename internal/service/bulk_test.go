@@ -0,0 +1,99 @@
+package service
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/samakintunde/bujo/internal/models"
+)
+
+func addTasks(t *testing.T, svc *JournalService, date time.Time, contents ...string) {
+	t.Helper()
+	for _, content := range contents {
+		if _, err := svc.AddEntry(content, models.EntryTypeTask, date); err != nil {
+			t.Fatalf("AddEntry failed: %v", err)
+		}
+	}
+}
+
+func TestUpdateEntriesStatus(t *testing.T) {
+	svc, _, _, cleanup := setupTestService(t)
+	defer cleanup()
+
+	addTasks(t, svc, time.Now(), "One", "Two", "Three")
+	entries, _ := svc.GetEntriesByDate(time.Now())
+
+	if err := svc.UpdateEntriesStatus(entries[:2], models.EntryStatusCompleted); err != nil {
+		t.Fatalf("UpdateEntriesStatus failed: %v", err)
+	}
+
+	entries, _ = svc.GetEntriesByDate(time.Now())
+	want := []models.EntryStatus{models.EntryStatusCompleted, models.EntryStatusCompleted, models.EntryStatusOpen}
+	for i, e := range entries {
+		if e.Status != want[i] {
+			t.Errorf("entry %d status = %s, want %s", i, e.Status, want[i])
+		}
+	}
+}
+
+func TestMigrateTasks(t *testing.T) {
+	svc, _, db, cleanup := setupTestService(t)
+	defer cleanup()
+
+	yesterday := time.Now().AddDate(0, 0, -1)
+	addTasks(t, svc, yesterday, "One", "Two")
+	entries, _ := svc.GetEntriesByDate(yesterday)
+
+	newEntries, err := svc.MigrateTasks(entries)
+	if err != nil {
+		t.Fatalf("MigrateTasks failed: %v", err)
+	}
+	if len(newEntries) != 2 {
+		t.Fatalf("Expected 2 migrated entries, got %d", len(newEntries))
+	}
+
+	old, _ := db.GetEntriesByFile(entries[0].FilePath)
+	for _, e := range old {
+		if e.Status != models.EntryStatusMigrated {
+			t.Errorf("Expected %q to be migrated, got %s", e.Content, e.Status)
+		}
+	}
+
+	today, _ := svc.GetEntriesByDate(time.Now())
+	if len(today) != 2 {
+		t.Fatalf("Expected 2 entries today, got %d", len(today))
+	}
+	for i, e := range today {
+		if e.ParentID != entries[i].ID || e.MigrationCount != 1 {
+			t.Errorf("today[%d] = parent %s count %d, want parent %s count 1", i, e.ParentID, e.MigrationCount, entries[i].ID)
+		}
+	}
+}
+
+func TestScheduleTasks(t *testing.T) {
+	svc, fs, _, cleanup := setupTestService(t)
+	defer cleanup()
+
+	addTasks(t, svc, time.Now(), "One", "Two", "Three")
+	entries, _ := svc.GetEntriesByDate(time.Now())
+
+	target := time.Now().AddDate(0, 0, 7)
+	if _, err := svc.ScheduleTasks([]models.Entry{entries[0], entries[2]}, target); err != nil {
+		t.Fatalf("ScheduleTasks failed: %v", err)
+	}
+
+	scheduled, _ := svc.GetEntriesByDate(target)
+	if len(scheduled) != 2 || scheduled[0].Content != "One" || scheduled[1].Content != "Three" {
+		t.Fatalf("Unexpected scheduled entries: %+v", scheduled)
+	}
+	if scheduled[0].RescheduleCount != 1 {
+		t.Errorf("Expected RescheduleCount 1, got %d", scheduled[0].RescheduleCount)
+	}
+
+	content, _ := os.ReadFile(fs.GetDayPath(time.Now().Format(time.DateOnly)))
+	if got := strings.Count(string(content), "- [<]"); got != 2 {
+		t.Errorf("Expected 2 scheduled markers in source file, got %d", got)
+	}
+}
@@ -0,0 +1,352 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	gosync "sync"
+	"text/template"
+	"time"
+
+	"github.com/samakintunde/bujo/internal/clock"
+	"github.com/samakintunde/bujo/internal/git"
+)
+
+// CommitPolicy decides when journal changes are committed.
+type CommitPolicy string
+
+const (
+	// CommitPerAction commits every change on its own.
+	CommitPerAction CommitPolicy = "action"
+	// CommitDebounce commits once no change has arrived for a while.
+	CommitDebounce CommitPolicy = "debounce"
+	// CommitOnQuit commits everything when the committer is closed.
+	CommitOnQuit CommitPolicy = "quit"
+	// CommitDaily folds each change into a single commit per day, as long as
+	// that commit has not been pushed.
+	CommitDaily CommitPolicy = "daily"
+	// CommitNever leaves committing to the user.
+	CommitNever CommitPolicy = "never"
+)
+
+const (
+	DefaultCommitDebounce     = 30 * time.Second
+	DefaultCommitMessage      = "feat(bujo): {{.Summary}}"
+	DefaultCommitBatchMessage = "feat(bujo): {{.Count}} changes on {{.Date}}"
+
+	// dayTrailer marks the commit a daily policy keeps amending.
+	dayTrailer = "Bujo-Day: "
+)
+
+// CommitOptions configures a Committer. Message and BatchMessage are
+// templates; empty fields use the defaults.
+type CommitOptions struct {
+	Policy       CommitPolicy
+	Debounce     time.Duration
+	Message      string
+	BatchMessage string
+}
+
+type commitMessageData struct {
+	Summary string
+	Body    string
+}
+
+type batchMessageData struct {
+	Count   int
+	Date    string
+	Changes []string
+}
+
+// Committer commits journal changes in the background according to a
+// policy, so that callers never wait on git.
+type Committer struct {
+	dir     string
+	opts    CommitOptions
+	message *template.Template
+	batch   *template.Template
+	now     func() time.Time
+
+	// queue grows as needed so Record never waits; wake signals the loop.
+	queueMu gosync.Mutex
+	queue   []string
+	wake    chan struct{}
+	flushes chan chan error
+	stop    chan struct{}
+	done    chan error
+	errs    chan error
+
+	// mu serialises Flush and Close; closed is set holding mu and queueMu.
+	mu     gosync.Mutex
+	closed bool
+}
+
+// NewCommitter starts a committer for the repository at dir. Empty options
+// fall back to the defaults.
+func NewCommitter(dir string, opts CommitOptions) (*Committer, error) {
+	switch opts.Policy {
+	case "":
+		opts.Policy = CommitPerAction
+	case CommitPerAction, CommitDebounce, CommitOnQuit, CommitDaily, CommitNever:
+	default:
+		return nil, fmt.Errorf("unknown commit policy %q (use action, debounce, quit, daily or never)", opts.Policy)
+	}
+	if opts.Debounce <= 0 {
+		opts.Debounce = DefaultCommitDebounce
+	}
+	if opts.Message == "" {
+		opts.Message = DefaultCommitMessage
+	}
+	if opts.BatchMessage == "" {
+		opts.BatchMessage = DefaultCommitBatchMessage
+	}
+
+	message, err := template.New("message").Parse(opts.Message)
+	if err != nil {
+		return nil, fmt.Errorf("invalid commit message template: %w", err)
+	}
+	batch, err := template.New("batch_message").Parse(opts.BatchMessage)
+	if err != nil {
+		return nil, fmt.Errorf("invalid batch commit message template: %w", err)
+	}
+
+	c := &Committer{
+		dir:     dir,
+		opts:    opts,
+		message: message,
+		batch:   batch,
+		now:     time.Now,
+		wake:    make(chan struct{}, 1),
+		flushes: make(chan chan error),
+		stop:    make(chan struct{}),
+		done:    make(chan error, 1),
+		errs:    make(chan error, 8),
+	}
+	go c.loop()
+	return c, nil
+}
+
+// Record queues a change for committing. The first line of summary is the
+// subject; anything after a blank line is kept as the body.
+func (c *Committer) Record(summary string) {
+	c.queueMu.Lock()
+	defer c.queueMu.Unlock()
+	if c.closed {
+		return
+	}
+	c.queue = append(c.queue, summary)
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+// take empties the queue.
+func (c *Committer) take() []string {
+	c.queueMu.Lock()
+	defer c.queueMu.Unlock()
+	queued := c.queue
+	c.queue = nil
+	return queued
+}
+
+// Flush commits everything pending and returns the first error since the
+// last flush.
+func (c *Committer) Flush() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil
+	}
+	reply := make(chan error)
+	c.flushes <- reply
+	return <-reply
+}
+
+// Close commits everything pending and stops the committer.
+func (c *Committer) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.queueMu.Lock()
+	c.closed = true
+	c.queueMu.Unlock()
+	close(c.stop)
+	return <-c.done
+}
+
+// Errors delivers commit failures, and nil once commits succeed again.
+func (c *Committer) Errors() <-chan error {
+	return c.errs
+}
+
+func (c *Committer) report(err error) {
+	select {
+	case c.errs <- err:
+	default:
+	}
+}
+
+func (c *Committer) loop() {
+	var pending []string
+	var firstErr error
+	var failing bool
+	var timer *time.Timer
+	var fire <-chan time.Time
+
+	commit := func() {
+		if timer != nil {
+			timer.Stop()
+			timer, fire = nil, nil
+		}
+		if err := c.commit(pending); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			failing = true
+			c.report(err)
+		} else if failing && len(pending) > 0 {
+			failing = false
+			c.report(nil)
+		}
+		pending = nil
+	}
+
+	for {
+		select {
+		case <-c.wake:
+			queued := c.take()
+			if len(queued) == 0 {
+				continue
+			}
+			switch c.opts.Policy {
+			case CommitDebounce:
+				pending = append(pending, queued...)
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = time.NewTimer(c.opts.Debounce)
+				fire = timer.C
+			case CommitOnQuit:
+				pending = append(pending, queued...)
+			case CommitPerAction:
+				for _, summary := range queued {
+					pending = []string{summary}
+					commit()
+				}
+			default:
+				// Changes that piled up during a slow commit go in together.
+				pending = append(pending, queued...)
+				commit()
+			}
+		case <-fire:
+			commit()
+		case reply := <-c.flushes:
+			// Changes recorded before the flush may still be queued.
+			pending = append(pending, c.take()...)
+			commit()
+			reply <- firstErr
+			firstErr = nil
+		case <-c.stop:
+			pending = append(pending, c.take()...)
+			commit()
+			c.done <- firstErr
+			return
+		}
+	}
+}
+
+func (c *Committer) commit(summaries []string) error {
+	if len(summaries) == 0 || c.opts.Policy == CommitNever || !git.IsPresent() || !git.IsRepo(c.dir) {
+		return nil
+	}
+	if c.opts.Policy == CommitDaily {
+		return c.commitDaily(summaries)
+	}
+
+	var message string
+	var err error
+	if len(summaries) == 1 {
+		message, err = c.renderMessage(summaries[0])
+	} else {
+		message, err = c.renderBatch(summaries, "")
+	}
+	if err != nil {
+		return err
+	}
+	return git.Commit(c.dir, message)
+}
+
+// commitDaily amends today's commit when it exists and is still local,
+// and starts a new one otherwise.
+func (c *Committer) commitDaily(summaries []string) error {
+	day := clock.Day(c.now()).Format(time.DateOnly)
+	trailer := dayTrailer + day
+
+	if head, err := git.HeadMessage(c.dir); err == nil && strings.HasSuffix(head, trailer) && !git.IsPushed(c.dir) {
+		changes := append(batchChanges(head), summaries...)
+		message, err := c.renderBatch(changes, trailer)
+		if err != nil {
+			return err
+		}
+		return git.Amend(c.dir, message)
+	}
+
+	message, err := c.renderBatch(summaries, trailer)
+	if err != nil {
+		return err
+	}
+	return git.Commit(c.dir, message)
+}
+
+func (c *Committer) renderMessage(summary string) (string, error) {
+	subject, body, _ := strings.Cut(summary, "\n\n")
+	var buf bytes.Buffer
+	if err := c.message.Execute(&buf, commitMessageData{Summary: subject, Body: body}); err != nil {
+		return "", fmt.Errorf("render commit message: %w", err)
+	}
+	message := strings.TrimSpace(buf.String())
+	if body != "" && !strings.Contains(message, body) {
+		message += "\n\n" + body
+	}
+	return message, nil
+}
+
+// renderBatch renders a commit holding several changes, listing each in
+// the body so history lookups still find it.
+func (c *Committer) renderBatch(summaries []string, trailer string) (string, error) {
+	data := batchMessageData{
+		Count: len(summaries),
+		Date:  clock.Day(c.now()).Format(time.DateOnly),
+	}
+	for _, s := range summaries {
+		data.Changes = append(data.Changes, strings.ReplaceAll(strings.TrimSpace(s), "\n\n", " "))
+	}
+
+	var buf bytes.Buffer
+	if err := c.batch.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("render batch commit message: %w", err)
+	}
+
+	lines := []string{strings.TrimSpace(buf.String()), ""}
+	for _, change := range data.Changes {
+		lines = append(lines, "- "+change)
+	}
+	if trailer != "" {
+		lines = append(lines, "", trailer)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// batchChanges recovers the change list from a batch commit message.
+func batchChanges(message string) []string {
+	var changes []string
+	for _, line := range strings.Split(message, "\n") {
+		if change, ok := strings.CutPrefix(line, "- "); ok {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
@@ -0,0 +1,295 @@
+package service
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/samakintunde/bujo/internal/git"
+	"github.com/samakintunde/bujo/internal/gittest"
+)
+
+func touch(t *testing.T, dir, name string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(name+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// commitLog returns the full message of every commit, newest first.
+func commitLog(t *testing.T, dir string) []string {
+	t.Helper()
+	cmd := exec.Command("git", "log", "--format=%B%x00")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+	var messages []string
+	for _, m := range strings.Split(string(out), "\x00") {
+		if m = strings.TrimSpace(m); m != "" {
+			messages = append(messages, m)
+		}
+	}
+	return messages
+}
+
+func TestCommitterPolicies(t *testing.T) {
+	t.Run("action", func(t *testing.T) {
+		dir := gittest.NewRepo(t)
+		c, err := NewCommitter(dir, CommitOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c.Close()
+
+		touch(t, dir, "a.md")
+		c.Record("add task #A")
+		if err := c.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		touch(t, dir, "b.md")
+		c.Record("add task #B")
+		if err := c.Flush(); err != nil {
+			t.Fatal(err)
+		}
+
+		log := commitLog(t, dir)
+		if len(log) != 2 || log[0] != "feat(bujo): add task #B" || log[1] != "feat(bujo): add task #A" {
+			t.Errorf("log = %q", log)
+		}
+	})
+
+	t.Run("debounce", func(t *testing.T) {
+		dir := gittest.NewRepo(t)
+		c, err := NewCommitter(dir, CommitOptions{Policy: CommitDebounce, Debounce: 50 * time.Millisecond})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c.Close()
+
+		touch(t, dir, "a.md")
+		c.Record("add task #A")
+		touch(t, dir, "b.md")
+		c.Record("update 2 entries to completed\n\n#B #C")
+		if log := commitLog(t, dir); len(log) != 0 {
+			t.Fatalf("committed before the debounce expired: %q", log)
+		}
+
+		deadline := time.Now().Add(5 * time.Second)
+		var log []string
+		for time.Now().Before(deadline) {
+			if log = commitLog(t, dir); len(log) > 0 {
+				break
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		if len(log) != 1 {
+			t.Fatalf("log = %q, want one batch commit", log)
+		}
+		for _, want := range []string{"2 changes on", "- add task #A", "- update 2 entries to completed #B #C"} {
+			if !strings.Contains(log[0], want) {
+				t.Errorf("batch message %q missing %q", log[0], want)
+			}
+		}
+	})
+
+	t.Run("quit", func(t *testing.T) {
+		dir := gittest.NewRepo(t)
+		c, err := NewCommitter(dir, CommitOptions{Policy: CommitOnQuit})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		touch(t, dir, "a.md")
+		c.Record("add task #A")
+		if log := commitLog(t, dir); len(log) != 0 {
+			t.Fatalf("committed before close: %q", log)
+		}
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if log := commitLog(t, dir); len(log) != 1 || log[0] != "feat(bujo): add task #A" {
+			t.Errorf("log = %q", log)
+		}
+		c.Record("ignored after close")
+	})
+
+	t.Run("never", func(t *testing.T) {
+		dir := gittest.NewRepo(t)
+		c, err := NewCommitter(dir, CommitOptions{Policy: CommitNever})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		touch(t, dir, "a.md")
+		c.Record("add task #A")
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if log := commitLog(t, dir); len(log) != 0 {
+			t.Errorf("log = %q, want no commits", log)
+		}
+	})
+
+	t.Run("daily", func(t *testing.T) {
+		dir := gittest.NewRepo(t)
+		c, err := NewCommitter(dir, CommitOptions{Policy: CommitDaily})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c.Close()
+		c.now = func() time.Time { return time.Date(2025, 3, 4, 9, 0, 0, 0, time.UTC) }
+
+		touch(t, dir, "a.md")
+		c.Record("add task #A")
+		if err := c.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		touch(t, dir, "b.md")
+		c.Record("add task #B")
+		if err := c.Flush(); err != nil {
+			t.Fatal(err)
+		}
+
+		log := commitLog(t, dir)
+		if len(log) != 1 {
+			t.Fatalf("log = %q, want one squashed commit", log)
+		}
+		for _, want := range []string{"2 changes on 2025-03-04", "- add task #A", "- add task #B", "Bujo-Day: 2025-03-04"} {
+			if !strings.Contains(log[0], want) {
+				t.Errorf("daily message %q missing %q", log[0], want)
+			}
+		}
+
+		// A pushed commit is never rewritten.
+		gittest.Run(t, dir, "update-ref", "refs/remotes/origin/main", "HEAD")
+		touch(t, dir, "c.md")
+		c.Record("add task #C")
+		if err := c.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		if log := commitLog(t, dir); len(log) != 2 {
+			t.Errorf("log = %q, want a new commit after push", log)
+		}
+	})
+}
+
+func TestCommitterMessageTemplate(t *testing.T) {
+	dir := gittest.NewRepo(t)
+	c, err := NewCommitter(dir, CommitOptions{Message: "journal: {{.Summary}}"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	touch(t, dir, "a.md")
+	c.Record("migrate 2 tasks\n\n#A #B")
+	if err := c.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if log := commitLog(t, dir); len(log) != 1 || log[0] != "journal: migrate 2 tasks\n\n#A #B" {
+		t.Errorf("log = %q", log)
+	}
+
+	if _, err := NewCommitter(dir, CommitOptions{Policy: "hourly"}); err == nil {
+		t.Error("expected an error for an unknown policy")
+	}
+	if _, err := NewCommitter(dir, CommitOptions{Message: "{{.Summary"}); err == nil {
+		t.Error("expected an error for a malformed template")
+	}
+}
+
+func TestCommitterReportsRecovery(t *testing.T) {
+	dir := gittest.NewRepo(t)
+	c, err := NewCommitter(dir, CommitOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	lock := filepath.Join(dir, ".git", "index.lock")
+	touch(t, dir, "a.md")
+	if err := os.WriteFile(lock, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	c.Record("add a")
+	if err := <-c.Errors(); !git.IsKind(err, git.ErrLocked) {
+		t.Fatalf("Errors() = %v, want the locked index", err)
+	}
+
+	if err := os.Remove(lock); err != nil {
+		t.Fatal(err)
+	}
+	touch(t, dir, "b.md")
+	c.Record("add b")
+	if err := <-c.Errors(); err != nil {
+		t.Errorf("Errors() = %v, want nil once commits succeed again", err)
+	}
+	// Commits that keep succeeding aren't reported.
+	_ = c.Flush()
+	touch(t, dir, "c.md")
+	c.Record("add c")
+	if err := c.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-c.Errors():
+		t.Errorf("Errors() = %v after a routine commit, want nothing", err)
+	default:
+	}
+}
+
+func TestCommitterRecordDoesNotBlock(t *testing.T) {
+	dir := gittest.NewRepo(t)
+	// A pre-commit hook that holds the first commit until the gate opens.
+	gates := t.TempDir()
+	started, gate := filepath.Join(gates, "started"), filepath.Join(gates, "gate")
+	hook := "#!/bin/sh\ntouch " + started + "\nwhile [ ! -f " + gate + " ]; do sleep 0.05; done\n"
+	if err := os.WriteFile(filepath.Join(dir, ".git", "hooks", "pre-commit"), []byte(hook), 0755); err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewCommitter(dir, CommitOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	touch(t, dir, "a.md")
+	c.Record("add a")
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if _, err := os.Stat(started); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the first commit never started")
+		}
+	}
+	recorded := make(chan struct{})
+	go func() {
+		defer close(recorded)
+		// More than any fixed buffer would hold while the commit hangs.
+		for i := 0; i < 200; i++ {
+			c.Record("edit")
+		}
+	}()
+	select {
+	case <-recorded:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Record() blocked behind a slow commit")
+	}
+
+	touch(t, dir, "b.md")
+	if err := os.WriteFile(gate, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if log := commitLog(t, dir); len(log) != 2 || log[1] != "feat(bujo): add a" {
+		t.Errorf("log = %q, want add a and one commit for the edits", log)
+	}
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/samakintunde/bujo/internal/git"
+	"github.com/samakintunde/bujo/internal/models"
+)
+
+// EntryHistory returns the commits that touched an entry or the entries it
+// was migrated, scheduled or moved from, oldest first.
+func (s *JournalService) EntryHistory(id string) ([]git.LogEntry, error) {
+	if !git.IsPresent() || !git.IsRepo(s.fs.Root) {
+		return nil, fmt.Errorf("journal at %s is not a git repository", s.fs.Root)
+	}
+
+	ids := []string{id}
+	chain, err := s.db.GetMigrationChain(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get migration chain: %w", err)
+	}
+	for _, e := range chain {
+		if e.ID == id {
+			break
+		}
+		ids = append(ids, e.ID)
+	}
+
+	log, err := git.EntryLog(s.fs.Root, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	return log, nil
+}
+
+// idList formats entry IDs for a commit body so that batch commits show up
+// in each entry's history.
+func idList(entries []models.Entry) string {
+	ids := make([]string, len(entries))
+	for i, e := range entries {
+		ids[i] = "#" + e.ID
+	}
+	return strings.Join(ids, " ")
+}
+
+// HistorySummary describes a history commit without the commit type prefix.
+func HistorySummary(c git.LogEntry) string {
+	return strings.TrimPrefix(c.Subject, "feat(bujo): ")
+}
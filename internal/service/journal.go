@@ -2,9 +2,10 @@ package service
 
 import (
 	"fmt"
-	"path/filepath"
+	"math"
 	"time"
 
+	"github.com/samakintunde/bujo/internal/clock"
 	"github.com/samakintunde/bujo/internal/git"
 	"github.com/samakintunde/bujo/internal/models"
 	"github.com/samakintunde/bujo/internal/storage"
@@ -15,13 +16,18 @@ type JournalService struct {
 	fs     *storage.FSStore
 	db     *storage.DBStore
 	syncer *sync.Syncer
+
+	committer  *Committer
+	gitErrs    chan error
+	gitFailing bool
 }
 
 func NewJournalService(fs *storage.FSStore, db *storage.DBStore, syncer *sync.Syncer) *JournalService {
 	return &JournalService{
-		fs:     fs,
-		db:     db,
-		syncer: syncer,
+		fs:      fs,
+		db:      db,
+		syncer:  syncer,
+		gitErrs: make(chan error, 8),
 	}
 }
 
@@ -44,13 +50,13 @@ func (s *JournalService) AddEntry(content string, entryType models.EntryType, da
 		return nil, fmt.Errorf("failed to sync file to db: %w", err)
 	}
 
-	s.gitCommit(path, fmt.Sprintf("feat(bujo): add %s #%s", entryType, entry.ID))
+	s.commit(fmt.Sprintf("add %s #%s", entryType, entry.ID))
 
 	return entry, nil
 }
 
 func (s *JournalService) UpdateEntryStatus(entry models.Entry, newStatus models.EntryStatus) error {
-	entry.Status = newStatus
+	entry.SetStatus(newStatus)
 
 	if err := s.fs.UpdateLine(entry.FilePath, entry.LineNumber, entry.RawString()); err != nil {
 		return fmt.Errorf("failed to update entry in file: %w", err)
@@ -60,22 +66,22 @@ func (s *JournalService) UpdateEntryStatus(entry models.Entry, newStatus models.
 		return fmt.Errorf("failed to sync file to db: %w", err)
 	}
 
-	s.gitCommit(filepath.Dir(entry.FilePath), fmt.Sprintf("feat(bujo): update %s #%s to %s", entry.Type, entry.ID, newStatus))
+	s.commit(fmt.Sprintf("update %s #%s to %s", entry.Type, entry.ID, newStatus))
 
 	return nil
 }
 
 func (s *JournalService) MigrateTask(entry models.Entry) (*models.Entry, error) {
-	entry.Status = models.EntryStatusMigrated
+	entry.SetStatus(models.EntryStatusMigrated)
 	if err := s.fs.UpdateLine(entry.FilePath, entry.LineNumber, entry.RawString()); err != nil {
 		return nil, fmt.Errorf("failed to update original entry: %w", err)
 	}
 
-	newEntry := models.NewEntry(models.EntryTypeTask, entry.Content)
+	newEntry := followUp(entry)
 	newEntry.MigrationCount = entry.MigrationCount + 1
 	newEntry.ParentID = entry.ID
 
-	todayPath, err := s.fs.EnsureDayPath(time.Now().Format(time.DateOnly))
+	todayPath, err := s.fs.EnsureDayPath(clock.Today().Format(time.DateOnly))
 	if err != nil {
 		return nil, fmt.Errorf("failed to ensure today path: %w", err)
 	}
@@ -92,18 +98,18 @@ func (s *JournalService) MigrateTask(entry models.Entry) (*models.Entry, error)
 		return nil, fmt.Errorf("failed to sync today file: %w", err)
 	}
 
-	s.gitCommit(filepath.Dir(todayPath), fmt.Sprintf("feat(bujo): migrate task #%s to #%s", entry.ID, newEntry.ID))
+	s.commit(fmt.Sprintf("migrate task #%s to #%s", entry.ID, newEntry.ID))
 
 	return newEntry, nil
 }
 
 func (s *JournalService) ScheduleTask(entry models.Entry, targetDate time.Time) (*models.Entry, error) {
-	entry.Status = models.EntryStatusScheduled
+	entry.SetStatus(models.EntryStatusScheduled)
 	if err := s.fs.UpdateLine(entry.FilePath, entry.LineNumber, entry.RawString()); err != nil {
 		return nil, fmt.Errorf("failed to update original entry: %w", err)
 	}
 
-	newEntry := models.NewEntry(models.EntryTypeTask, entry.Content)
+	newEntry := followUp(entry)
 	newEntry.RescheduleCount = entry.RescheduleCount + 1
 	newEntry.ParentID = entry.ID
 
@@ -125,11 +131,130 @@ func (s *JournalService) ScheduleTask(entry models.Entry, targetDate time.Time)
 		return nil, fmt.Errorf("failed to sync target file: %w", err)
 	}
 
-	s.gitCommit(filepath.Dir(targetPath), fmt.Sprintf("feat(bujo): schedule task #%s to %s as #%s", entry.ID, targetDateStr, newEntry.ID))
+	s.commit(fmt.Sprintf("schedule task #%s to %s as #%s", entry.ID, targetDateStr, newEntry.ID))
+
+	return newEntry, nil
+}
+
+// MoveToCollection files a task into a named collection such as "someday",
+// marking the original as migrated.
+func (s *JournalService) MoveToCollection(entry models.Entry, collection string) (*models.Entry, error) {
+	entry.SetStatus(models.EntryStatusMigrated)
+	if err := s.fs.UpdateLine(entry.FilePath, entry.LineNumber, entry.RawString()); err != nil {
+		return nil, fmt.Errorf("failed to update original entry: %w", err)
+	}
+
+	newEntry := followUp(entry)
+	newEntry.MigrationCount = entry.MigrationCount + 1
+	newEntry.ParentID = entry.ID
+
+	collectionPath, err := s.fs.EnsureCollectionPath(collection)
+	if err != nil {
+		return nil, fmt.Errorf("failed to ensure collection path: %w", err)
+	}
+	newEntry.FilePath = collectionPath
+
+	if err := s.fs.AppendLine(collectionPath, newEntry.RawString()); err != nil {
+		return nil, fmt.Errorf("failed to write collection entry: %w", err)
+	}
+
+	if err := s.syncer.SyncFile(entry.FilePath); err != nil {
+		return nil, fmt.Errorf("failed to sync original file: %w", err)
+	}
+	if err := s.syncer.SyncFile(collectionPath); err != nil {
+		return nil, fmt.Errorf("failed to sync collection file: %w", err)
+	}
+
+	s.commit(fmt.Sprintf("move task #%s to %s as #%s", entry.ID, collection, newEntry.ID))
 
 	return newEntry, nil
 }
 
+// RevertTask restores a task to the state captured in original and, when
+// createdID is set, removes the copy a migrate, schedule or move created.
+func (s *JournalService) RevertTask(original models.Entry, createdID string) error {
+	current, err := s.db.GetEntryByID(original.ID)
+	if err != nil {
+		return fmt.Errorf("failed to find original entry: %w", err)
+	}
+	original.FilePath = current.FilePath
+	original.LineNumber = current.LineNumber
+
+	if err := s.fs.UpdateLine(original.FilePath, original.LineNumber, original.RawString()); err != nil {
+		return fmt.Errorf("failed to restore original entry: %w", err)
+	}
+
+	if createdID != "" {
+		created, err := s.db.GetEntryByID(createdID)
+		if err != nil {
+			return fmt.Errorf("failed to find created entry: %w", err)
+		}
+		if err := s.fs.RemoveLine(created.FilePath, created.LineNumber); err != nil {
+			return fmt.Errorf("failed to remove created entry: %w", err)
+		}
+		if err := s.syncer.SyncFile(created.FilePath); err != nil {
+			return fmt.Errorf("failed to sync created file: %w", err)
+		}
+	}
+
+	if err := s.syncer.SyncFile(original.FilePath); err != nil {
+		return fmt.Errorf("failed to sync original file: %w", err)
+	}
+
+	s.commit(fmt.Sprintf("revert task #%s to %s", original.ID, original.Status))
+
+	return nil
+}
+
+// MoveEntry relocates an entry's line to position in the day file for
+// targetDate, keeping its ID and metadata. Position is a 1-based line number;
+// 0 appends to the end of the file.
+func (s *JournalService) MoveEntry(entry models.Entry, targetDate time.Time, position int) (*models.Entry, error) {
+	current, err := s.db.GetEntryByID(entry.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find entry: %w", err)
+	}
+
+	targetDateStr := targetDate.Format(time.DateOnly)
+	targetPath, err := s.fs.EnsureDayPath(targetDateStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to ensure target path: %w", err)
+	}
+
+	if targetPath == current.FilePath {
+		if position == 0 {
+			position = math.MaxInt
+		}
+		if err := s.fs.MoveLine(targetPath, current.LineNumber, position); err != nil {
+			return nil, fmt.Errorf("failed to move entry: %w", err)
+		}
+		if err := s.syncer.SyncFile(targetPath); err != nil {
+			return nil, fmt.Errorf("failed to sync file: %w", err)
+		}
+		s.commit(fmt.Sprintf("reorder %s #%s", current.Type, current.ID))
+	} else {
+		if err := s.fs.InsertLine(targetPath, position, current.RawContent); err != nil {
+			return nil, fmt.Errorf("failed to write entry to target: %w", err)
+		}
+		if err := s.fs.RemoveLine(current.FilePath, current.LineNumber); err != nil {
+			return nil, fmt.Errorf("failed to remove entry from source: %w", err)
+		}
+		if err := s.syncer.SyncFile(current.FilePath); err != nil {
+			return nil, fmt.Errorf("failed to sync source file: %w", err)
+		}
+		if err := s.syncer.SyncFile(targetPath); err != nil {
+			return nil, fmt.Errorf("failed to sync target file: %w", err)
+		}
+		s.commit(fmt.Sprintf("move %s #%s to %s", current.Type, current.ID, targetDateStr))
+	}
+
+	moved, err := s.db.GetEntryByID(entry.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find moved entry: %w", err)
+	}
+	return &moved, nil
+}
+
 func (s *JournalService) GetEntriesByDate(date time.Time) ([]models.Entry, error) {
 	dateStr := date.Format(time.DateOnly)
 	path := s.fs.GetDayPath(dateStr)
@@ -137,11 +262,25 @@ func (s *JournalService) GetEntriesByDate(date time.Time) ([]models.Entry, error
 	if err := s.syncer.SyncFile(path); err != nil {
 		return nil, fmt.Errorf("failed to sync file: %w", err)
 	}
+	return s.dayEntries(date)
+}
 
-	entries, err := s.db.GetEntriesByFile(path)
+// dayEntries reads date's entries from the index. They come from every log
+// of the day, including any left in an earlier layout's place.
+func (s *JournalService) dayEntries(date time.Time) ([]models.Entry, error) {
+	indexed, err := s.db.GetEntriesByDate(date)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get entries: %w", err)
 	}
+	var entries []models.Entry
+	for _, e := range indexed {
+		// Other files are dated by their modification time.
+		if _, ok := storage.DayFromPath(s.fs.Root, e.FilePath); ok {
+			entries = append(entries, e)
+		}
+	}
+	models.SortEventsByTime(entries)
+	models.SortTasksByPriority(entries)
 
 	return entries, nil
 }
@@ -170,6 +309,30 @@ func (s *JournalService) GetMigrationChain(entryID string) ([]models.Entry, erro
 	return chain, nil
 }
 
+func (s *JournalService) GetStats(opts storage.StatsOptions) (*storage.Stats, error) {
+	stats, err := s.db.GetStats(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stats: %w", err)
+	}
+	return stats, nil
+}
+
+func (s *JournalService) GetDailyActivity(from, to time.Time) ([]storage.DayActivity, error) {
+	activity, err := s.db.GetDailyActivity(from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get daily activity: %w", err)
+	}
+	return activity, nil
+}
+
+func (s *JournalService) GetMostRescheduled(limit int) ([]storage.RescheduledTask, error) {
+	tasks, err := s.db.GetMostRescheduled(limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get most rescheduled tasks: %w", err)
+	}
+	return tasks, nil
+}
+
 func (s *JournalService) GetLastOpenedAt() (time.Time, error) {
 	return s.db.GetLastOpenedAt()
 }
@@ -178,8 +341,71 @@ func (s *JournalService) SetLastOpenedAt(t time.Time) error {
 	return s.db.SetLastOpenedAt(t)
 }
 
-func (s *JournalService) gitCommit(dir, message string) {
+// followUp creates the task that continues entry elsewhere, keeping what
+// belongs to the task rather than to the day it was on.
+func followUp(entry models.Entry) *models.Entry {
+	next := models.NewEntry(models.EntryTypeTask, entry.Content)
+	next.DueDate = entry.DueDate
+	next.Priority = entry.Priority
+	next.Tags = entry.Tags
+	return next
+}
+
+// GetDeadlines returns open tasks due by until, including overdue ones.
+func (s *JournalService) GetDeadlines(until time.Time) ([]models.Entry, error) {
+	entries, err := s.db.GetDeadlines(until)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deadlines: %w", err)
+	}
+	return entries, nil
+}
+
+// SetCommitter hands commits to c instead of committing synchronously after
+// each change.
+func (s *JournalService) SetCommitter(c *Committer) {
+	s.committer = c
+}
+
+// FlushCommits commits any changes the committer is still holding.
+func (s *JournalService) FlushCommits() error {
+	if s.committer == nil {
+		return nil
+	}
+	return s.committer.Flush()
+}
+
+// commit records a change. Without a committer it is committed right away
+// with the default message.
+func (s *JournalService) commit(summary string) {
+	if s.committer != nil {
+		s.committer.Record(summary)
+		return
+	}
 	if git.IsPresent() && git.IsRepo(s.fs.Root) {
-		_ = git.Commit(dir, message)
+		err := git.Commit(s.fs.Root, "feat(bujo): "+summary)
+		if err == nil && !s.gitFailing {
+			return
+		}
+		s.gitFailing = err != nil
+		select {
+		case s.gitErrs <- err:
+		default:
+		}
+	}
+}
+
+// GitErrors delivers commit failures, and nil once commits succeed again.
+func (s *JournalService) GitErrors() <-chan error {
+	if s.committer != nil {
+		return s.committer.Errors()
+	}
+	return s.gitErrs
+}
+
+// GitStatus summarises the journal repository and its uncommitted changes.
+func (s *JournalService) GitStatus() (*git.RepoStatus, error) {
+	if !git.IsPresent() || !git.IsRepo(s.fs.Root) {
+		return nil, fmt.Errorf("journal at %s is not a git repository", s.fs.Root)
 	}
+	return git.Status(s.fs.Root)
 }
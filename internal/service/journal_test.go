@@ -2,6 +2,8 @@ package service
 
 import (
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -108,6 +110,44 @@ func TestUpdateEntryStatus(t *testing.T) {
 	}
 }
 
+func TestUpdateEntryStatusRecordsCompletion(t *testing.T) {
+	svc, _, db, cleanup := setupTestService(t)
+	defer cleanup()
+
+	entry, err := svc.AddEntry("Test task", models.EntryTypeTask, time.Now())
+	if err != nil {
+		t.Fatalf("AddEntry failed: %v", err)
+	}
+	entries, _ := db.GetEntriesByFile(entry.FilePath)
+	if !entries[0].CreatedAt.Equal(entry.CreatedAt) {
+		t.Errorf("CreatedAt = %v, want %v", entries[0].CreatedAt, entry.CreatedAt)
+	}
+
+	if err := svc.UpdateEntryStatus(entries[0], models.EntryStatusCompleted); err != nil {
+		t.Fatalf("UpdateEntryStatus failed: %v", err)
+	}
+	entries, _ = db.GetEntriesByFile(entry.FilePath)
+	done := entries[0]
+	if done.CompletedAt.IsZero() {
+		t.Fatal("CompletedAt not recorded")
+	}
+	if !done.CreatedAt.Equal(entry.CreatedAt) {
+		t.Errorf("CreatedAt changed to %v, want %v", done.CreatedAt, entry.CreatedAt)
+	}
+	bytes, _ := os.ReadFile(entry.FilePath)
+	if !strings.Contains(string(bytes), `"done":"`) {
+		t.Errorf("Expected completion time in metadata, got: %s", string(bytes))
+	}
+
+	if err := svc.UpdateEntryStatus(done, models.EntryStatusOpen); err != nil {
+		t.Fatalf("UpdateEntryStatus failed: %v", err)
+	}
+	entries, _ = db.GetEntriesByFile(entry.FilePath)
+	if !entries[0].CompletedAt.IsZero() {
+		t.Errorf("CompletedAt = %v after reopening, want zero", entries[0].CompletedAt)
+	}
+}
+
 func TestMigrateTask(t *testing.T) {
 	svc, _, db, cleanup := setupTestService(t)
 	defer cleanup()
@@ -139,6 +179,125 @@ func TestMigrateTask(t *testing.T) {
 	}
 }
 
+func TestMigrateTaskKeepsDeadline(t *testing.T) {
+	svc, fs, db, cleanup := setupTestService(t)
+	defer cleanup()
+
+	yesterday := time.Now().AddDate(0, 0, -1)
+	path, err := fs.EnsureDayPath(yesterday.Format(time.DateOnly))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Deadline kept in metadata rather than the content.
+	if err := fs.AppendLine(path, `- [ ] Report <!-- {"id":"t1","due":"2025-03-07"} -->`); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := svc.GetEntriesByDate(yesterday)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("GetEntriesByDate = %v, %v", entries, err)
+	}
+
+	newEntry, err := svc.MigrateTask(entries[0])
+	if err != nil {
+		t.Fatalf("MigrateTask failed: %v", err)
+	}
+
+	migrated, err := db.GetEntryByID(newEntry.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if migrated.DueDate != "2025-03-07" {
+		t.Errorf("DueDate after migration = %q, want 2025-03-07", migrated.DueDate)
+	}
+
+	deadlines, err := svc.GetDeadlines(time.Date(2025, 3, 7, 0, 0, 0, 0, time.Local))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deadlines) != 1 || deadlines[0].ID != newEntry.ID {
+		t.Errorf("GetDeadlines() = %+v, want only the migrated task", deadlines)
+	}
+}
+
+func TestRevertTask(t *testing.T) {
+	svc, _, db, cleanup := setupTestService(t)
+	defer cleanup()
+
+	yesterday := time.Now().AddDate(0, 0, -1)
+	entry, err := svc.AddEntry("Old task", models.EntryTypeTask, yesterday)
+	if err != nil {
+		t.Fatalf("AddEntry failed: %v", err)
+	}
+
+	entries, _ := db.GetEntriesByFile(entry.FilePath)
+	original := entries[0]
+
+	newEntry, err := svc.MigrateTask(original)
+	if err != nil {
+		t.Fatalf("MigrateTask failed: %v", err)
+	}
+
+	if err := svc.RevertTask(original, newEntry.ID); err != nil {
+		t.Fatalf("RevertTask failed: %v", err)
+	}
+
+	oldEntries, _ := db.GetEntriesByFile(original.FilePath)
+	if oldEntries[0].Status != models.EntryStatusOpen {
+		t.Errorf("Expected original to be open again, got %s", oldEntries[0].Status)
+	}
+
+	todayEntries, _ := db.GetEntriesByFile(newEntry.FilePath)
+	if len(todayEntries) != 0 {
+		t.Errorf("Expected migrated copy to be removed, got %d entries", len(todayEntries))
+	}
+}
+
+func TestMoveEntry(t *testing.T) {
+	svc, _, _, cleanup := setupTestService(t)
+	defer cleanup()
+
+	today := time.Now()
+	for _, content := range []string{"One", "Two", "Three"} {
+		if _, err := svc.AddEntry(content, models.EntryTypeTask, today); err != nil {
+			t.Fatalf("AddEntry failed: %v", err)
+		}
+	}
+	entries, _ := svc.GetEntriesByDate(today)
+
+	moved, err := svc.MoveEntry(entries[2], today, entries[0].LineNumber)
+	if err != nil {
+		t.Fatalf("MoveEntry within day failed: %v", err)
+	}
+	if moved.ID != entries[2].ID || moved.LineNumber != entries[0].LineNumber {
+		t.Errorf("Expected %s on line %d, got %s on line %d", entries[2].ID, entries[0].LineNumber, moved.ID, moved.LineNumber)
+	}
+
+	reordered, _ := svc.GetEntriesByDate(today)
+	for i, want := range []string{"Three", "One", "Two"} {
+		if reordered[i].Content != want {
+			t.Errorf("entry %d = %s, want %s", i, reordered[i].Content, want)
+		}
+	}
+
+	tomorrow := today.AddDate(0, 0, 1)
+	moved, err = svc.MoveEntry(reordered[1], tomorrow, 0)
+	if err != nil {
+		t.Fatalf("MoveEntry to another day failed: %v", err)
+	}
+	if moved.ID != reordered[1].ID || moved.MigrationCount != 0 {
+		t.Errorf("Expected ID and metadata preserved, got %+v", moved)
+	}
+
+	remaining, _ := svc.GetEntriesByDate(today)
+	if len(remaining) != 2 || remaining[1].Content != "Two" || remaining[1].LineNumber != reordered[2].LineNumber-1 {
+		t.Errorf("Expected line numbers to close up after removal, got %+v", remaining)
+	}
+	target, _ := svc.GetEntriesByDate(tomorrow)
+	if len(target) != 1 || target[0].ID != reordered[1].ID {
+		t.Errorf("Expected moved entry in tomorrow's file, got %+v", target)
+	}
+}
+
 func TestScheduleTask(t *testing.T) {
 	svc, _, db, cleanup := setupTestService(t)
 	defer cleanup()
@@ -170,6 +329,42 @@ func TestScheduleTask(t *testing.T) {
 	}
 }
 
+func TestMoveToCollection(t *testing.T) {
+	svc, fs, db, cleanup := setupTestService(t)
+	defer cleanup()
+
+	yesterday := time.Now().AddDate(0, 0, -1)
+	entry, err := svc.AddEntry("Learn the banjo", models.EntryTypeTask, yesterday)
+	if err != nil {
+		t.Fatalf("AddEntry failed: %v", err)
+	}
+
+	entries, _ := db.GetEntriesByFile(entry.FilePath)
+	dbEntry := entries[0]
+
+	newEntry, err := svc.MoveToCollection(dbEntry, "someday")
+	if err != nil {
+		t.Fatalf("MoveToCollection failed: %v", err)
+	}
+
+	if newEntry.FilePath != fs.GetCollectionPath("someday") {
+		t.Errorf("Expected collection path %s, got %s", fs.GetCollectionPath("someday"), newEntry.FilePath)
+	}
+	if newEntry.ParentID != dbEntry.ID {
+		t.Errorf("Expected ParentID %s, got %s", dbEntry.ID, newEntry.ParentID)
+	}
+
+	collected, _ := db.GetEntriesByFile(newEntry.FilePath)
+	if len(collected) != 1 || collected[0].ID != newEntry.ID {
+		t.Errorf("Expected collection to hold %s, got %+v", newEntry.ID, collected)
+	}
+
+	oldEntries, _ := db.GetEntriesByFile(dbEntry.FilePath)
+	if oldEntries[0].Status != models.EntryStatusMigrated {
+		t.Errorf("Expected old entry status to be migrated, got %s", oldEntries[0].Status)
+	}
+}
+
 func TestGetEntriesByDate(t *testing.T) {
 	svc, _, _, cleanup := setupTestService(t)
 	defer cleanup()
@@ -189,6 +384,75 @@ func TestGetEntriesByDate(t *testing.T) {
 	}
 }
 
+func TestGetEntriesByDateAfterLayoutChange(t *testing.T) {
+	svc, fs, _, cleanup := setupTestService(t)
+	defer cleanup()
+
+	today := time.Now()
+	if _, err := svc.AddEntry("Written before", models.EntryTypeTask, today); err != nil {
+		t.Fatalf("AddEntry failed: %v", err)
+	}
+	if err := storage.ConfigureLayout(".", "YYYY-MM-DD"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = storage.ConfigureLayout("YYYY/MM", "YYYY-MM-DD") })
+	if _, err := svc.AddEntry("Written after", models.EntryTypeTask, today); err != nil {
+		t.Fatalf("AddEntry failed: %v", err)
+	}
+	// A collection edited today is dated today too, but is not a log.
+	collection := filepath.Join(fs.Root, "collections", "someday.md")
+	if err := os.MkdirAll(filepath.Dir(collection), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(collection, []byte("- [ ] Some day\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.syncer.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := svc.GetEntriesByDate(today)
+	if err != nil {
+		t.Fatalf("GetEntriesByDate failed: %v", err)
+	}
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.Content)
+	}
+	slices.Sort(got)
+	want := []string{"Written after", "Written before"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("entries = %q, want %q", got, want)
+	}
+}
+
+func TestGetEntriesByDateSortsEvents(t *testing.T) {
+	svc, _, _, cleanup := setupTestService(t)
+	defer cleanup()
+
+	today := time.Now()
+	_, _ = svc.AddEntry("Retro 16:00-17:00", models.EntryTypeEvent, today)
+	_, _ = svc.AddEntry("Task", models.EntryTypeTask, today)
+	_, _ = svc.AddEntry("Standup at 9:30am", models.EntryTypeEvent, today)
+
+	entries, err := svc.GetEntriesByDate(today)
+	if err != nil {
+		t.Fatalf("GetEntriesByDate failed: %v", err)
+	}
+
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.Content)
+	}
+	want := []string{"Standup at 9:30am", "Task", "Retro 16:00-17:00"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("order = %q, want %q", got, want)
+	}
+	if entries[0].StartTime != "09:30" || entries[2].EndTime != "17:00" {
+		t.Errorf("times not indexed: %+v", entries)
+	}
+}
+
 func TestGetStaleTasks(t *testing.T) {
 	svc, _, _, cleanup := setupTestService(t)
 	defer cleanup()
@@ -227,3 +491,66 @@ func TestGetMigrationChain(t *testing.T) {
 		t.Errorf("Expected chain length 2, got %d", len(chain))
 	}
 }
+
+func TestRecordReview(t *testing.T) {
+	svc, fs, db, cleanup := setupTestService(t)
+	defer cleanup()
+
+	session := models.NewReviewSession("Last week", "#work")
+	session.Decisions = []models.ReviewDecision{
+		{EntryID: "a", Content: "Ship it", Decision: models.ReviewDecisionMigrated, Target: "2024-03-10", DecidedAt: time.Now()},
+		{EntryID: "b", Content: "Plan", Decision: models.ReviewDecisionSkipped, DecidedAt: time.Now()},
+	}
+
+	logPath := fs.Root + "/reviews.md"
+	if err := svc.RecordReview(*session, logPath); err != nil {
+		t.Fatalf("RecordReview failed: %v", err)
+	}
+
+	sessions, err := db.GetReviewSessions(1)
+	if err != nil {
+		t.Fatalf("GetReviewSessions failed: %v", err)
+	}
+	if len(sessions) != 1 || len(sessions[0].Decisions) != 2 {
+		t.Fatalf("Expected 1 session with 2 decisions, got %+v", sessions)
+	}
+	if sessions[0].FinishedAt.IsZero() {
+		t.Error("Expected FinishedAt to be set")
+	}
+
+	bytes, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("Failed to read review log: %v", err)
+	}
+	log := string(bytes)
+	for _, want := range []string{"## Review", "Last week · #work", "* migrated: Ship it → 2024-03-10", "Migrated 1", "Skipped 1"} {
+		if !strings.Contains(log, want) {
+			t.Errorf("Review log missing %q:\n%s", want, log)
+		}
+	}
+
+	if err := svc.syncer.SyncFile(logPath); err != nil {
+		t.Fatalf("SyncFile failed: %v", err)
+	}
+	if entries, _ := db.GetEntriesByFile(logPath); len(entries) != 0 {
+		t.Errorf("Review log should not be indexed as entries, got %d", len(entries))
+	}
+}
+
+func TestInJournal(t *testing.T) {
+	root := filepath.Join("/home", "me", "journal")
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{filepath.Join(root, "reviews.md"), true},
+		{filepath.Join(root, "logs", "reviews.md"), true},
+		{filepath.Join(root+"-archive", "reviews.md"), false},
+		{filepath.Join(root, "..", "reviews.md"), false},
+	}
+	for _, tt := range tests {
+		if got := inJournal(root, tt.path); got != tt.want {
+			t.Errorf("inJournal(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/samakintunde/bujo/internal/git"
+)
+
+// SyncRemote commits, pulls and pushes the journal repository, then
+// re-indexes the files the pull changed.
+func (s *JournalService) SyncRemote(opts git.SyncOptions) (*git.SyncResult, error) {
+	if !git.IsPresent() || !git.IsRepo(s.fs.Root) {
+		return nil, fmt.Errorf("journal at %s is not a git repository", s.fs.Root)
+	}
+
+	if err := s.FlushCommits(); err != nil {
+		return nil, fmt.Errorf("failed to commit pending changes: %w", err)
+	}
+
+	result, err := git.Sync(s.fs.Root, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sync with %s: %w", opts.Remote, err)
+	}
+
+	if err := s.syncer.SyncPaths(result.Changed); err != nil {
+		return nil, fmt.Errorf("failed to index pulled changes: %w", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/samakintunde/bujo/internal/git"
+	"github.com/samakintunde/bujo/internal/gittest"
+	"github.com/samakintunde/bujo/internal/models"
+)
+
+func TestSyncRemote(t *testing.T) {
+	if !git.IsPresent() {
+		t.Skip("git not installed")
+	}
+	svc, fs, db, cleanup := setupTestService(t)
+	defer cleanup()
+
+	remote := t.TempDir()
+	gittest.Run(t, remote, "init", "--bare", "-b", "main")
+
+	other := t.TempDir()
+	gittest.Run(t, other, "clone", remote, ".")
+	gittest.Run(t, other, "config", "user.name", "Test")
+	gittest.Run(t, other, "config", "user.email", "test@example.com")
+	dayFile := filepath.Join("2024", "03", "2024-03-01.md")
+	if err := os.MkdirAll(filepath.Join(other, "2024", "03"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(other, dayFile), []byte("- [ ] pulled task <!-- {\"id\":\"remote1\"} -->\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gittest.Run(t, other, "add", ".")
+	gittest.Run(t, other, "commit", "-m", "remote entry")
+	gittest.Run(t, other, "push", "origin", "HEAD:main")
+
+	gittest.Init(t, fs.Root)
+	gittest.Run(t, fs.Root, "remote", "add", "origin", remote)
+	// Keep the index out of the repository, as a real journal does.
+	if err := os.WriteFile(filepath.Join(fs.Root, ".gitignore"), []byte("*.sqlite*\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := svc.AddEntry("local task", models.EntryTypeTask, time.Now()); err != nil {
+		t.Fatalf("AddEntry failed: %v", err)
+	}
+
+	result, err := svc.SyncRemote(git.SyncOptions{Remote: "origin", Strategy: git.StrategyRebase})
+	if err != nil {
+		t.Fatalf("SyncRemote failed: %v", err)
+	}
+	if !result.Pushed {
+		t.Error("Expected local changes to be pushed")
+	}
+
+	entries, err := db.GetEntriesByFile(filepath.Join(fs.Root, dayFile))
+	if err != nil {
+		t.Fatalf("GetEntriesByFile failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].ID != "remote1" {
+		t.Errorf("Expected pulled entry to be indexed, got %+v", entries)
+	}
+}
+
+func TestEntryHistory(t *testing.T) {
+	if !git.IsPresent() {
+		t.Skip("git not installed")
+	}
+	svc, fs, db, cleanup := setupTestService(t)
+	defer cleanup()
+
+	gittest.Init(t, fs.Root)
+
+	yesterday := time.Now().AddDate(0, 0, -1)
+	entry, err := svc.AddEntry("Traced task", models.EntryTypeTask, yesterday)
+	if err != nil {
+		t.Fatalf("AddEntry failed: %v", err)
+	}
+	entries, _ := db.GetEntriesByFile(entry.FilePath)
+	migrated, err := svc.MigrateTask(entries[0])
+	if err != nil {
+		t.Fatalf("MigrateTask failed: %v", err)
+	}
+
+	log, err := svc.EntryHistory(migrated.ID)
+	if err != nil {
+		t.Fatalf("EntryHistory failed: %v", err)
+	}
+	if len(log) != 2 {
+		t.Fatalf("Expected add and migrate commits, got %d", len(log))
+	}
+	if got := HistorySummary(log[0]); got != "add task #"+entry.ID {
+		t.Errorf("First commit = %q, want the original's add", got)
+	}
+	if !strings.HasPrefix(HistorySummary(log[1]), "migrate task #"+entry.ID) {
+		t.Errorf("Second commit = %q, want the migration", HistorySummary(log[1]))
+	}
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/samakintunde/bujo/internal/models"
+	"github.com/samakintunde/bujo/internal/storage"
+)
+
+func (s *JournalService) GetStaleTasksMatching(filter storage.StaleFilter) ([]models.Entry, error) {
+	tasks, err := s.db.GetStaleTasksMatching(filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stale tasks: %w", err)
+	}
+	return tasks, nil
+}
+
+func (s *JournalService) CountStaleTasksMatching(filter storage.StaleFilter) (int, error) {
+	count, err := s.db.CountStaleTasksMatching(filter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count stale tasks: %w", err)
+	}
+	return count, nil
+}
+
+// RecordReview saves a finished review session to the index and, when
+// logPath is set, appends a summary to a Markdown review log.
+func (s *JournalService) RecordReview(session models.ReviewSession, logPath string) error {
+	if session.FinishedAt.IsZero() {
+		session.FinishedAt = time.Now()
+	}
+
+	if err := s.db.SaveReviewSession(session); err != nil {
+		return fmt.Errorf("failed to save review session: %w", err)
+	}
+
+	if logPath == "" {
+		return nil
+	}
+
+	if err := s.fs.AppendLine(logPath, formatReviewLog(session)); err != nil {
+		return fmt.Errorf("failed to write review log: %w", err)
+	}
+
+	if inJournal(s.fs.Root, logPath) {
+		s.commit(fmt.Sprintf("log review %s", session.ID))
+	}
+
+	return nil
+}
+
+// inJournal reports whether path is inside the journal at root, and so is
+// committed with it.
+func inJournal(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && filepath.IsLocal(rel)
+}
+
+func (s *JournalService) GetReviewSessions(limit int) ([]models.ReviewSession, error) {
+	sessions, err := s.db.GetReviewSessions(limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get review sessions: %w", err)
+	}
+	return sessions, nil
+}
+
+// formatReviewLog renders a session as a Markdown section. Decisions use "*"
+// bullets so the log isn't mistaken for journal entries if it lives inside
+// the journal.
+func formatReviewLog(session models.ReviewSession) string {
+	var b strings.Builder
+
+	title := session.Scope
+	if session.Filter != "" {
+		title += " · " + session.Filter
+	}
+	fmt.Fprintf(&b, "## Review %s · %s\n\n", session.StartedAt.Format("2006-01-02 15:04"), title)
+
+	for _, d := range session.Decisions {
+		line := fmt.Sprintf("* %s: %s", d.Decision, d.Content)
+		if d.Target != "" {
+			line += " → " + d.Target
+		}
+		b.WriteString(line + "\n")
+	}
+	if len(session.Decisions) > 0 {
+		b.WriteString("\n")
+	}
+
+	fmt.Fprintf(&b, "Migrated %d · Scheduled %d · Completed %d · Cancelled %d · Someday %d · Skipped %d\n",
+		session.Count(models.ReviewDecisionMigrated), session.Count(models.ReviewDecisionScheduled),
+		session.Count(models.ReviewDecisionCompleted), session.Count(models.ReviewDecisionCancelled),
+		session.Count(models.ReviewDecisionSomeday), session.Count(models.ReviewDecisionSkipped))
+
+	return b.String()
+}
@@ -8,6 +8,10 @@ import (
 	"time"
 )
 
+// CollectionsDir holds named collections (e.g. someday) that live outside
+// the daily logs.
+const CollectionsDir = "collections"
+
 type FSStore struct {
 	Root string
 }
@@ -21,11 +25,7 @@ func (fs *FSStore) GetDayPath(dateStr string) string {
 	if err != nil {
 		return filepath.Join(fs.Root, dateStr+".md")
 	}
-	year := parsedDate.Format("2006")
-	month := parsedDate.Format("01")
-	fileName := dateStr + ".md"
-
-	return filepath.Join(fs.Root, year, month, fileName)
+	return filepath.Join(fs.Root, layout.path(parsedDate))
 }
 
 func (fs *FSStore) EnsureDayPath(dateStr string) (string, error) {
@@ -37,6 +37,18 @@ func (fs *FSStore) EnsureDayPath(dateStr string) (string, error) {
 	return path, nil
 }
 
+func (fs *FSStore) GetCollectionPath(name string) string {
+	return filepath.Join(fs.Root, CollectionsDir, name+".md")
+}
+
+func (fs *FSStore) EnsureCollectionPath(name string) (string, error) {
+	path := fs.GetCollectionPath(name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
 func (fs *FSStore) AppendLine(path, content string) error {
 	// Adding O_CREATE creates the file if missing. Neat!
 	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
@@ -65,3 +77,98 @@ func (fs *FSStore) UpdateLine(path string, lineNum int, content string) error {
 	newContent := strings.Join(lines, "\n")
 	return os.WriteFile(path, []byte(newContent), 0644)
 }
+
+func (fs *FSStore) RemoveLine(path string, lineNum int) error {
+	f, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	lines := strings.Split(string(f), "\n")
+	if lineNum < 1 || lineNum > len(lines) {
+		return fmt.Errorf("line number out of range")
+	}
+	lines = append(lines[:lineNum-1], lines[lineNum:]...)
+	newContent := strings.Join(lines, "\n")
+	return os.WriteFile(path, []byte(newContent), 0644)
+}
+
+// UpdateLines rewrites several lines of a file in a single write. Keys are
+// 1-based line numbers.
+func (fs *FSStore) UpdateLines(path string, updates map[int]string) error {
+	f, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	lines := strings.Split(string(f), "\n")
+	for lineNum, content := range updates {
+		if lineNum < 1 || lineNum > len(lines) {
+			return fmt.Errorf("line number out of range")
+		}
+		lines[lineNum-1] = content
+	}
+	newContent := strings.Join(lines, "\n")
+	return os.WriteFile(path, []byte(newContent), 0644)
+}
+
+func (fs *FSStore) AppendLines(path string, contents []string) error {
+	if len(contents) == 0 {
+		return nil
+	}
+	return fs.AppendLine(path, strings.Join(contents, "\n"))
+}
+
+// MoveLine moves line from to position to, shifting the lines in between.
+// Both are 1-based; to is clamped to the last line of the file.
+func (fs *FSStore) MoveLine(path string, from, to int) error {
+	f, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	lines, trailing := splitLines(string(f))
+	if from < 1 || from > len(lines) {
+		return fmt.Errorf("line number out of range")
+	}
+	to = max(1, min(to, len(lines)))
+
+	line := lines[from-1]
+	lines = append(lines[:from-1], lines[from:]...)
+	lines = append(lines[:to-1], append([]string{line}, lines[to-1:]...)...)
+	return os.WriteFile(path, []byte(joinLines(lines, trailing)), 0644)
+}
+
+// InsertLine inserts content so that it becomes line lineNum, appending
+// when lineNum is 0 or past the end of the file.
+func (fs *FSStore) InsertLine(path string, lineNum int, content string) error {
+	f, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fs.AppendLine(path, content)
+		}
+		return err
+	}
+	lines, trailing := splitLines(string(f))
+	if lineNum < 1 || lineNum > len(lines) {
+		return fs.AppendLine(path, content)
+	}
+	lines = append(lines[:lineNum-1], append([]string{content}, lines[lineNum-1:]...)...)
+	return os.WriteFile(path, []byte(joinLines(lines, trailing)), 0644)
+}
+
+// splitLines splits file content into lines, reporting whether it ended with
+// a newline so joinLines can restore it.
+func splitLines(content string) ([]string, bool) {
+	trailing := strings.HasSuffix(content, "\n")
+	content = strings.TrimSuffix(content, "\n")
+	if content == "" {
+		return nil, trailing
+	}
+	return strings.Split(content, "\n"), trailing
+}
+
+func joinLines(lines []string, trailing bool) string {
+	s := strings.Join(lines, "\n")
+	if trailing {
+		s += "\n"
+	}
+	return s
+}
@@ -139,3 +139,82 @@ func TestUpdateLine_OutOfRange(t *testing.T) {
 		t.Error("UpdateLine(5) should error for out of range")
 	}
 }
+
+func TestUpdateLines(t *testing.T) {
+	dir := t.TempDir()
+	fs := &FSStore{Root: dir}
+	path := filepath.Join(dir, "test.md")
+
+	if err := os.WriteFile(path, []byte("one\ntwo\nthree"), 0644); err != nil {
+		t.Fatalf("WriteFile() error: %v", err)
+	}
+
+	if err := fs.UpdateLines(path, map[int]string{1: "ONE", 3: "THREE"}); err != nil {
+		t.Fatalf("UpdateLines() error: %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error: %v", err)
+	}
+	if string(content) != "ONE\ntwo\nTHREE" {
+		t.Errorf("content = %q, want %q", content, "ONE\ntwo\nTHREE")
+	}
+
+	if err := fs.UpdateLines(path, map[int]string{9: "bad"}); err == nil {
+		t.Error("UpdateLines(9) should error for out of range")
+	}
+}
+
+func TestMoveLine(t *testing.T) {
+	dir := t.TempDir()
+	fs := &FSStore{Root: dir}
+	path := filepath.Join(dir, "test.md")
+
+	tests := []struct {
+		from, to int
+		want     string
+	}{
+		{1, 3, "b\nc\na\n"},
+		{3, 1, "c\na\nb\n"},
+		{2, 99, "a\nc\nb\n"},
+		{2, 2, "a\nb\nc\n"},
+	}
+	for _, tt := range tests {
+		if err := os.WriteFile(path, []byte("a\nb\nc\n"), 0644); err != nil {
+			t.Fatalf("WriteFile() error: %v", err)
+		}
+		if err := fs.MoveLine(path, tt.from, tt.to); err != nil {
+			t.Fatalf("MoveLine(%d, %d) error: %v", tt.from, tt.to, err)
+		}
+		content, _ := os.ReadFile(path)
+		if string(content) != tt.want {
+			t.Errorf("MoveLine(%d, %d) = %q, want %q", tt.from, tt.to, content, tt.want)
+		}
+	}
+
+	if err := fs.MoveLine(path, 4, 1); err == nil {
+		t.Error("MoveLine(4) should error for out of range")
+	}
+}
+
+func TestInsertLine(t *testing.T) {
+	dir := t.TempDir()
+	fs := &FSStore{Root: dir}
+	path := filepath.Join(dir, "test.md")
+
+	if err := os.WriteFile(path, []byte("a\nc\n"), 0644); err != nil {
+		t.Fatalf("WriteFile() error: %v", err)
+	}
+	if err := fs.InsertLine(path, 2, "b"); err != nil {
+		t.Fatalf("InsertLine() error: %v", err)
+	}
+	if err := fs.InsertLine(path, 0, "d"); err != nil {
+		t.Fatalf("InsertLine() error: %v", err)
+	}
+
+	content, _ := os.ReadFile(path)
+	if string(content) != "a\nb\nc\nd\n" {
+		t.Errorf("content = %q, want %q", content, "a\nb\nc\nd\n")
+	}
+}
@@ -0,0 +1,200 @@
+package storage
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dayLayout places daily logs in the journal, as a path pattern without
+// .md using YYYY, MM and DD.
+type dayLayout struct {
+	pattern string
+	match   *regexp.Regexp
+	// tokens lists the token each group of match captures, in order.
+	tokens []string
+}
+
+var layout = mustLayout("YYYY/MM", "YYYY-MM-DD")
+
+var layoutTokens = map[string]string{
+	"YYYY": `(\d{4})`,
+	"MM":   `(\d{2})`,
+	"DD":   `(\d{2})`,
+}
+
+// ConfigureLayout sets where daily logs go. A dir of "." puts them in the
+// journal's root.
+func ConfigureLayout(dir, file string) error {
+	l, err := newLayout(dir, file)
+	if err != nil {
+		return err
+	}
+	layout = l
+	return nil
+}
+
+func mustLayout(dir, file string) *dayLayout {
+	l, err := newLayout(dir, file)
+	if err != nil {
+		panic(err)
+	}
+	return l
+}
+
+func newLayout(dir, file string) (*dayLayout, error) {
+	if file == "" || strings.ContainsAny(file, `/\`) {
+		return nil, fmt.Errorf("file pattern %q must be a file name", file)
+	}
+	pattern := filepath.ToSlash(filepath.Join(dir, file))
+	if !filepath.IsLocal(pattern) {
+		return nil, fmt.Errorf("directory pattern %q leaves the journal", dir)
+	}
+
+	l := &dayLayout{pattern: pattern}
+	var expr strings.Builder
+	expr.WriteString("^")
+	for rest := pattern; rest != ""; {
+		token := ""
+		for t := range layoutTokens {
+			if strings.HasPrefix(rest, t) {
+				token = t
+				break
+			}
+		}
+		if token == "" {
+			expr.WriteString(regexp.QuoteMeta(rest[:1]))
+			rest = rest[1:]
+			continue
+		}
+		expr.WriteString(layoutTokens[token])
+		l.tokens = append(l.tokens, token)
+		rest = rest[len(token):]
+	}
+	expr.WriteString("$")
+	for t := range layoutTokens {
+		if !strings.Contains(pattern, t) {
+			return nil, fmt.Errorf("pattern %q has no %s", pattern, t)
+		}
+	}
+	l.match = regexp.MustCompile(expr.String())
+	return l, nil
+}
+
+// path is where date's log goes, relative to the journal.
+func (l *dayLayout) path(date time.Time) string {
+	r := strings.NewReplacer("YYYY", date.Format("2006"), "MM", date.Format("01"), "DD", date.Format("02"))
+	return filepath.FromSlash(r.Replace(l.pattern)) + ".md"
+}
+
+// date reads the date back from a log's path relative to the journal. A
+// token used twice must hold the same value both times.
+func (l *dayLayout) date(rel string) (time.Time, bool) {
+	rel = strings.TrimSuffix(filepath.ToSlash(rel), ".md")
+	m := l.match.FindStringSubmatch(rel)
+	if m == nil {
+		return time.Time{}, false
+	}
+	values := make(map[string]int)
+	for i, token := range l.tokens {
+		n, _ := strconv.Atoi(m[i+1])
+		if prev, ok := values[token]; ok && prev != n {
+			return time.Time{}, false
+		}
+		values[token] = n
+	}
+	date := time.Date(values["YYYY"], time.Month(values["MM"]), values["DD"], 0, 0, 0, 0, time.UTC)
+	// time.Date normalises dates like February 30th.
+	if date.Month() != time.Month(values["MM"]) || date.Day() != values["DD"] {
+		return time.Time{}, false
+	}
+	return date, true
+}
+
+// DayFromPath returns the day of the daily log at path. Logs named
+// YYYY-MM-DD.md count whatever the layout.
+func DayFromPath(root, path string) (time.Time, bool) {
+	if rel, err := filepath.Rel(root, path); err == nil {
+		if date, ok := layout.date(rel); ok {
+			return date, true
+		}
+	}
+	date, err := time.Parse(time.DateOnly, strings.TrimSuffix(filepath.Base(path), ".md"))
+	return date, err == nil
+}
+
+// Move is a daily log moved by Relayout.
+type Move struct {
+	From, To string
+}
+
+// Relayout moves the daily logs at root to where the layout puts them. from
+// is the earlier layout as "dir_pattern/file_pattern", if known.
+func Relayout(root, from string) (moved, skipped []Move, err error) {
+	day := func(path string) (time.Time, bool) { return DayFromPath(root, path) }
+	if from != "" {
+		old, err := newLayout(filepath.Dir(from), filepath.Base(from))
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid layout %q: %w", from, err)
+		}
+		day = func(path string) (time.Time, bool) {
+			if rel, err := filepath.Rel(root, path); err == nil {
+				if date, ok := old.date(rel); ok {
+					return date, true
+				}
+			}
+			return DayFromPath(root, path)
+		}
+	}
+
+	var moves []Move
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if strings.HasPrefix(d.Name(), ".") && path != root {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) != ".md" {
+			return nil
+		}
+		if date, ok := day(path); ok {
+			if to := filepath.Join(root, layout.path(date)); to != path {
+				moves = append(moves, Move{From: path, To: to})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, m := range moves {
+		if _, err := os.Stat(m.To); err == nil {
+			skipped = append(skipped, m)
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(m.To), 0755); err != nil {
+			return moved, skipped, err
+		}
+		if err := os.Rename(m.From, m.To); err != nil {
+			return moved, skipped, err
+		}
+		moved = append(moved, m)
+		// Drop the directories the move emptied; Remove fails on the rest.
+		for dir := filepath.Dir(m.From); dir != root; dir = filepath.Dir(dir) {
+			if os.Remove(dir) != nil {
+				break
+			}
+		}
+	}
+	return moved, skipped, nil
+}
@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func useLayout(t *testing.T, dir, file string) {
+	t.Helper()
+	if err := ConfigureLayout(dir, file); err != nil {
+		t.Fatalf("ConfigureLayout(%q, %q) error: %v", dir, file, err)
+	}
+	t.Cleanup(func() { _ = ConfigureLayout("YYYY/MM", "YYYY-MM-DD") })
+}
+
+func TestConfigureLayout(t *testing.T) {
+	fs := &FSStore{Root: "/journal"}
+	date := time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		dir, file string
+		want      string
+	}{
+		{"YYYY/MM", "YYYY-MM-DD", "/journal/2024/03/2024-03-09.md"},
+		{".", "DD.MM.YYYY", "/journal/09.03.2024.md"},
+		{"logs/YYYY", "MM-DD", "/journal/logs/2024/03-09.md"},
+	}
+	for _, tt := range tests {
+		useLayout(t, tt.dir, tt.file)
+		path := fs.GetDayPath("2024-03-09")
+		if path != filepath.FromSlash(tt.want) {
+			t.Errorf("%s/%s: GetDayPath() = %q, want %q", tt.dir, tt.file, path, tt.want)
+		}
+		if got, ok := DayFromPath(fs.Root, path); !ok || !got.Equal(date) {
+			t.Errorf("%s/%s: DayFromPath(%q) = %v, %v, want %v", tt.dir, tt.file, path, got, ok, date)
+		}
+	}
+
+	for _, bad := range [][2]string{{"YYYY", "MM"}, {"../YYYY", "MM-DD"}, {".", "YYYY/MM-DD"}, {".", ""}} {
+		if err := ConfigureLayout(bad[0], bad[1]); err == nil {
+			t.Errorf("ConfigureLayout(%q, %q): want error", bad[0], bad[1])
+		}
+	}
+}
+
+func TestDayFromPath(t *testing.T) {
+	useLayout(t, "YYYY/MM", "DD")
+
+	tests := []struct {
+		path string
+		want string
+		ok   bool
+	}{
+		{"/journal/2024/03/09.md", "2024-03-09", true},
+		// Logs written before the layout changed still count.
+		{"/journal/2024/03/2024-03-09.md", "2024-03-09", true},
+		{"/journal/2024/02/30.md", "", false},
+		{"/journal/collections/someday.md", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := DayFromPath("/journal", filepath.FromSlash(tt.path))
+		if ok != tt.ok || (ok && got.Format(time.DateOnly) != tt.want) {
+			t.Errorf("DayFromPath(%q) = %v, %v, want %q, %v", tt.path, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestDayFromPathRepeatedToken(t *testing.T) {
+	// The default layout has the year and month twice; they must agree.
+	if _, ok := DayFromPath("/journal", filepath.FromSlash("/journal/2024/04/2024-03-09.md")); !ok {
+		t.Error("DayFromPath() of a misfiled log should still fall back to its name")
+	}
+	if _, ok := layout.date("2024/04/2024-03-09.md"); ok {
+		t.Error("layout.date() accepted a path whose months disagree")
+	}
+}
+
+func TestRelayout(t *testing.T) {
+	root := t.TempDir()
+	write := func(rel, content string) {
+		t.Helper()
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("2024/03/2024-03-09.md", "- [ ] default layout\n")
+	write("logs/10.03.2024.md", "- [ ] custom layout\n")
+	write("2024/03/2024-03-11.md", "- [ ] taken\n")
+	write("2024-03-11.md", "- [ ] already moved\n")
+	write("collections/someday.md", "- [ ] not a log\n")
+
+	useLayout(t, ".", "YYYY-MM-DD")
+	moved, skipped, err := Relayout(root, "logs/DD.MM.YYYY")
+	if err != nil {
+		t.Fatalf("Relayout() error: %v", err)
+	}
+	if len(moved) != 2 || len(skipped) != 1 {
+		t.Fatalf("Relayout() moved %v, skipped %v, want 2 moved and 1 skipped", moved, skipped)
+	}
+	for _, rel := range []string{"2024-03-09.md", "2024-03-10.md", "2024-03-11.md", "2024/03/2024-03-11.md", "collections/someday.md"} {
+		if _, err := os.Stat(filepath.Join(root, rel)); err != nil {
+			t.Errorf("%s: %v", rel, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(root, "logs")); !os.IsNotExist(err) {
+		t.Errorf("emptied directory logs was left behind: %v", err)
+	}
+}
@@ -0,0 +1,287 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const schemaVersionKey = "schema_version"
+
+// migration upgrades the index by one version. Indexes from before versioning
+// run every migration, so each must cope with finding its change already
+// made.
+type migration struct {
+	name string
+	// reindex forgets sync times so every Markdown file is parsed again, for
+	// changes that add data existing rows lack.
+	reindex bool
+	up      func(tx *sql.Tx) error
+}
+
+// migrations are applied in order; migrations[i] takes the index from
+// version i to i+1. Only ever append.
+var migrations = []migration{
+	{name: "create tables", up: createTables},
+	{name: "add event times, due dates and priorities", reindex: true, up: addEntryColumns},
+	{name: "drop the status check", up: dropStatusCheck},
+	{name: "index entries by file", up: indexEntriesByFile},
+	{name: "add entry dates and completion times", reindex: true, up: addEntryDates},
+	{name: "add entry tags", reindex: true, up: addEntryTags},
+}
+
+// SchemaVersion is the index version this build writes.
+var SchemaVersion = len(migrations)
+
+// migrate brings the index up to SchemaVersion. An index written by a newer
+// version can't be trusted, so its entries are dropped and rebuilt from the
+// Markdown files; review history is kept.
+func (s *DBStore) migrate() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+CREATE TABLE IF NOT EXISTS app_state (
+    key TEXT PRIMARY KEY,
+    value TEXT NOT NULL
+);`)
+	if err != nil {
+		return err
+	}
+
+	version, err := schemaVersion(tx)
+	if err != nil {
+		return err
+	}
+	if version > SchemaVersion {
+		if err := dropIndex(tx); err != nil {
+			return err
+		}
+		version = 0
+	}
+
+	for i := version; i < SchemaVersion; i++ {
+		m := migrations[i]
+		if err := m.up(tx); err != nil {
+			return fmt.Errorf("migrate index to version %d (%s): %w", i+1, m.name, err)
+		}
+		if m.reindex {
+			if _, err := tx.Exec(`DELETE FROM files`); err != nil {
+				return err
+			}
+		}
+	}
+	if version != SchemaVersion {
+		_, err = tx.Exec(`INSERT OR REPLACE INTO app_state (key, value) VALUES (?, ?)`, schemaVersionKey, strconv.Itoa(SchemaVersion))
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// schemaVersion reads the stored version; 0 means before versioning.
+func schemaVersion(tx *sql.Tx) (int, error) {
+	var value string
+	err := tx.QueryRow(`SELECT value FROM app_state WHERE key = ?`, schemaVersionKey).Scan(&value)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	version, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid index schema version %q", value)
+	}
+	return version, nil
+}
+
+// Reset empties the index so it can be rebuilt from the Markdown files.
+// Review history is kept.
+func (s *DBStore) Reset() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := dropIndex(tx); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM app_state WHERE key = ?`, schemaVersionKey); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	return s.migrate()
+}
+
+// dropIndex removes everything derived from the Markdown files.
+func dropIndex(tx *sql.Tx) error {
+	for _, stmt := range []string{`DROP TABLE IF EXISTS entries`, `DROP TABLE IF EXISTS files`} {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func createTables(tx *sql.Tx) error {
+	_, err := tx.Exec(fmt.Sprintf(entriesTable, "entries"))
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+CREATE TABLE IF NOT EXISTS files (
+    path TEXT PRIMARY KEY,
+    last_synced_at DATETIME,
+    hash TEXT
+);`)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`CREATE INDEX IF NOT EXISTS idx_status_date ON entries(status, file_path);`)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("CREATE INDEX IF NOT EXISTS idx_parent ON entries(parent_id);")
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+CREATE TABLE IF NOT EXISTS review_sessions (
+    id TEXT PRIMARY KEY,
+    started_at DATETIME NOT NULL,
+    finished_at DATETIME,
+    scope TEXT NOT NULL,
+    filter TEXT
+);`)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+CREATE TABLE IF NOT EXISTS review_decisions (
+    session_id TEXT NOT NULL REFERENCES review_sessions(id),
+    entry_id TEXT NOT NULL,
+    content TEXT NOT NULL,
+    decision TEXT NOT NULL,
+    target TEXT,
+    decided_at DATETIME NOT NULL
+);`)
+	return err
+}
+
+func addEntryColumns(tx *sql.Tx) error {
+	for _, c := range []struct{ name, definition string }{
+		{"start_time", "TEXT NOT NULL DEFAULT ''"},
+		{"end_time", "TEXT NOT NULL DEFAULT ''"},
+		{"due_date", "TEXT NOT NULL DEFAULT ''"},
+		{"priority", "INTEGER NOT NULL DEFAULT 0"},
+	} {
+		if err := addColumn(tx, "entries", c.name, c.definition); err != nil {
+			return err
+		}
+	}
+	_, err := tx.Exec("CREATE INDEX IF NOT EXISTS idx_due ON entries(due_date) WHERE due_date != '';")
+	return err
+}
+
+func indexEntriesByFile(tx *sql.Tx) error {
+	_, err := tx.Exec("CREATE INDEX IF NOT EXISTS idx_file ON entries(file_path, line_number);")
+	return err
+}
+
+// addEntryDates separates the day an entry's log is on from when the entry
+// was created, which created_at used to stand in for.
+func addEntryDates(tx *sql.Tx) error {
+	if err := addColumn(tx, "entries", "entry_date", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := addColumn(tx, "entries", "completed_at", "DATETIME"); err != nil {
+		return err
+	}
+	_, err := tx.Exec("CREATE INDEX IF NOT EXISTS idx_entry_date ON entries(entry_date);")
+	return err
+}
+
+// addEntryTags stores parsed tags so filters don't have to find them in
+// content.
+func addEntryTags(tx *sql.Tx) error {
+	return addColumn(tx, "entries", "tags", "TEXT NOT NULL DEFAULT ''")
+}
+
+// entriesTable creates the entries table under the given name, in its
+// current shape. Status isn't checked: statuses are configurable.
+const entriesTable = `
+CREATE TABLE IF NOT EXISTS %s (
+    id TEXT PRIMARY KEY,
+    type TEXT NOT NULL DEFAULT 'task' CHECK(type IN ('task', 'event', 'note')),
+    status TEXT NOT NULL DEFAULT 'open',
+    content TEXT NOT NULL,
+    raw_content TEXT NOT NULL,
+    file_path TEXT NOT NULL,
+    line_number INTEGER NOT NULL,
+    migration_count INTEGER DEFAULT 0,
+    reschedule_count INTEGER DEFAULT 0,
+    parent_id TEXT,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME,
+    is_deleted BOOLEAN DEFAULT 0,
+    start_time TEXT NOT NULL DEFAULT '',
+    end_time TEXT NOT NULL DEFAULT '',
+    due_date TEXT NOT NULL DEFAULT '',
+    priority INTEGER NOT NULL DEFAULT 0,
+    entry_date TEXT NOT NULL DEFAULT '',
+    completed_at DATETIME,
+    tags TEXT NOT NULL DEFAULT ''
+);`
+
+// dropStatusCheck rebuilds an entries table from older versions, which only
+// accepted the built-in statuses. SQLite can't drop a CHECK constraint, so
+// the rows are copied into a new table.
+func dropStatusCheck(tx *sql.Tx) error {
+	var ddl string
+	if err := tx.QueryRow(`SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'entries'`).Scan(&ddl); err != nil {
+		return err
+	}
+	if !strings.Contains(ddl, "CHECK(status IN") {
+		return nil
+	}
+	// The columns as of this migration; later ones are added after it.
+	const columns = `id, type, status, content, raw_content, file_path, line_number,
+        migration_count, reschedule_count, parent_id, created_at, updated_at,
+        start_time, end_time, due_date, priority, is_deleted`
+	for _, stmt := range []string{
+		fmt.Sprintf(entriesTable, "entries_new"),
+		`INSERT INTO entries_new (` + columns + `) SELECT ` + columns + ` FROM entries`,
+		`DROP TABLE entries`,
+		`ALTER TABLE entries_new RENAME TO entries`,
+		`CREATE INDEX IF NOT EXISTS idx_status_date ON entries(status, file_path);`,
+		`CREATE INDEX IF NOT EXISTS idx_parent ON entries(parent_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_due ON entries(due_date) WHERE due_date != '';`,
+	} {
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("rebuild entries table: %w", err)
+		}
+	}
+	return nil
+}
+
+// addColumn adds a column that indexes created by older versions lack.
+func addColumn(tx *sql.Tx, table, column, definition string) error {
+	var exists bool
+	err := tx.QueryRow(`SELECT COUNT(*) > 0 FROM pragma_table_info(?) WHERE name = ?`, table, column).Scan(&exists)
+	if err != nil || exists {
+		return err
+	}
+	_, err = tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	return err
+}
@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/samakintunde/bujo/internal/models"
+)
+
+func TestMigrateRecordsVersion(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewDBStore(dir)
+	if err != nil {
+		t.Fatalf("NewDBStore() error: %v", err)
+	}
+	defer store.Close()
+
+	var value string
+	if err := store.db.QueryRow(`SELECT value FROM app_state WHERE key = ?`, schemaVersionKey).Scan(&value); err != nil {
+		t.Fatalf("reading schema version: %v", err)
+	}
+	if value != fmt.Sprint(SchemaVersion) {
+		t.Errorf("schema_version = %s, want %d", value, SchemaVersion)
+	}
+}
+
+func TestAddsMissingColumns(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewDBStore(dir)
+	if err != nil {
+		t.Fatalf("NewDBStore() error: %v", err)
+	}
+	// Simulate an unversioned index from before event times.
+	for _, stmt := range []string{
+		`DELETE FROM app_state`,
+		`ALTER TABLE entries DROP COLUMN start_time`,
+		`ALTER TABLE entries DROP COLUMN end_time`,
+		`ALTER TABLE entries DROP COLUMN priority`,
+		`DROP INDEX idx_entry_date`,
+		`ALTER TABLE entries DROP COLUMN entry_date`,
+		`ALTER TABLE entries DROP COLUMN completed_at`,
+		`INSERT INTO files (path, last_synced_at) VALUES ('/test/2024-01-01.md', '2024-01-01')`,
+	} {
+		if _, err := store.db.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+	store.Close()
+
+	store, err = NewDBStore(dir)
+	if err != nil {
+		t.Fatalf("reopening old index: %v", err)
+	}
+	defer store.Close()
+
+	last, err := store.GetFileLastSync("/test/2024-01-01.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !last.IsZero() {
+		t.Errorf("sync time kept after adding columns; files would not be re-indexed")
+	}
+	if err := store.SyncEntries("/test/2024-01-01.md", []models.Entry{{ID: "e", Type: models.EntryTypeEvent, Status: models.EntryStatusOpen, StartTime: "09:00"}}); err != nil {
+		t.Errorf("SyncEntries() on upgraded index: %v", err)
+	}
+}
+
+func TestDropsStatusCheck(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewDBStore(dir)
+	if err != nil {
+		t.Fatalf("NewDBStore() error: %v", err)
+	}
+	// Simulate an unversioned index from before statuses were configurable.
+	oldTable := strings.Replace(entriesTable, "status TEXT NOT NULL DEFAULT 'open',",
+		"status TEXT NOT NULL DEFAULT 'open' CHECK(status IN ('open', 'completed', 'migrated', 'scheduled', 'cancelled')),", 1)
+	for _, stmt := range []string{
+		`DELETE FROM app_state`,
+		`DROP TABLE entries`,
+		fmt.Sprintf(oldTable, "entries"),
+		`ALTER TABLE entries DROP COLUMN entry_date`,
+		`ALTER TABLE entries DROP COLUMN completed_at`,
+		`INSERT INTO entries (id, status, content, raw_content, file_path, line_number, parent_id, created_at, updated_at)
+			VALUES ('kept', 'open', 'Old task', '- [ ] Old task', '/test/old.md', 1, '', '2024-01-01', '2024-01-01')`,
+	} {
+		if _, err := store.db.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+	store.Close()
+
+	store, err = NewDBStore(dir)
+	if err != nil {
+		t.Fatalf("reopening old index: %v", err)
+	}
+	defer store.Close()
+
+	if _, err := store.GetEntryByID("kept"); err != nil {
+		t.Errorf("existing entry lost in rebuild: %v", err)
+	}
+	waiting := []models.Entry{{ID: "w", Type: models.EntryTypeTask, Status: "waiting", FilePath: "/test/new.md", LineNumber: 1}}
+	if err := store.SyncEntries("/test/new.md", waiting); err != nil {
+		t.Errorf("SyncEntries() with a custom status: %v", err)
+	}
+}
+
+func TestMigrateRebuildsIndexFromNewerVersion(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewDBStore(dir)
+	if err != nil {
+		t.Fatalf("NewDBStore() error: %v", err)
+	}
+	path := "/test/2024-01-01.md"
+	if err := store.SyncEntries(path, []models.Entry{{ID: "t1", Type: models.EntryTypeTask, Status: models.EntryStatusOpen, FilePath: path, LineNumber: 1}}); err != nil {
+		t.Fatal(err)
+	}
+	session := models.ReviewSession{ID: "r1", StartedAt: time.Now(), FinishedAt: time.Now(), Scope: "All stale"}
+	if err := store.SaveReviewSession(session); err != nil {
+		t.Fatal(err)
+	}
+	// Simulate an index written by a later version with an unknown layout.
+	for _, stmt := range []string{
+		fmt.Sprintf(`UPDATE app_state SET value = '%d' WHERE key = '%s'`, SchemaVersion+1, schemaVersionKey),
+		`ALTER TABLE entries DROP COLUMN priority`,
+	} {
+		if _, err := store.db.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+	store.Close()
+
+	store, err = NewDBStore(dir)
+	if err != nil {
+		t.Fatalf("reopening newer index: %v", err)
+	}
+	defer store.Close()
+
+	if last, _ := store.GetFileLastSync(path); !last.IsZero() {
+		t.Errorf("sync time kept; files would not be re-indexed")
+	}
+	if entries, _ := store.GetEntriesByFile(path); len(entries) != 0 {
+		t.Errorf("entries from the newer index kept: %v", entries)
+	}
+	if err := store.SyncEntries(path, []models.Entry{{ID: "t1", Type: models.EntryTypeTask, Status: models.EntryStatusOpen, FilePath: path, LineNumber: 1, Priority: 2}}); err != nil {
+		t.Errorf("SyncEntries() on rebuilt index: %v", err)
+	}
+	sessions, err := store.GetReviewSessions(10)
+	if err != nil || len(sessions) != 1 {
+		t.Errorf("review history lost: %v, %v", sessions, err)
+	}
+}
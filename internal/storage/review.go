@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"database/sql"
+
+	"github.com/samakintunde/bujo/internal/models"
+)
+
+// SaveReviewSession stores a finished review and its decisions. Unlike
+// entries, review history isn't derived from Markdown and survives reindexing.
+func (s *DBStore) SaveReviewSession(session models.ReviewSession) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`INSERT OR REPLACE INTO review_sessions (id, started_at, finished_at, scope, filter)
+		VALUES (?, ?, ?, ?, ?)`,
+		session.ID, session.StartedAt, session.FinishedAt, session.Scope, session.Filter)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM review_decisions WHERE session_id = ?`, session.ID); err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare(`INSERT INTO review_decisions (session_id, entry_id, content, decision, target, decided_at)
+		VALUES (?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, d := range session.Decisions {
+		if _, err := stmt.Exec(session.ID, d.EntryID, d.Content, d.Decision, d.Target, d.DecidedAt); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetReviewSessions returns the most recent review sessions, newest first.
+func (s *DBStore) GetReviewSessions(limit int) ([]models.ReviewSession, error) {
+	rows, err := s.db.Query(`SELECT id, started_at, finished_at, scope, filter
+		FROM review_sessions ORDER BY started_at DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []models.ReviewSession
+	for rows.Next() {
+		var session models.ReviewSession
+		var finishedAt sql.NullTime
+		var filter sql.NullString
+		if err := rows.Scan(&session.ID, &session.StartedAt, &finishedAt, &session.Scope, &filter); err != nil {
+			return nil, err
+		}
+		session.FinishedAt = finishedAt.Time
+		session.Filter = filter.String
+		sessions = append(sessions, session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range sessions {
+		decisions, err := s.getReviewDecisions(sessions[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		sessions[i].Decisions = decisions
+	}
+	return sessions, nil
+}
+
+func (s *DBStore) getReviewDecisions(sessionID string) ([]models.ReviewDecision, error) {
+	rows, err := s.db.Query(`SELECT entry_id, content, decision, target, decided_at
+		FROM review_decisions WHERE session_id = ? ORDER BY decided_at ASC`, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var decisions []models.ReviewDecision
+	for rows.Next() {
+		var d models.ReviewDecision
+		var target sql.NullString
+		if err := rows.Scan(&d.EntryID, &d.Content, &d.Decision, &target, &d.DecidedAt); err != nil {
+			return nil, err
+		}
+		d.Target = target.String
+		decisions = append(decisions, d)
+	}
+	return decisions, rows.Err()
+}
@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/samakintunde/bujo/internal/models"
+)
+
+func TestSaveAndGetReviewSessions(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewDBStore(dir)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	defer store.Close()
+
+	older := models.ReviewSession{
+		ID:         "r1",
+		StartedAt:  time.Now().AddDate(0, 0, -7),
+		FinishedAt: time.Now().AddDate(0, 0, -7),
+		Scope:      "Last week",
+	}
+	newer := models.ReviewSession{
+		ID:         "r2",
+		StartedAt:  time.Now(),
+		FinishedAt: time.Now(),
+		Scope:      "All stale",
+		Filter:     "#work",
+		Decisions: []models.ReviewDecision{
+			{EntryID: "a", Content: "Ship", Decision: models.ReviewDecisionCompleted, DecidedAt: time.Now()},
+			{EntryID: "b", Content: "Plan", Decision: models.ReviewDecisionScheduled, Target: "2024-03-10", DecidedAt: time.Now()},
+		},
+	}
+
+	for _, session := range []models.ReviewSession{older, newer} {
+		if err := store.SaveReviewSession(session); err != nil {
+			t.Fatalf("SaveReviewSession() error: %v", err)
+		}
+	}
+
+	sessions, err := store.GetReviewSessions(10)
+	if err != nil {
+		t.Fatalf("GetReviewSessions() error: %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("GetReviewSessions() returned %d sessions, want 2", len(sessions))
+	}
+	if sessions[0].ID != "r2" {
+		t.Errorf("sessions[0].ID = %s, want r2 (newest first)", sessions[0].ID)
+	}
+	if sessions[0].Filter != "#work" {
+		t.Errorf("sessions[0].Filter = %q, want #work", sessions[0].Filter)
+	}
+	if len(sessions[0].Decisions) != 2 || sessions[0].Decisions[1].Target != "2024-03-10" {
+		t.Errorf("sessions[0].Decisions = %+v, want 2 decisions with schedule target", sessions[0].Decisions)
+	}
+	if len(sessions[1].Decisions) != 0 {
+		t.Errorf("sessions[1].Decisions = %+v, want none", sessions[1].Decisions)
+	}
+}
@@ -2,10 +2,13 @@ package storage
 
 import (
 	"database/sql"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/samakintunde/bujo/internal/clock"
 	"github.com/samakintunde/bujo/internal/models"
 	_ "modernc.org/sqlite"
 )
@@ -16,6 +19,15 @@ type DBStore struct {
 
 const DB_FILEPATH = "db.sqlite"
 
+// notInCollection excludes entries filed in a collection, which aren't tied
+// to a day and so never go stale.
+const notInCollection = "instr(file_path, ?) = 0"
+
+var collectionSegment = string(filepath.Separator) + CollectionsDir + string(filepath.Separator)
+
+// likeEscaper makes text match itself in a LIKE pattern with ESCAPE '\'.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
 func NewDBStore(basePath string) (*DBStore, error) {
 	if err := os.MkdirAll(basePath, 0755); err != nil {
 		return nil, err
@@ -37,7 +49,7 @@ func NewDBStore(basePath string) (*DBStore, error) {
 	if err != nil {
 		return nil, err
 	}
-	err = store.createSchema()
+	err = store.migrate()
 	if err != nil {
 		return nil, err
 	}
@@ -54,65 +66,55 @@ func (s *DBStore) Close() error {
 	return s.db.Close()
 }
 
-func (s *DBStore) createSchema() error {
-	tx, err := s.db.Begin()
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
+// entryColumns are the entries columns in the order scanEntry reads them.
+const entryColumns = `id, type, status, content, raw_content, file_path, line_number,
+        migration_count, reschedule_count, parent_id, created_at, updated_at,
+        start_time, end_time, due_date, priority, entry_date, completed_at, tags`
 
-	_, err = tx.Exec(`
-CREATE TABLE IF NOT EXISTS entries (
-    id TEXT PRIMARY KEY,
-    type TEXT NOT NULL DEFAULT 'task' CHECK(type IN ('task', 'event', 'note')),
-    status TEXT NOT NULL DEFAULT 'open' CHECK(status IN ('open', 'completed', 'migrated', 'scheduled', 'cancelled')),
-    content TEXT NOT NULL,
-    raw_content TEXT NOT NULL,
-    file_path TEXT NOT NULL,
-    line_number INTEGER NOT NULL,
-    migration_count INTEGER DEFAULT 0,
-    reschedule_count INTEGER DEFAULT 0,
-    parent_id TEXT,
-    created_at DATETIME NOT NULL,
-    updated_at DATETIME,
-    is_deleted BOOLEAN DEFAULT 0
-);`)
-	if err != nil {
-		return err
-	}
-	_, err = tx.Exec(`
-CREATE TABLE IF NOT EXISTS files (
-    path TEXT PRIMARY KEY,
-    last_synced_at DATETIME,
-    hash TEXT
-);`)
-	if err != nil {
-		return err
-	}
-	_, err = tx.Exec(`CREATE INDEX IF NOT EXISTS idx_status_date ON entries(status, file_path);`)
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanEntry(row rowScanner) (models.Entry, error) {
+	var e models.Entry
+	var date, tags string
+	var completedAt sql.NullTime
+	err := row.Scan(
+		&e.ID, &e.Type, &e.Status, &e.Content, &e.RawContent, &e.FilePath, &e.LineNumber,
+		&e.MigrationCount, &e.RescheduleCount, &e.ParentID, &e.CreatedAt, &e.UpdatedAt,
+		&e.StartTime, &e.EndTime, &e.DueDate, &e.Priority, &date, &completedAt, &tags,
+	)
 	if err != nil {
-		return err
+		return e, err
 	}
-	_, err = tx.Exec("CREATE INDEX IF NOT EXISTS idx_parent ON entries(parent_id);")
-	if err != nil {
-		return err
+	if date != "" {
+		e.Date, _ = time.Parse(time.DateOnly, date)
 	}
+	e.CompletedAt = completedAt.Time
+	e.Tags = strings.Fields(tags)
+	return e, nil
+}
 
-	_, err = tx.Exec(`
-CREATE TABLE IF NOT EXISTS app_state (
-    key TEXT PRIMARY KEY,
-    value TEXT NOT NULL
-);`)
-	if err != nil {
-		return err
+// entryTags is how an entry's tags are stored: space-separated with a space
+// at each end, so that a tag matches as LIKE '% tag %'.
+func entryTags(e models.Entry) string {
+	if len(e.Tags) == 0 {
+		return ""
 	}
+	return " " + strings.Join(e.Tags, " ") + " "
+}
 
-	err = tx.Commit()
-	if err != nil {
-		return err
+// entryDate is how an entry's log day is stored.
+func entryDate(e models.Entry) string {
+	if e.Date.IsZero() {
+		return ""
 	}
+	return e.Date.Format(time.DateOnly)
+}
 
-	return nil
+// nullTime stores a zero time as NULL.
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
 }
 
 func (s *DBStore) GetFileLastSync(path string) (time.Time, error) {
@@ -127,51 +129,186 @@ func (s *DBStore) GetFileLastSync(path string) (time.Time, error) {
 	return lastSyncedAt.Time, nil
 }
 
+// GetFileHash returns the content hash a file was last synced with, or ""
+// if it hasn't been synced with one.
+func (s *DBStore) GetFileHash(path string) (string, error) {
+	var hash sql.NullString
+	err := s.db.QueryRow("SELECT hash FROM files WHERE path = ?", path).Scan(&hash)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		return "", err
+	}
+	return hash.String, nil
+}
+
+// GetFileSyncTimes returns when each indexed file was last synced.
+func (s *DBStore) GetFileSyncTimes() (map[string]time.Time, error) {
+	rows, err := s.db.Query("SELECT path, last_synced_at FROM files")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	times := make(map[string]time.Time)
+	for rows.Next() {
+		var path string
+		var lastSyncedAt sql.NullTime
+		if err := rows.Scan(&path, &lastSyncedAt); err != nil {
+			return nil, err
+		}
+		times[path] = lastSyncedAt.Time
+	}
+	return times, rows.Err()
+}
+
+// FileEntries are the entries parsed from one file. Hash identifies the
+// content they were parsed from, if known.
+type FileEntries struct {
+	Path    string
+	Entries []models.Entry
+	Hash    string
+}
+
 func (s *DBStore) SyncEntries(path string, entries []models.Entry) error {
+	return s.SyncFiles([]FileEntries{{Path: path, Entries: entries}})
+}
+
+// SyncFiles replaces the indexed entries of several files in a single
+// transaction.
+func (s *DBStore) SyncFiles(files []FileEntries) error {
 	tx, err := s.db.Begin()
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
-	if _, err := tx.Exec("DELETE FROM entries WHERE file_path = ?", path); err != nil {
+	stmt, err := tx.Prepare(`INSERT INTO entries (` + entryColumns + `)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
 		return err
 	}
+	defer stmt.Close()
 
-	stmt, err := tx.Prepare(`INSERT INTO entries (
-        id, type, status, content, raw_content, file_path, line_number,
-        migration_count, reschedule_count, parent_id, created_at, updated_at
-    ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	now := time.Now()
+	for _, f := range files {
+		if _, err := tx.Exec("DELETE FROM entries WHERE file_path = ?", f.Path); err != nil {
+			return err
+		}
+
+		for _, e := range f.Entries {
+			if e.Type == models.EntryTypeIgnore {
+				continue
+			}
+			_, err = stmt.Exec(
+				e.ID, e.Type, e.Status, e.Content, e.RawContent, e.FilePath, e.LineNumber,
+				e.MigrationCount, e.RescheduleCount, e.ParentID, e.CreatedAt, e.UpdatedAt,
+				e.StartTime, e.EndTime, e.DueDate, e.Priority, entryDate(e), nullTime(e.CompletedAt), entryTags(e),
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		if _, err := tx.Exec(`INSERT OR REPLACE INTO files (path, last_synced_at, hash) VALUES (?, ?, ?)`, f.Path, now, f.Hash); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// RemoveFile drops a deleted file and its entries from the index.
+func (s *DBStore) RemoveFile(path string) error {
+	tx, err := s.db.Begin()
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
+	defer tx.Rollback()
 
-	for _, e := range entries {
-		if e.Type == models.EntryTypeIgnore {
-			continue
+	if _, err := tx.Exec("DELETE FROM entries WHERE file_path = ?", path); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM files WHERE path = ?", path); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetEntriesByIDs returns the indexed entries with the given IDs, keyed by
+// ID. IDs that aren't indexed are left out.
+func (s *DBStore) GetEntriesByIDs(ids []string) (map[string]models.Entry, error) {
+	entries := make(map[string]models.Entry)
+	const chunk = 500
+	for start := 0; start < len(ids); start += chunk {
+		batch := ids[start:min(start+chunk, len(ids))]
+		args := make([]any, len(batch))
+		for i, id := range batch {
+			args[i] = id
 		}
-		_, err = stmt.Exec(
-			e.ID, e.Type, e.Status, e.Content, e.RawContent, e.FilePath, e.LineNumber,
-			e.MigrationCount, e.RescheduleCount, e.ParentID, e.CreatedAt, e.UpdatedAt,
-		)
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(batch)), ", ")
+		rows, err := s.db.Query(`SELECT `+entryColumns+` FROM entries WHERE id IN (`+placeholders+`)`, args...)
 		if err != nil {
-			return err
+			return nil, err
+		}
+		for rows.Next() {
+			e, err := scanEntry(rows)
+			if err != nil {
+				rows.Close()
+				return nil, err
+			}
+			entries[e.ID] = e
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
 		}
 	}
+	return entries, nil
+}
 
-	if _, err := tx.Exec(`INSERT OR REPLACE INTO files (path, last_synced_at) VALUES (?, ?)`, path, time.Now()); err != nil {
+// RemoveEntries drops entries from the index, e.g. rows left behind by a
+// line that moved to another file.
+func (s *DBStore) RemoveEntries(ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
+	for _, id := range ids {
+		if _, err := tx.Exec("DELETE FROM entries WHERE id = ?", id); err != nil {
+			return err
+		}
+	}
 	return tx.Commit()
 }
 
+// GetIndexedPaths returns every file the index knows about.
+func (s *DBStore) GetIndexedPaths() ([]string, error) {
+	rows, err := s.db.Query(`SELECT path FROM files UNION SELECT file_path FROM entries ORDER BY 1`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var paths []string
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, rows.Err()
+}
+
 func (s *DBStore) GetEntriesByFile(path string) ([]models.Entry, error) {
-	query := `
-        SELECT id, type, status, content, raw_content, file_path, line_number,
-               migration_count, reschedule_count, parent_id, created_at, updated_at
-        FROM entries
+	query := `SELECT ` + entryColumns + ` FROM entries
         WHERE file_path = ?
         ORDER BY line_number ASC`
 
@@ -183,11 +320,7 @@ func (s *DBStore) GetEntriesByFile(path string) ([]models.Entry, error) {
 
 	var entries []models.Entry
 	for rows.Next() {
-		var e models.Entry
-		err := rows.Scan(
-			&e.ID, &e.Type, &e.Status, &e.Content, &e.RawContent, &e.FilePath, &e.LineNumber,
-			&e.MigrationCount, &e.RescheduleCount, &e.ParentID, &e.CreatedAt, &e.UpdatedAt,
-		)
+		e, err := scanEntry(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -196,73 +329,127 @@ func (s *DBStore) GetEntriesByFile(path string) ([]models.Entry, error) {
 	return entries, nil
 }
 
-func (s *DBStore) CountStaleTasks(daysBack int) (int, error) {
-	var query string
+// GetEntriesByDate returns the entries of every file dated day, in file and
+// line order.
+func (s *DBStore) GetEntriesByDate(day time.Time) ([]models.Entry, error) {
+	rows, err := s.db.Query(`SELECT `+entryColumns+` FROM entries
+		WHERE entry_date = ?
+		ORDER BY file_path ASC, line_number ASC`, day.Format(time.DateOnly))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []models.Entry
+	for rows.Next() {
+		e, err := scanEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// openStatus matches column against the statuses of tasks still to be done.
+func openStatus(column string) (string, []any) {
 	var args []any
+	for _, status := range models.OpenStatuses() {
+		args = append(args, string(status))
+	}
+	return column + " IN (" + strings.TrimSuffix(strings.Repeat("?, ", len(args)), ", ") + ")", args
+}
+
+// StaleKind narrows stale tasks by how they arrived on their day.
+type StaleKind string
+
+const (
+	StaleKindAny       StaleKind = ""
+	StaleKindNew       StaleKind = "new"
+	StaleKindMigrated  StaleKind = "migrated"
+	StaleKindScheduled StaleKind = "scheduled"
+)
+
+// StaleFilter selects open tasks from before today. DaysBack of 0 means all
+// stale tasks, 1 means the most recent day that has any, and N means the
+// last N days.
+type StaleFilter struct {
+	DaysBack int
+	Tags     []string
+	Kind     StaleKind
+}
+
+func (f StaleFilter) conditions() (string, []any) {
+	open, args := openStatus("status")
+	conds := []string{"type = 'task'", open, notInCollection}
+	args = append(args, collectionSegment)
+
+	for _, tag := range f.Tags {
+		conds = append(conds, `tags LIKE ? ESCAPE '\'`)
+		args = append(args, "% "+likeEscaper.Replace(strings.ToLower(strings.TrimPrefix(tag, "#")))+" %")
+	}
+
+	switch f.Kind {
+	case StaleKindNew:
+		conds = append(conds, "migration_count = 0 AND reschedule_count = 0")
+	case StaleKindMigrated:
+		conds = append(conds, "migration_count > 0")
+	case StaleKindScheduled:
+		conds = append(conds, "reschedule_count > 0")
+	}
+
+	return strings.Join(conds, " AND "), args
+}
 
-	today := time.Now().Truncate(24 * time.Hour)
-
-	if daysBack == 0 {
-		query = `SELECT COUNT(*) FROM entries 
-			WHERE type = 'task' AND status = 'open' 
-			AND created_at < ?`
-		args = []any{today}
-	} else if daysBack == 1 {
-		query = `SELECT COUNT(*) FROM entries 
-			WHERE type = 'task' AND status = 'open' 
-			AND date(created_at) = (
-				SELECT MAX(date(created_at)) FROM entries 
-				WHERE type = 'task' AND status = 'open' AND created_at < ?
-			)`
-		args = []any{today}
-	} else {
-		cutoff := today.AddDate(0, 0, -daysBack)
-		query = `SELECT COUNT(*) FROM entries 
-			WHERE type = 'task' AND status = 'open' 
-			AND created_at < ? AND created_at >= ?`
-		args = []any{today, cutoff}
+// where compares days rather than timestamps, so that "today" is the
+// journal's day whatever the timezone.
+func (f StaleFilter) where() (string, []any) {
+	today := clock.Today()
+	where, args := f.conditions()
+
+	switch {
+	case f.DaysBack == 0:
+		where += " AND entry_date < ?"
+		args = append(args, today.Format(time.DateOnly))
+	case f.DaysBack == 1:
+		subWhere, subArgs := f.conditions()
+		where += fmt.Sprintf(` AND entry_date = (
+			SELECT MAX(entry_date) FROM entries
+			WHERE %s AND entry_date < ?
+		)`, subWhere)
+		args = append(append(args, subArgs...), today.Format(time.DateOnly))
+	default:
+		cutoff := today.AddDate(0, 0, -f.DaysBack)
+		where += " AND entry_date < ? AND entry_date >= ?"
+		args = append(args, today.Format(time.DateOnly), cutoff.Format(time.DateOnly))
 	}
 
+	return where, args
+}
+
+func (s *DBStore) CountStaleTasks(daysBack int) (int, error) {
+	return s.CountStaleTasksMatching(StaleFilter{DaysBack: daysBack})
+}
+
+func (s *DBStore) CountStaleTasksMatching(filter StaleFilter) (int, error) {
+	where, args := filter.where()
+
 	var count int
-	err := s.db.QueryRow(query, args...).Scan(&count)
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM entries WHERE `+where, args...).Scan(&count)
 	return count, err
 }
 
 func (s *DBStore) GetStaleTasks(daysBack int) ([]models.Entry, error) {
-	var query string
-	var args []any
+	return s.GetStaleTasksMatching(StaleFilter{DaysBack: daysBack})
+}
 
-	today := time.Now().Truncate(24 * time.Hour)
-
-	if daysBack == 0 {
-		query = `SELECT id, type, status, content, raw_content, file_path, line_number,
-				migration_count, reschedule_count, parent_id, created_at, updated_at
-			FROM entries 
-			WHERE type = 'task' AND status = 'open' 
-			AND created_at < ?
-			ORDER BY created_at ASC`
-		args = []any{today}
-	} else if daysBack == 1 {
-		query = `SELECT id, type, status, content, raw_content, file_path, line_number,
-				migration_count, reschedule_count, parent_id, created_at, updated_at
-			FROM entries 
-			WHERE type = 'task' AND status = 'open' 
-			AND date(created_at) = (
-				SELECT MAX(date(created_at)) FROM entries 
-				WHERE type = 'task' AND status = 'open' AND created_at < ?
-			)
-			ORDER BY created_at ASC`
-		args = []any{today}
-	} else {
-		cutoff := today.AddDate(0, 0, -daysBack)
-		query = `SELECT id, type, status, content, raw_content, file_path, line_number,
-				migration_count, reschedule_count, parent_id, created_at, updated_at
-			FROM entries 
-			WHERE type = 'task' AND status = 'open' 
-			AND created_at < ? AND created_at >= ?
-			ORDER BY created_at ASC`
-		args = []any{today, cutoff}
-	}
+// GetStaleTasksMatching returns stale tasks highest priority first, so that
+// a review deals with what matters before the rest.
+func (s *DBStore) GetStaleTasksMatching(filter StaleFilter) ([]models.Entry, error) {
+	where, args := filter.where()
+	query := `SELECT ` + entryColumns + ` FROM entries
+		WHERE ` + where + `
+		ORDER BY priority DESC, entry_date ASC, line_number ASC`
 
 	rows, err := s.db.Query(query, args...)
 	if err != nil {
@@ -272,11 +459,7 @@ func (s *DBStore) GetStaleTasks(daysBack int) ([]models.Entry, error) {
 
 	var entries []models.Entry
 	for rows.Next() {
-		var e models.Entry
-		err := rows.Scan(
-			&e.ID, &e.Type, &e.Status, &e.Content, &e.RawContent, &e.FilePath, &e.LineNumber,
-			&e.MigrationCount, &e.RescheduleCount, &e.ParentID, &e.CreatedAt, &e.UpdatedAt,
-		)
+		e, err := scanEntry(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -285,6 +468,33 @@ func (s *DBStore) GetStaleTasks(daysBack int) ([]models.Entry, error) {
 	return entries, nil
 }
 
+// GetDeadlines returns open tasks due on or before until, overdue ones
+// included, earliest deadline first.
+func (s *DBStore) GetDeadlines(until time.Time) ([]models.Entry, error) {
+	open, args := openStatus("status")
+	rows, err := s.db.Query(`SELECT `+entryColumns+` FROM entries
+		WHERE type = 'task' AND `+open+` AND due_date != '' AND due_date <= ?
+		ORDER BY due_date ASC, file_path ASC, line_number ASC`, append(args, until.Format(time.DateOnly))...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []models.Entry
+	for rows.Next() {
+		e, err := scanEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+func (s *DBStore) GetEntryByID(id string) (models.Entry, error) {
+	return scanEntry(s.db.QueryRow(`SELECT `+entryColumns+` FROM entries WHERE id = ?`, id))
+}
+
 func (s *DBStore) UpdateEntryStatus(id string, status models.EntryStatus) error {
 	_, err := s.db.Exec(`UPDATE entries SET status = ?, updated_at = ? WHERE id = ?`,
 		status, time.Now(), id)
@@ -333,14 +543,7 @@ func (s *DBStore) GetMigrationChain(entryID string) ([]models.Entry, error) {
 	currentID := rootID
 
 	for currentID != "" {
-		var e models.Entry
-		err := s.db.QueryRow(`
-			SELECT id, type, status, content, raw_content, file_path, line_number,
-			       migration_count, reschedule_count, parent_id, created_at, updated_at
-			FROM entries WHERE id = ?`, currentID).Scan(
-			&e.ID, &e.Type, &e.Status, &e.Content, &e.RawContent, &e.FilePath, &e.LineNumber,
-			&e.MigrationCount, &e.RescheduleCount, &e.ParentID, &e.CreatedAt, &e.UpdatedAt,
-		)
+		e, err := scanEntry(s.db.QueryRow(`SELECT `+entryColumns+` FROM entries WHERE id = ?`, currentID))
 		if err != nil {
 			if err == sql.ErrNoRows {
 				break
@@ -3,9 +3,11 @@ package storage
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/samakintunde/bujo/internal/clock"
 	"github.com/samakintunde/bujo/internal/models"
 )
 
@@ -67,6 +69,8 @@ func TestSyncAndGetEntries(t *testing.T) {
 			RawContent: "- * Meeting",
 			FilePath:   "/test/2024-01-01.md",
 			LineNumber: 2,
+			StartTime:  "14:00",
+			EndTime:    "15:00",
 		},
 	}
 
@@ -90,6 +94,9 @@ func TestSyncAndGetEntries(t *testing.T) {
 	if got[1].ID != "id2" || got[1].Content != "Meeting" {
 		t.Errorf("got[1] = %+v, want id2/Meeting", got[1])
 	}
+	if got[1].StartTime != "14:00" || got[1].EndTime != "15:00" {
+		t.Errorf("got[1] time = %q-%q, want 14:00-15:00", got[1].StartTime, got[1].EndTime)
+	}
 }
 
 func TestSyncReplacesExisting(t *testing.T) {
@@ -207,11 +214,11 @@ func TestCountStaleTasks(t *testing.T) {
 	tenDaysAgo := time.Now().AddDate(0, 0, -10)
 
 	entries := []models.Entry{
-		{ID: "t1", Type: models.EntryTypeTask, Status: models.EntryStatusOpen, Content: "Task 1", RawContent: "- [ ] Task 1", FilePath: "/test/old.md", LineNumber: 1, CreatedAt: yesterday},
-		{ID: "t2", Type: models.EntryTypeTask, Status: models.EntryStatusOpen, Content: "Task 2", RawContent: "- [ ] Task 2", FilePath: "/test/old.md", LineNumber: 2, CreatedAt: threeDaysAgo},
-		{ID: "t3", Type: models.EntryTypeTask, Status: models.EntryStatusOpen, Content: "Task 3", RawContent: "- [ ] Task 3", FilePath: "/test/old.md", LineNumber: 3, CreatedAt: tenDaysAgo},
-		{ID: "t4", Type: models.EntryTypeTask, Status: models.EntryStatusCompleted, Content: "Done", RawContent: "- [x] Done", FilePath: "/test/old.md", LineNumber: 4, CreatedAt: yesterday},
-		{ID: "t5", Type: models.EntryTypeTask, Status: models.EntryStatusOpen, Content: "Today", RawContent: "- [ ] Today", FilePath: "/test/today.md", LineNumber: 1, CreatedAt: time.Now()},
+		{ID: "t1", Type: models.EntryTypeTask, Status: models.EntryStatusOpen, Content: "Task 1", RawContent: "- [ ] Task 1", FilePath: "/test/old.md", LineNumber: 1, Date: yesterday},
+		{ID: "t2", Type: models.EntryTypeTask, Status: models.EntryStatusOpen, Content: "Task 2", RawContent: "- [ ] Task 2", FilePath: "/test/old.md", LineNumber: 2, Date: threeDaysAgo},
+		{ID: "t3", Type: models.EntryTypeTask, Status: models.EntryStatusOpen, Content: "Task 3", RawContent: "- [ ] Task 3", FilePath: "/test/old.md", LineNumber: 3, Date: tenDaysAgo},
+		{ID: "t4", Type: models.EntryTypeTask, Status: models.EntryStatusCompleted, Content: "Done", RawContent: "- [x] Done", FilePath: "/test/old.md", LineNumber: 4, Date: yesterday},
+		{ID: "t5", Type: models.EntryTypeTask, Status: models.EntryStatusOpen, Content: "Today", RawContent: "- [ ] Today", FilePath: "/test/today.md", LineNumber: 1, Date: time.Now()},
 	}
 
 	if err := store.SyncEntries("/test/old.md", entries[:4]); err != nil {
@@ -246,6 +253,46 @@ func TestCountStaleTasks(t *testing.T) {
 	}
 }
 
+func TestStaleTasksUseJournalDay(t *testing.T) {
+	// Fourteen hours ahead of UTC, the journal's today is often UTC's
+	// tomorrow. Daily files are dated at UTC midnight.
+	loc := time.FixedZone("UTC+14", 14*60*60)
+	if err := clock.Configure(loc, 0); err != nil {
+		t.Fatalf("Configure() error: %v", err)
+	}
+	t.Cleanup(func() { _ = clock.Configure(time.Local, 0) })
+
+	store, err := NewDBStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	defer store.Close()
+
+	fileDate := func(day time.Time) time.Time {
+		return time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	today := clock.Today()
+	entries := []models.Entry{
+		{ID: "t1", Type: models.EntryTypeTask, Status: models.EntryStatusOpen, Content: "Yesterday", RawContent: "- [ ] Yesterday", FilePath: "/test/yesterday.md", LineNumber: 1, Date: fileDate(today.AddDate(0, 0, -1))},
+		{ID: "t2", Type: models.EntryTypeTask, Status: models.EntryStatusOpen, Content: "Today", RawContent: "- [ ] Today", FilePath: "/test/today.md", LineNumber: 1, Date: fileDate(today)},
+	}
+	for _, e := range entries {
+		if err := store.SyncEntries(e.FilePath, []models.Entry{e}); err != nil {
+			t.Fatalf("SyncEntries() error: %v", err)
+		}
+	}
+
+	for _, days := range []int{0, 1, 7} {
+		count, err := store.CountStaleTasks(days)
+		if err != nil {
+			t.Fatalf("CountStaleTasks(%d) error: %v", days, err)
+		}
+		if count != 1 {
+			t.Errorf("CountStaleTasks(%d) = %d, want 1 (only yesterday's task)", days, count)
+		}
+	}
+}
+
 func TestGetStaleTasks(t *testing.T) {
 	dir := t.TempDir()
 	store, err := NewDBStore(dir)
@@ -258,8 +305,8 @@ func TestGetStaleTasks(t *testing.T) {
 	threeDaysAgo := time.Now().AddDate(0, 0, -3)
 
 	entries := []models.Entry{
-		{ID: "t1", Type: models.EntryTypeTask, Status: models.EntryStatusOpen, Content: "Yesterday task", RawContent: "- [ ] Yesterday task", FilePath: "/test/old.md", LineNumber: 1, CreatedAt: yesterday},
-		{ID: "t2", Type: models.EntryTypeTask, Status: models.EntryStatusOpen, Content: "Old task", RawContent: "- [ ] Old task", FilePath: "/test/old.md", LineNumber: 2, CreatedAt: threeDaysAgo},
+		{ID: "t1", Type: models.EntryTypeTask, Status: models.EntryStatusOpen, Content: "Yesterday task", RawContent: "- [ ] Yesterday task", FilePath: "/test/old.md", LineNumber: 1, Date: yesterday},
+		{ID: "t2", Type: models.EntryTypeTask, Status: models.EntryStatusOpen, Content: "Old task", RawContent: "- [ ] Old task", FilePath: "/test/old.md", LineNumber: 2, Date: threeDaysAgo},
 	}
 
 	if err := store.SyncEntries("/test/old.md", entries); err != nil {
@@ -286,6 +333,145 @@ func TestGetStaleTasks(t *testing.T) {
 	}
 }
 
+func TestGetStaleTasksPriorityFirst(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewDBStore(dir)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	defer store.Close()
+
+	yesterday := time.Now().AddDate(0, 0, -1)
+	threeDaysAgo := time.Now().AddDate(0, 0, -3)
+
+	entries := []models.Entry{
+		{ID: "old", Type: models.EntryTypeTask, Status: models.EntryStatusOpen, Content: "Old task", FilePath: "/test/old.md", LineNumber: 1, Date: threeDaysAgo},
+		{ID: "urgent", Type: models.EntryTypeTask, Status: models.EntryStatusOpen, Content: "!!! Urgent", FilePath: "/test/old.md", LineNumber: 2, Date: yesterday, Priority: 3},
+		{ID: "starred", Type: models.EntryTypeTask, Status: models.EntryStatusOpen, Content: "* Starred", FilePath: "/test/old.md", LineNumber: 3, Date: yesterday, Priority: 1},
+	}
+	if err := store.SyncEntries("/test/old.md", entries); err != nil {
+		t.Fatalf("SyncEntries() error: %v", err)
+	}
+
+	tasks, err := store.GetStaleTasks(0)
+	if err != nil {
+		t.Fatalf("GetStaleTasks(0) error: %v", err)
+	}
+	var ids []string
+	for _, e := range tasks {
+		ids = append(ids, e.ID)
+	}
+	if strings.Join(ids, " ") != "urgent starred old" {
+		t.Errorf("GetStaleTasks(0) = %v, want [urgent starred old]", ids)
+	}
+	if len(tasks) > 0 && tasks[0].Priority != 3 {
+		t.Errorf("Priority = %d, want 3", tasks[0].Priority)
+	}
+}
+
+func TestStaleTasksExcludeCollections(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewDBStore(dir)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	defer store.Close()
+
+	lastWeek := time.Now().AddDate(0, 0, -7)
+	somedayPath := filepath.Join("/test", CollectionsDir, "someday.md")
+	entries := []models.Entry{
+		{ID: "s1", Type: models.EntryTypeTask, Status: models.EntryStatusOpen, Content: "Someday", RawContent: "- [ ] Someday", FilePath: somedayPath, LineNumber: 1, Date: lastWeek},
+	}
+	if err := store.SyncEntries(somedayPath, entries); err != nil {
+		t.Fatalf("SyncEntries() error: %v", err)
+	}
+
+	count, err := store.CountStaleTasks(0)
+	if err != nil {
+		t.Fatalf("CountStaleTasks(0) error: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("CountStaleTasks(0) = %d, want 0 (collections never go stale)", count)
+	}
+
+	tasks, err := store.GetStaleTasks(0)
+	if err != nil {
+		t.Fatalf("GetStaleTasks(0) error: %v", err)
+	}
+	if len(tasks) != 0 {
+		t.Errorf("GetStaleTasks(0) returned %d tasks, want 0", len(tasks))
+	}
+}
+
+func TestStaleTasksMatching(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewDBStore(dir)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	defer store.Close()
+
+	twoDaysAgo := time.Now().AddDate(0, 0, -2)
+	fiveDaysAgo := time.Now().AddDate(0, 0, -5)
+
+	entries := []models.Entry{
+		{ID: "t1", Type: models.EntryTypeTask, Status: models.EntryStatusOpen, Content: "Ship it #work", RawContent: "- [ ] Ship it #work", FilePath: "/test/old.md", LineNumber: 1, Date: twoDaysAgo},
+		{ID: "t2", Type: models.EntryTypeTask, Status: models.EntryStatusOpen, Content: "Run #workout", RawContent: "- [ ] Run #workout", FilePath: "/test/old.md", LineNumber: 2, Date: twoDaysAgo, MigrationCount: 1},
+		{ID: "t3", Type: models.EntryTypeTask, Status: models.EntryStatusOpen, Content: "#work Plan", RawContent: "- [ ] #work Plan", FilePath: "/test/old.md", LineNumber: 3, Date: fiveDaysAgo, RescheduleCount: 1},
+		{ID: "t4", Type: models.EntryTypeTask, Status: models.EntryStatusOpen, Content: "File #to_do", RawContent: "- [ ] File #to_do", FilePath: "/test/old.md", LineNumber: 4, Date: twoDaysAgo},
+		{ID: "t5", Type: models.EntryTypeTask, Status: models.EntryStatusOpen, Content: "Email the team (#Work).", RawContent: "- [ ] Email the team (#Work).", FilePath: "/test/old.md", LineNumber: 5, Date: twoDaysAgo},
+	}
+	for i := range entries {
+		entries[i].Tags = models.ParseTags(entries[i].Content)
+	}
+	if err := store.SyncEntries("/test/old.md", entries); err != nil {
+		t.Fatalf("SyncEntries() error: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		filter StaleFilter
+		want   []string
+	}{
+		{"all", StaleFilter{}, []string{"t3", "t1", "t2", "t4", "t5"}},
+		{"last active day", StaleFilter{DaysBack: 1}, []string{"t1", "t2", "t4", "t5"}},
+		{"tag", StaleFilter{Tags: []string{"work"}}, []string{"t3", "t1", "t5"}},
+		{"tag in another case", StaleFilter{Tags: []string{"WORK"}}, []string{"t3", "t1", "t5"}},
+		{"tag with hash", StaleFilter{Tags: []string{"#workout"}}, []string{"t2"}},
+		{"tag with wildcards", StaleFilter{Tags: []string{"to_do"}}, []string{"t4"}},
+		{"wildcards in tag match themselves", StaleFilter{Tags: []string{"wor_"}}, nil},
+		{"percent in tag matches itself", StaleFilter{Tags: []string{"w%"}}, nil},
+		{"new", StaleFilter{Kind: StaleKindNew}, []string{"t1", "t4", "t5"}},
+		{"migrated", StaleFilter{Kind: StaleKindMigrated}, []string{"t2"}},
+		{"scheduled in range", StaleFilter{DaysBack: 7, Kind: StaleKindScheduled}, []string{"t3"}},
+		{"last active day with tag", StaleFilter{DaysBack: 1, Tags: []string{"work"}, Kind: StaleKindScheduled}, []string{"t3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tasks, err := store.GetStaleTasksMatching(tt.filter)
+			if err != nil {
+				t.Fatalf("GetStaleTasksMatching() error: %v", err)
+			}
+			var got []string
+			for _, task := range tasks {
+				got = append(got, task.ID)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("GetStaleTasksMatching() = %v, want %v", got, tt.want)
+			}
+
+			count, err := store.CountStaleTasksMatching(tt.filter)
+			if err != nil {
+				t.Fatalf("CountStaleTasksMatching() error: %v", err)
+			}
+			if count != len(tt.want) {
+				t.Errorf("CountStaleTasksMatching() = %d, want %d", count, len(tt.want))
+			}
+		})
+	}
+}
+
 func TestLastOpenedAt(t *testing.T) {
 	dir := t.TempDir()
 	store, err := NewDBStore(dir)
@@ -350,3 +536,47 @@ func TestUpdateEntryStatus(t *testing.T) {
 		t.Errorf("Entry status = %s, want %s", got[0].Status, models.EntryStatusCompleted)
 	}
 }
+
+func TestGetDeadlines(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewDBStore(dir)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	defer store.Close()
+	custom := []models.StatusDef{
+		{Status: "waiting", Markdown: "w", Open: true},
+		{Status: "delegated", Markdown: "d"},
+	}
+	if err := models.ConfigureStatuses(custom, nil); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = models.ConfigureStatuses(nil, nil) })
+
+	path := "/test/2025-03-01.md"
+	entries := []models.Entry{
+		{ID: "later", Type: models.EntryTypeTask, Status: models.EntryStatusOpen, FilePath: path, LineNumber: 1, DueDate: "2025-03-20"},
+		{ID: "soon", Type: models.EntryTypeTask, Status: models.EntryStatusOpen, FilePath: path, LineNumber: 2, DueDate: "2025-03-07"},
+		{ID: "overdue", Type: models.EntryTypeTask, Status: models.EntryStatusOpen, FilePath: path, LineNumber: 3, DueDate: "2025-03-01"},
+		{ID: "done", Type: models.EntryTypeTask, Status: models.EntryStatusCompleted, FilePath: path, LineNumber: 4, DueDate: "2025-03-02"},
+		{ID: "migrated", Type: models.EntryTypeTask, Status: models.EntryStatusMigrated, FilePath: path, LineNumber: 5, DueDate: "2025-03-02"},
+		{ID: "none", Type: models.EntryTypeTask, Status: models.EntryStatusOpen, FilePath: path, LineNumber: 6},
+		{ID: "waiting", Type: models.EntryTypeTask, Status: "waiting", FilePath: path, LineNumber: 7, DueDate: "2025-03-05"},
+		{ID: "delegated", Type: models.EntryTypeTask, Status: "delegated", FilePath: path, LineNumber: 8, DueDate: "2025-03-04"},
+	}
+	if err := store.SyncEntries(path, entries); err != nil {
+		t.Fatalf("SyncEntries() error: %v", err)
+	}
+
+	got, err := store.GetDeadlines(time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("GetDeadlines() error: %v", err)
+	}
+	var ids []string
+	for _, e := range got {
+		ids = append(ids, e.ID)
+	}
+	if strings.Join(ids, " ") != "overdue waiting soon" {
+		t.Errorf("GetDeadlines() = %v, want [overdue waiting soon]", ids)
+	}
+}
@@ -0,0 +1,406 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/samakintunde/bujo/internal/clock"
+	"github.com/samakintunde/bujo/internal/models"
+)
+
+type PeriodStats struct {
+	Period         string  `json:"period"`
+	Total          int     `json:"total"`
+	Completed      int     `json:"completed"`
+	CompletionRate float64 `json:"completion_rate"`
+}
+
+type RescheduledTask struct {
+	ID              string `json:"id"`
+	Content         string `json:"content"`
+	Status          string `json:"status"`
+	FilePath        string `json:"file_path"`
+	MigrationCount  int    `json:"migration_count"`
+	RescheduleCount int    `json:"reschedule_count"`
+}
+
+type AgeBucket struct {
+	Label   string `json:"label"`
+	MinDays int    `json:"min_days"`
+	MaxDays int    `json:"max_days,omitempty"`
+	Count   int    `json:"count"`
+}
+
+type Streak struct {
+	Current int `json:"current"`
+	Longest int `json:"longest"`
+}
+
+type Stats struct {
+	Weekly                        []PeriodStats     `json:"weekly"`
+	Monthly                       []PeriodStats     `json:"monthly"`
+	AvgMigrationsBeforeCompletion float64           `json:"avg_migrations_before_completion"`
+	AvgDaysToComplete             float64           `json:"avg_days_to_complete"`
+	MostRescheduled               []RescheduledTask `json:"most_rescheduled"`
+	OpenTaskAges                  []AgeBucket       `json:"open_task_ages"`
+	Streak                        Streak            `json:"streak"`
+}
+
+type DayActivity struct {
+	Date      string `json:"date"`
+	Total     int    `json:"total"`
+	Completed int    `json:"completed"`
+}
+
+type StatsOptions struct {
+	Now    time.Time
+	Weeks  int
+	Months int
+	Top    int
+}
+
+// openTaskAgeBuckets are the ranges reported by GetStats, in ascending
+// order. The last bucket is unbounded.
+var openTaskAgeBuckets = []AgeBucket{
+	{Label: "today", MinDays: 0},
+	{Label: "1-7 days", MinDays: 1, MaxDays: 7},
+	{Label: "8-30 days", MinDays: 8, MaxDays: 30},
+	{Label: "31-90 days", MinDays: 31, MaxDays: 90},
+	{Label: "90+ days", MinDays: 91},
+}
+
+// GetStats summarises task outcomes. Moved tasks are left out of completion
+// rates; the entry they moved to counts instead.
+func (s *DBStore) GetStats(opts StatsOptions) (*Stats, error) {
+	if opts.Now.IsZero() {
+		opts.Now = clock.Now()
+	}
+	stats := &Stats{}
+
+	weekStart := startOfWeek(opts.Now).AddDate(0, 0, -7*(max(opts.Weeks, 1)-1))
+	weekly, err := s.periodStats(weekKey, weekStart)
+	if err != nil {
+		return nil, fmt.Errorf("weekly stats: %w", err)
+	}
+	stats.Weekly = weekly
+
+	monthStart := time.Date(opts.Now.Year(), opts.Now.Month()-time.Month(max(opts.Months, 1)-1), 1, 0, 0, 0, 0, time.UTC)
+	monthly, err := s.periodStats(monthKey, monthStart)
+	if err != nil {
+		return nil, fmt.Errorf("monthly stats: %w", err)
+	}
+	stats.Monthly = monthly
+
+	err = s.db.QueryRow(`SELECT COALESCE(AVG(migration_count), 0) FROM entries
+		WHERE type = 'task' AND status = 'completed'`).Scan(&stats.AvgMigrationsBeforeCompletion)
+	if err != nil {
+		return nil, fmt.Errorf("average migrations: %w", err)
+	}
+
+	stats.AvgDaysToComplete, err = s.avgDaysToComplete()
+	if err != nil {
+		return nil, fmt.Errorf("average days to complete: %w", err)
+	}
+
+	stats.MostRescheduled, err = s.GetMostRescheduled(max(opts.Top, 1))
+	if err != nil {
+		return nil, fmt.Errorf("most rescheduled: %w", err)
+	}
+
+	stats.OpenTaskAges, err = s.openTaskAges(opts.Now)
+	if err != nil {
+		return nil, fmt.Errorf("open task ages: %w", err)
+	}
+
+	stats.Streak, err = s.completionStreak(opts.Now)
+	if err != nil {
+		return nil, fmt.Errorf("streak: %w", err)
+	}
+
+	return stats, nil
+}
+
+// taskDay is the journal day a task counts on: the day it was completed,
+// or else the day of its log.
+type taskDay struct {
+	day       time.Time
+	completed bool
+}
+
+// taskDays returns the tasks counting towards completion stats from since
+// onwards. Migrated and scheduled tasks count where they were moved to.
+func (s *DBStore) taskDays(since time.Time) ([]taskDay, error) {
+	// Completions are stored with their offset, so allow a day's slack and
+	// find the journal day in Go.
+	rows, err := s.db.Query(`
+		SELECT entry_date, status, completed_at
+		FROM entries
+		WHERE type = 'task' AND status NOT IN ('migrated', 'scheduled') AND `+notInCollection+`
+		AND (entry_date >= ? OR completed_at >= ?)`,
+		collectionSegment, since.Format(time.DateOnly), since.AddDate(0, 0, -1).Format(time.DateOnly))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var days []taskDay
+	for rows.Next() {
+		var date, status string
+		var completedAt sql.NullTime
+		if err := rows.Scan(&date, &status, &completedAt); err != nil {
+			return nil, err
+		}
+		completed := status == string(models.EntryStatusCompleted)
+		if completed && completedAt.Valid {
+			date = clock.Day(completedAt.Time).Format(time.DateOnly)
+		}
+		d, err := time.Parse(time.DateOnly, date)
+		if err != nil || d.Before(since) {
+			continue
+		}
+		days = append(days, taskDay{day: d, completed: completed})
+	}
+	return days, rows.Err()
+}
+
+// periodStats groups tasks from since onwards by key.
+func (s *DBStore) periodStats(key func(day time.Time) string, since time.Time) ([]PeriodStats, error) {
+	days, err := s.taskDays(since)
+	if err != nil {
+		return nil, err
+	}
+
+	byKey := map[string]*PeriodStats{}
+	for _, t := range days {
+		k := key(t.day)
+		p, ok := byKey[k]
+		if !ok {
+			p = &PeriodStats{Period: k}
+			byKey[k] = p
+		}
+		p.Total++
+		if t.completed {
+			p.Completed++
+		}
+	}
+
+	periods := make([]PeriodStats, 0, len(byKey))
+	for _, p := range byKey {
+		p.CompletionRate = float64(p.Completed) / float64(p.Total)
+		periods = append(periods, *p)
+	}
+	sort.Slice(periods, func(i, j int) bool { return periods[i].Period < periods[j].Period })
+	return periods, nil
+}
+
+// weekKey names the week day falls in after the ISO week of its first
+// Monday, so a week that spans New Year keeps one name.
+func weekKey(day time.Time) string {
+	start := startOfWeek(day)
+	monday := start.AddDate(0, 0, (8-int(start.Weekday()))%7)
+	year, week := monday.ISOWeek()
+	return fmt.Sprintf("%d-W%02d", year, week)
+}
+
+func monthKey(day time.Time) string {
+	return day.Format("2006-01")
+}
+
+// GetMostRescheduled returns the latest entry of each chain, ordered by how
+// many times the task has been moved.
+func (s *DBStore) GetMostRescheduled(limit int) ([]RescheduledTask, error) {
+	rows, err := s.db.Query(`
+		SELECT e.id, e.content, e.status, e.file_path, e.migration_count, e.reschedule_count
+		FROM entries e
+		WHERE e.type = 'task'
+		AND e.migration_count + e.reschedule_count > 0
+		AND NOT EXISTS (SELECT 1 FROM entries c WHERE c.parent_id = e.id)
+		ORDER BY e.migration_count + e.reschedule_count DESC, e.entry_date ASC
+		LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []RescheduledTask
+	for rows.Next() {
+		var t RescheduledTask
+		if err := rows.Scan(&t.ID, &t.Content, &t.Status, &t.FilePath, &t.MigrationCount, &t.RescheduleCount); err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, t)
+	}
+	return tasks, rows.Err()
+}
+
+// avgDaysToComplete averages the days from the start of a completed task's
+// migration chain to its completion.
+func (s *DBStore) avgDaysToComplete() (float64, error) {
+	rows, err := s.db.Query(`
+		WITH RECURSIVE chain(id, root_id) AS (
+			SELECT id, id FROM entries
+			WHERE parent_id IS NULL OR parent_id = ''
+			OR parent_id NOT IN (SELECT id FROM entries)
+			UNION
+			SELECT e.id, c.root_id FROM entries e JOIN chain c ON e.parent_id = c.id
+		)
+		SELECT r.created_at, e.completed_at
+		FROM entries e
+		JOIN chain c ON c.id = e.id
+		JOIN entries r ON r.id = c.root_id
+		WHERE e.type = 'task' AND e.status = 'completed' AND e.completed_at IS NOT NULL`)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var total time.Duration
+	var count int
+	for rows.Next() {
+		var created, completed time.Time
+		if err := rows.Scan(&created, &completed); err != nil {
+			return 0, err
+		}
+		if completed.After(created) {
+			total += completed.Sub(created)
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil || count == 0 {
+		return 0, err
+	}
+	return total.Hours() / 24 / float64(count), nil
+}
+
+// GetDailyActivity returns per-day task totals and completions between from
+// and to (inclusive). Days without tasks are omitted.
+func (s *DBStore) GetDailyActivity(from, to time.Time) ([]DayActivity, error) {
+	days, err := s.taskDays(from)
+	if err != nil {
+		return nil, err
+	}
+
+	byDay := map[string]*DayActivity{}
+	for _, t := range days {
+		if t.day.After(to) {
+			continue
+		}
+		date := t.day.Format(time.DateOnly)
+		d, ok := byDay[date]
+		if !ok {
+			d = &DayActivity{Date: date}
+			byDay[date] = d
+		}
+		d.Total++
+		if t.completed {
+			d.Completed++
+		}
+	}
+
+	activity := make([]DayActivity, 0, len(byDay))
+	for _, d := range byDay {
+		activity = append(activity, *d)
+	}
+	sort.Slice(activity, func(i, j int) bool { return activity[i].Date < activity[j].Date })
+	return activity, nil
+}
+
+// openTaskAges buckets open tasks by the age of their migration chain,
+// leaving out tasks on future days.
+func (s *DBStore) openTaskAges(now time.Time) ([]AgeBucket, error) {
+	open, openArgs := openStatus("e.status")
+	query := fmt.Sprintf(`
+		WITH RECURSIVE chain(id, root_day) AS (
+			SELECT id, entry_date FROM entries
+			WHERE parent_id IS NULL OR parent_id = ''
+			OR parent_id NOT IN (SELECT id FROM entries)
+			UNION
+			SELECT e.id, c.root_day FROM entries e JOIN chain c ON e.parent_id = c.id
+		)
+		SELECT CAST(julianday(?) - julianday(c.root_day) AS INTEGER) AS age, COUNT(*)
+		FROM entries e JOIN chain c ON c.id = e.id
+		WHERE e.type = 'task' AND %s AND %s
+		GROUP BY age`, open, notInCollection)
+
+	args := append([]any{now.Format(time.DateOnly)}, openArgs...)
+	rows, err := s.db.Query(query, append(args, collectionSegment)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	buckets := make([]AgeBucket, len(openTaskAgeBuckets))
+	copy(buckets, openTaskAgeBuckets)
+	for rows.Next() {
+		var age, count int
+		if err := rows.Scan(&age, &count); err != nil {
+			return nil, err
+		}
+		for i := len(buckets) - 1; i >= 0; i-- {
+			if age >= buckets[i].MinDays {
+				buckets[i].Count += count
+				break
+			}
+		}
+	}
+	return buckets, rows.Err()
+}
+
+// completionStreak counts consecutive days with a completed task, ending
+// today or yesterday.
+func (s *DBStore) completionStreak(now time.Time) (Streak, error) {
+	tasks, err := s.taskDays(time.Time{})
+	if err != nil {
+		return Streak{}, err
+	}
+
+	seen := map[time.Time]bool{}
+	var days []time.Time
+	for _, t := range tasks {
+		if t.completed && !seen[t.day] {
+			seen[t.day] = true
+			days = append(days, t.day)
+		}
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].After(days[j]) })
+	return computeStreak(days, now), nil
+}
+
+// computeStreak expects days sorted newest first.
+func computeStreak(days []time.Time, now time.Time) Streak {
+	var streak Streak
+	if len(days) == 0 {
+		return streak
+	}
+
+	today, _ := time.Parse(time.DateOnly, now.Format(time.DateOnly))
+	current := daysBetween(days[0], today) <= 1
+
+	run := 0
+	for i, day := range days {
+		if i > 0 && daysBetween(day, days[i-1]) == 1 {
+			run++
+		} else {
+			if i > 0 {
+				current = false
+			}
+			run = 1
+		}
+		streak.Longest = max(streak.Longest, run)
+		if current {
+			streak.Current = run
+		}
+	}
+	return streak
+}
+
+func daysBetween(from, to time.Time) int {
+	return int(to.Sub(from).Hours() / 24)
+}
+
+func startOfWeek(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return day.AddDate(0, 0, -clock.WeekdayIndex(day))
+}
@@ -0,0 +1,295 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/samakintunde/bujo/internal/clock"
+	"github.com/samakintunde/bujo/internal/models"
+)
+
+func day(s string) time.Time {
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestGetStats(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewDBStore(dir)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	defer store.Close()
+
+	entries := []models.Entry{
+		{ID: "a1", Type: models.EntryTypeTask, Status: models.EntryStatusCompleted, Content: "Done early", FilePath: "/test/2024-03-04.md", LineNumber: 1, Date: day("2024-03-04")},
+		{ID: "a2", Type: models.EntryTypeTask, Status: models.EntryStatusMigrated, Content: "Chronic", FilePath: "/test/2024-03-04.md", LineNumber: 2, Date: day("2024-03-04")},
+		{ID: "b1", Type: models.EntryTypeTask, Status: models.EntryStatusMigrated, Content: "Chronic", FilePath: "/test/2024-03-05.md", LineNumber: 1, Date: day("2024-03-05"), ParentID: "a2", MigrationCount: 1},
+		{ID: "b2", Type: models.EntryTypeTask, Status: models.EntryStatusCompleted, Content: "Done", FilePath: "/test/2024-03-05.md", LineNumber: 2, Date: day("2024-03-05"), MigrationCount: 1},
+		{ID: "c1", Type: models.EntryTypeTask, Status: models.EntryStatusOpen, Content: "Chronic", FilePath: "/test/2024-03-11.md", LineNumber: 1, Date: day("2024-03-11"), ParentID: "b1", MigrationCount: 2},
+		{ID: "c2", Type: models.EntryTypeTask, Status: models.EntryStatusOpen, Content: "Fresh", FilePath: "/test/2024-03-11.md", LineNumber: 2, Date: day("2024-03-11")},
+		{ID: "c3", Type: models.EntryTypeTask, Status: models.EntryStatusCancelled, Content: "Nope", FilePath: "/test/2024-03-11.md", LineNumber: 3, Date: day("2024-03-11")},
+		{ID: "c4", Type: models.EntryTypeNote, Status: models.EntryStatusOpen, Content: "A note", FilePath: "/test/2024-03-11.md", LineNumber: 4, Date: day("2024-03-11")},
+	}
+	for _, path := range []string{"/test/2024-03-04.md", "/test/2024-03-05.md", "/test/2024-03-11.md"} {
+		var fileEntries []models.Entry
+		for _, e := range entries {
+			if e.FilePath == path {
+				fileEntries = append(fileEntries, e)
+			}
+		}
+		if err := store.SyncEntries(path, fileEntries); err != nil {
+			t.Fatalf("SyncEntries() error: %v", err)
+		}
+	}
+
+	stats, err := store.GetStats(StatsOptions{Now: day("2024-03-11"), Weeks: 4, Months: 2, Top: 3})
+	if err != nil {
+		t.Fatalf("GetStats() error: %v", err)
+	}
+
+	if len(stats.Weekly) != 2 {
+		t.Fatalf("Weekly = %+v, want 2 periods", stats.Weekly)
+	}
+	if stats.Weekly[0].Total != 2 || stats.Weekly[0].Completed != 2 {
+		t.Errorf("Weekly[0] = %+v, want 2/2 (migrated tasks excluded)", stats.Weekly[0])
+	}
+	if stats.Weekly[1].Total != 3 || stats.Weekly[1].Completed != 0 {
+		t.Errorf("Weekly[1] = %+v, want 0/3", stats.Weekly[1])
+	}
+
+	if len(stats.Monthly) != 1 || stats.Monthly[0].Period != "2024-03" {
+		t.Fatalf("Monthly = %+v, want single 2024-03 period", stats.Monthly)
+	}
+	if got := stats.Monthly[0].CompletionRate; got != 0.4 {
+		t.Errorf("Monthly completion rate = %v, want 0.4", got)
+	}
+
+	if stats.AvgDaysToComplete != 0 {
+		t.Errorf("AvgDaysToComplete = %v, want 0 without completion times", stats.AvgDaysToComplete)
+	}
+
+	if stats.AvgMigrationsBeforeCompletion != 0.5 {
+		t.Errorf("AvgMigrationsBeforeCompletion = %v, want 0.5", stats.AvgMigrationsBeforeCompletion)
+	}
+
+	if len(stats.MostRescheduled) == 0 || stats.MostRescheduled[0].ID != "c1" {
+		t.Errorf("MostRescheduled = %+v, want c1 first", stats.MostRescheduled)
+	}
+	for _, task := range stats.MostRescheduled {
+		if task.ID == "b1" {
+			t.Errorf("MostRescheduled includes b1, which has been migrated onward")
+		}
+	}
+
+	ages := map[string]int{}
+	for _, b := range stats.OpenTaskAges {
+		ages[b.Label] = b.Count
+	}
+	if ages["today"] != 1 {
+		t.Errorf("OpenTaskAges[today] = %d, want 1", ages["today"])
+	}
+	if ages["1-7 days"] != 1 {
+		t.Errorf("OpenTaskAges[1-7 days] = %d, want 1 (aged from chain root)", ages["1-7 days"])
+	}
+
+	if stats.Streak.Longest != 2 {
+		t.Errorf("Streak.Longest = %d, want 2", stats.Streak.Longest)
+	}
+	if stats.Streak.Current != 0 {
+		t.Errorf("Streak.Current = %d, want 0", stats.Streak.Current)
+	}
+}
+
+func TestComputeStreak(t *testing.T) {
+	now := day("2024-03-10")
+	tests := []struct {
+		name string
+		days []string
+		want Streak
+	}{
+		{"empty", nil, Streak{}},
+		{"ends today", []string{"2024-03-10", "2024-03-09", "2024-03-08"}, Streak{Current: 3, Longest: 3}},
+		{"ends yesterday", []string{"2024-03-09", "2024-03-08"}, Streak{Current: 2, Longest: 2}},
+		{"broken", []string{"2024-03-07", "2024-03-06"}, Streak{Current: 0, Longest: 2}},
+		{"longest in past", []string{"2024-03-10", "2024-03-05", "2024-03-04", "2024-03-03"}, Streak{Current: 1, Longest: 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var days []time.Time
+			for _, d := range tt.days {
+				days = append(days, day(d))
+			}
+			if got := computeStreak(days, now); got != tt.want {
+				t.Errorf("computeStreak() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetDailyActivity(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewDBStore(dir)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	defer store.Close()
+
+	entries := []models.Entry{
+		{ID: "t1", Type: models.EntryTypeTask, Status: models.EntryStatusCompleted, Content: "Done", FilePath: "/test/a.md", LineNumber: 1, Date: day("2024-03-04")},
+		{ID: "t2", Type: models.EntryTypeTask, Status: models.EntryStatusOpen, Content: "Open", FilePath: "/test/a.md", LineNumber: 2, Date: day("2024-03-04")},
+		{ID: "t3", Type: models.EntryTypeTask, Status: models.EntryStatusMigrated, Content: "Moved", FilePath: "/test/a.md", LineNumber: 3, Date: day("2024-03-04")},
+		{ID: "t4", Type: models.EntryTypeTask, Status: models.EntryStatusCompleted, Content: "Too old", FilePath: "/test/a.md", LineNumber: 4, Date: day("2024-02-01")},
+	}
+	if err := store.SyncEntries("/test/a.md", entries); err != nil {
+		t.Fatalf("SyncEntries() error: %v", err)
+	}
+
+	activity, err := store.GetDailyActivity(day("2024-03-01"), day("2024-03-10"))
+	if err != nil {
+		t.Fatalf("GetDailyActivity() error: %v", err)
+	}
+
+	want := []DayActivity{{Date: "2024-03-04", Total: 2, Completed: 1}}
+	if len(activity) != len(want) || activity[0] != want[0] {
+		t.Errorf("GetDailyActivity() = %+v, want %+v", activity, want)
+	}
+}
+
+func TestAvgDaysToComplete(t *testing.T) {
+	store, err := NewDBStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	defer store.Close()
+
+	at := func(s string) time.Time {
+		ts, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			panic(err)
+		}
+		return ts
+	}
+	// A task migrated once and completed three days after it was first
+	// written down, and one completed the day it was created.
+	entries := []models.Entry{
+		{ID: "a1", Type: models.EntryTypeTask, Status: models.EntryStatusMigrated, Content: "Slow", FilePath: "/test/2024-03-04.md", LineNumber: 1, Date: day("2024-03-04"), CreatedAt: at("2024-03-04T09:00:00Z")},
+		{ID: "a2", Type: models.EntryTypeTask, Status: models.EntryStatusCompleted, Content: "Quick", FilePath: "/test/2024-03-04.md", LineNumber: 2, Date: day("2024-03-04"), CreatedAt: at("2024-03-04T09:00:00Z"), CompletedAt: at("2024-03-04T21:00:00Z")},
+		{ID: "b1", Type: models.EntryTypeTask, Status: models.EntryStatusCompleted, Content: "Slow", FilePath: "/test/2024-03-05.md", LineNumber: 1, Date: day("2024-03-05"), CreatedAt: at("2024-03-05T08:00:00Z"), CompletedAt: at("2024-03-07T09:00:00Z"), ParentID: "a1", MigrationCount: 1},
+	}
+	if err := store.SyncEntries("/test/2024-03-04.md", entries[:2]); err != nil {
+		t.Fatalf("SyncEntries() error: %v", err)
+	}
+	if err := store.SyncEntries("/test/2024-03-05.md", entries[2:]); err != nil {
+		t.Fatalf("SyncEntries() error: %v", err)
+	}
+
+	got, err := store.avgDaysToComplete()
+	if err != nil {
+		t.Fatalf("avgDaysToComplete() error: %v", err)
+	}
+	if want := (3 + 0.5) / 2; got != want {
+		t.Errorf("avgDaysToComplete() = %v, want %v", got, want)
+	}
+
+	done, err := store.GetEntryByID("b1")
+	if err != nil {
+		t.Fatalf("GetEntryByID() error: %v", err)
+	}
+	if !done.CompletedAt.Equal(at("2024-03-07T09:00:00Z")) || !done.Date.Equal(day("2024-03-05")) {
+		t.Errorf("GetEntryByID() CompletedAt, Date = %v, %v", done.CompletedAt, done.Date)
+	}
+}
+
+func TestWeeklyStatsFollowWeekStart(t *testing.T) {
+	store, err := NewDBStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDBStore() error: %v", err)
+	}
+	defer store.Close()
+
+	for _, date := range []string{"2024-03-09", "2024-03-10"} {
+		path := "/test/" + date + ".md"
+		entry := models.Entry{ID: date, Type: models.EntryTypeTask, Status: models.EntryStatusOpen, Content: "Task", FilePath: path, LineNumber: 1, Date: day(date)}
+		if err := store.SyncEntries(path, []models.Entry{entry}); err != nil {
+			t.Fatalf("SyncEntries() error: %v", err)
+		}
+	}
+
+	weekly := func() []PeriodStats {
+		t.Helper()
+		stats, err := store.GetStats(StatsOptions{Now: day("2024-03-10"), Weeks: 2})
+		if err != nil {
+			t.Fatalf("GetStats() error: %v", err)
+		}
+		return stats.Weekly
+	}
+
+	// Saturday and Sunday share a week that starts on Monday...
+	if got := weekly(); len(got) != 1 || got[0].Total != 2 {
+		t.Errorf("Weekly = %+v, want one week of 2 tasks", got)
+	}
+
+	// ...but not one that starts on Sunday.
+	clock.SetWeekStart(time.Sunday)
+	defer clock.SetWeekStart(time.Monday)
+	got := weekly()
+	if len(got) != 2 || got[0].Period != "2024-W10" || got[1].Period != "2024-W11" {
+		t.Errorf("Weekly = %+v, want 2024-W10 and 2024-W11 with a task each", got)
+	}
+}
+
+func TestStatsCountCompletionDay(t *testing.T) {
+	store, err := NewDBStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDBStore() error: %v", err)
+	}
+	defer store.Close()
+
+	// Logged in December and done on New Year's Day, and an open task in the
+	// same Monday-to-Sunday week on the other side of New Year.
+	done, _ := time.Parse(time.RFC3339, "2025-01-01T10:00:00Z")
+	entries := []models.Entry{
+		{ID: "old", Type: models.EntryTypeTask, Status: models.EntryStatusCompleted, Content: "Late", FilePath: "/test/2024-12-02.md", LineNumber: 1, Date: day("2024-12-02"), CompletedAt: done},
+		{ID: "new", Type: models.EntryTypeTask, Status: models.EntryStatusOpen, Content: "Fresh", FilePath: "/test/2024-12-31.md", LineNumber: 1, Date: day("2024-12-31")},
+		// Collections are dated by mtime and left out.
+		{ID: "idea", Type: models.EntryTypeTask, Status: models.EntryStatusCompleted, Content: "Idea", FilePath: "/test/collections/ideas.md", LineNumber: 1, Date: day("2025-01-02"), CompletedAt: done},
+	}
+	for _, e := range entries {
+		if err := store.SyncEntries(e.FilePath, []models.Entry{e}); err != nil {
+			t.Fatalf("SyncEntries() error: %v", err)
+		}
+	}
+
+	stats, err := store.GetStats(StatsOptions{Now: day("2025-01-02"), Weeks: 1, Months: 1})
+	if err != nil {
+		t.Fatalf("GetStats() error: %v", err)
+	}
+	want := PeriodStats{Period: "2025-W01", Total: 2, Completed: 1, CompletionRate: 0.5}
+	if len(stats.Weekly) != 1 || stats.Weekly[0] != want {
+		t.Errorf("Weekly = %+v, want %+v", stats.Weekly, want)
+	}
+	want = PeriodStats{Period: "2025-01", Total: 1, Completed: 1, CompletionRate: 1}
+	if len(stats.Monthly) != 1 || stats.Monthly[0] != want {
+		t.Errorf("Monthly = %+v, want %+v", stats.Monthly, want)
+	}
+	if stats.Streak != (Streak{Current: 1, Longest: 1}) {
+		t.Errorf("Streak = %+v, want the New Year's Day completion", stats.Streak)
+	}
+	if got := stats.OpenTaskAges[1].Count; got != 1 {
+		t.Errorf("OpenTaskAges[1-7 days] = %d, want only the daily log's task", got)
+	}
+
+	activity, err := store.GetDailyActivity(day("2024-12-01"), day("2025-01-02"))
+	if err != nil {
+		t.Fatalf("GetDailyActivity() error: %v", err)
+	}
+	wantActivity := []DayActivity{{Date: "2024-12-31", Total: 1}, {Date: "2025-01-01", Total: 1, Completed: 1}}
+	if len(activity) != 2 || activity[0] != wantActivity[0] || activity[1] != wantActivity[1] {
+		t.Errorf("GetDailyActivity() = %+v, want %+v", activity, wantActivity)
+	}
+}
@@ -0,0 +1,257 @@
+package sync
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/samakintunde/bujo/internal/id"
+	"github.com/samakintunde/bujo/internal/models"
+	"github.com/samakintunde/bujo/internal/parser"
+	"github.com/samakintunde/bujo/internal/storage"
+)
+
+// ProblemKind names an inconsistency bujo doctor looks for.
+type ProblemKind string
+
+const (
+	ProblemDuplicateID    ProblemKind = "duplicate id"
+	ProblemDanglingParent ProblemKind = "dangling parent"
+	ProblemBadMetadata    ProblemKind = "malformed metadata"
+	ProblemParentCycle    ProblemKind = "parent cycle"
+	ProblemIndexMismatch  ProblemKind = "index mismatch"
+)
+
+// Problem is an inconsistency in the journal or its index. Line is 0 when
+// it concerns a whole file.
+type Problem struct {
+	Kind   ProblemKind
+	Path   string
+	Line   int
+	ID     string
+	Detail string
+}
+
+// Diagnosis lists the problems found in a journal together with the line
+// edits that repair them.
+type Diagnosis struct {
+	Problems []Problem
+	// repaired holds the entries whose lines need rewriting.
+	repaired []*models.Entry
+}
+
+func (d *Diagnosis) add(p Problem, repaired *models.Entry) {
+	d.Problems = append(d.Problems, p)
+	if repaired == nil {
+		return
+	}
+	for _, e := range d.repaired {
+		if e == repaired {
+			return
+		}
+	}
+	d.repaired = append(d.repaired, repaired)
+}
+
+// Diagnose checks the journal and its index for problems without changing
+// anything.
+func (s *Syncer) Diagnose() (*Diagnosis, error) {
+	paths, err := s.files()
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Diagnosis{}
+	var entries []*models.Entry
+	for _, path := range paths {
+		parsed, err := parser.ParseRaw(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		if err := s.checkIndex(d, path, parsed); err != nil {
+			return nil, err
+		}
+
+		for i := range parsed {
+			e := &parsed[i]
+			if err := parser.MetadataError(e.RawContent); err != nil {
+				detail := err.Error()
+				fixed, dropped := salvage(e)
+				if len(dropped) > 0 {
+					detail += "; fixing drops " + strings.Join(dropped, ", ")
+				}
+				d.add(Problem{Kind: ProblemBadMetadata, Path: path, Line: e.LineNumber, Detail: detail}, fixed)
+			}
+			if e.Type != models.EntryTypeIgnore && e.ID != "" {
+				entries = append(entries, e)
+			}
+		}
+	}
+
+	checkDuplicateIDs(d, entries)
+	byID := make(map[string]*models.Entry, len(entries))
+	for _, e := range entries {
+		byID[e.ID] = e
+	}
+	checkParents(d, entries, byID)
+
+	indexed, err := s.DB.GetIndexedPaths()
+	if err != nil {
+		return nil, err
+	}
+	onDisk := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		onDisk[path] = true
+	}
+	for _, path := range indexed {
+		if !onDisk[path] {
+			d.add(Problem{Kind: ProblemIndexMismatch, Path: path, Detail: "file no longer exists but is still indexed"}, nil)
+		}
+	}
+
+	return d, nil
+}
+
+// Repair rewrites the lines a diagnosis found broken and rebuilds the index.
+func (s *Syncer) Repair(d *Diagnosis) error {
+	updates := make(map[string]map[int]string)
+	for _, e := range d.repaired {
+		if updates[e.FilePath] == nil {
+			updates[e.FilePath] = make(map[int]string)
+		}
+		updates[e.FilePath][e.LineNumber] = e.RawString()
+	}
+	fs := &storage.FSStore{Root: s.Root}
+	for path, lines := range updates {
+		if err := fs.UpdateLines(path, lines); err != nil {
+			return fmt.Errorf("failed to repair %s: %w", path, err)
+		}
+	}
+	return s.Reindex()
+}
+
+// checkIndex compares a file's entries with what the index holds for it.
+func (s *Syncer) checkIndex(d *Diagnosis, path string, parsed []models.Entry) error {
+	indexed, err := s.DB.GetEntriesByFile(path)
+	if err != nil {
+		return err
+	}
+	var want, got []string
+	for _, e := range parsed {
+		if e.Type != models.EntryTypeIgnore {
+			want = append(want, indexKey(e))
+		}
+	}
+	for _, e := range indexed {
+		got = append(got, indexKey(e))
+	}
+	if strings.Join(want, "\n") == strings.Join(got, "\n") {
+		return nil
+	}
+	detail := "index is out of date"
+	if len(indexed) == 0 {
+		detail = "file is not indexed"
+	}
+	d.add(Problem{Kind: ProblemIndexMismatch, Path: path, Detail: detail}, nil)
+	return nil
+}
+
+func indexKey(e models.Entry) string {
+	return fmt.Sprintf("%s|%d|%s|%s|%s", e.ID, e.LineNumber, e.Type, e.Status, e.Content)
+}
+
+// salvage rebuilds an entry from a line with broken metadata, keeping the
+// fields that can still be read. It returns the keys of those that can't.
+func salvage(e *models.Entry) (*models.Entry, []string) {
+	rest, meta, dropped := parser.SalvageMetadata(e.RawContent)
+	if meta.ID == "" {
+		meta.ID = id.New()
+	}
+	fixed := parser.ParseLine(rest + " " + meta.String())
+	if fixed.Type == models.EntryTypeIgnore {
+		return nil, dropped
+	}
+	fixed.FilePath, fixed.LineNumber = e.FilePath, e.LineNumber
+	*e = fixed
+	return e, dropped
+}
+
+// checkDuplicateIDs keeps the first entry with an ID, in path order, and
+// gives every later copy a fresh one.
+func checkDuplicateIDs(d *Diagnosis, entries []*models.Entry) {
+	first := make(map[string]*models.Entry, len(entries))
+	for _, e := range entries {
+		original, ok := first[e.ID]
+		if !ok {
+			first[e.ID] = e
+			continue
+		}
+		d.add(Problem{
+			Kind:   ProblemDuplicateID,
+			Path:   e.FilePath,
+			Line:   e.LineNumber,
+			ID:     e.ID,
+			Detail: fmt.Sprintf("also on %s:%d", filepath.Base(original.FilePath), original.LineNumber),
+		}, e)
+		e.ID = id.New()
+	}
+}
+
+// checkParents unlinks missing parents and breaks each parent cycle at its
+// earliest entry.
+func checkParents(d *Diagnosis, entries []*models.Entry, byID map[string]*models.Entry) {
+	for _, e := range entries {
+		if e.ParentID != "" && byID[e.ParentID] == nil {
+			d.add(Problem{Kind: ProblemDanglingParent, Path: e.FilePath, Line: e.LineNumber, ID: e.ID, Detail: "parent #" + e.ParentID + " not found"}, e)
+			e.ParentID = ""
+		}
+	}
+
+	order := make(map[*models.Entry]int, len(entries))
+	for i, e := range entries {
+		order[e] = i
+	}
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[*models.Entry]int, len(entries))
+	for _, start := range entries {
+		var chain []*models.Entry
+		cur := start
+		for cur != nil && state[cur] == unvisited {
+			state[cur] = visiting
+			chain = append(chain, cur)
+			cur = byID[cur.ParentID]
+		}
+		if cur != nil && state[cur] == visiting {
+			var cycle []*models.Entry
+			for i, e := range chain {
+				if e == cur {
+					cycle = chain[i:]
+					break
+				}
+			}
+			earliest := cycle[0]
+			var ids []string
+			for _, e := range cycle {
+				ids = append(ids, "#"+e.ID)
+				if order[e] < order[earliest] {
+					earliest = e
+				}
+			}
+			d.add(Problem{
+				Kind:   ProblemParentCycle,
+				Path:   earliest.FilePath,
+				Line:   earliest.LineNumber,
+				ID:     earliest.ID,
+				Detail: strings.Join(ids, " → "),
+			}, earliest)
+			earliest.ParentID = ""
+		}
+		for _, e := range chain {
+			state[e] = visited
+		}
+	}
+}
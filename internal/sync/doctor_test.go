@@ -0,0 +1,109 @@
+package sync
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiagnoseAndRepair(t *testing.T) {
+	dir, syncer := setupSyncer(t)
+
+	first := filepath.Join(dir, "2024-01-15.md")
+	second := filepath.Join(dir, "2024-01-16.md")
+	files := map[string]string{
+		first: `- [ ] Original <!-- {"id":"AAA"} -->
+- [ ] Orphan <!-- {"id":"BBB","pid":"GONE"} -->
+- [ ] Loop one <!-- {"id":"C1","pid":"C2"} -->
+- [ ] Loop two <!-- {"id":"C2","pid":"C1"} -->
+`,
+		second: `- [ ] Copied <!-- {"id":"AAA"} -->
+- [ ] Broken <!-- {"id":"DDD","mig":1,"ct":"2024-01-16T09:00:00Z","ut":"soon", -->
+`,
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	d, err := syncer.Diagnose()
+	if err != nil {
+		t.Fatalf("Diagnose() error: %v", err)
+	}
+	found := make(map[ProblemKind]Problem)
+	for _, p := range d.Problems {
+		found[p.Kind] = p
+	}
+	for _, kind := range []ProblemKind{ProblemDuplicateID, ProblemDanglingParent, ProblemBadMetadata, ProblemParentCycle, ProblemIndexMismatch} {
+		if _, ok := found[kind]; !ok {
+			t.Errorf("Diagnose() missed a %s: %+v", kind, d.Problems)
+		}
+	}
+	if p := found[ProblemDuplicateID]; p.Path != second || p.Line != 1 {
+		t.Errorf("duplicate reported at %s:%d, want the later copy", p.Path, p.Line)
+	}
+	if p := found[ProblemBadMetadata]; !strings.HasSuffix(p.Detail, "fixing drops ut") {
+		t.Errorf("bad metadata detail = %q, want the dropped field named", p.Detail)
+	}
+	if p := found[ProblemParentCycle]; p.ID != "C1" {
+		t.Errorf("cycle broken at #%s, want the earliest entry C1", p.ID)
+	}
+
+	if err := syncer.Repair(d); err != nil {
+		t.Fatalf("Repair() error: %v", err)
+	}
+
+	got, _ := os.ReadFile(first)
+	for _, want := range []string{`Orphan <!-- {"id":"BBB"} -->`, `Loop one <!-- {"id":"C1"} -->`, `Loop two <!-- {"id":"C2","pid":"C1"} -->`} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("%s missing %q:\n%s", first, want, got)
+		}
+	}
+	got, _ = os.ReadFile(second)
+	if strings.Contains(string(got), `"id":"AAA"`) {
+		t.Errorf("copy kept the duplicate ID:\n%s", got)
+	}
+	if !strings.Contains(string(got), `- [ ] Broken <!-- {"id":"DDD","mig":1,"ct":"2024-01-16T09:00:00Z"} -->`) {
+		t.Errorf("broken metadata not rewritten with the fields it kept:\n%s", got)
+	}
+
+	d, err = syncer.Diagnose()
+	if err != nil {
+		t.Fatalf("Diagnose() after repair error: %v", err)
+	}
+	if len(d.Problems) != 0 {
+		t.Errorf("problems left after repair: %+v", d.Problems)
+	}
+}
+
+func TestDiagnoseRemovedFile(t *testing.T) {
+	dir, syncer := setupSyncer(t)
+
+	path := filepath.Join(dir, "2024-01-15.md")
+	if err := os.WriteFile(path, []byte("- [ ] Task <!-- {\"id\":\"AAA\"} -->\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := syncer.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := syncer.Diagnose()
+	if err != nil {
+		t.Fatalf("Diagnose() error: %v", err)
+	}
+	if len(d.Problems) != 1 || d.Problems[0].Kind != ProblemIndexMismatch || d.Problems[0].Path != path {
+		t.Errorf("Diagnose() = %+v, want one index mismatch for the removed file", d.Problems)
+	}
+
+	if err := syncer.Reindex(); err != nil {
+		t.Fatalf("Reindex() error: %v", err)
+	}
+	if d, _ := syncer.Diagnose(); len(d.Problems) != 0 {
+		t.Errorf("problems left after reindex: %+v", d.Problems)
+	}
+}
@@ -1,13 +1,18 @@
 package sync
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
-	"time"
+	gosync "sync"
 
+	"github.com/samakintunde/bujo/internal/clock"
 	"github.com/samakintunde/bujo/internal/id"
 	"github.com/samakintunde/bujo/internal/models"
 	"github.com/samakintunde/bujo/internal/parser"
@@ -17,20 +22,107 @@ import (
 type Syncer struct {
 	Root string
 	DB   *storage.DBStore
+	// Log receives a line for every repair made to a journal file.
+	Log io.Writer
 }
 
 func NewSyncer(root string, db *storage.DBStore) *Syncer {
 	return &Syncer{
 		Root: root,
 		DB:   db,
+		Log:  os.Stderr,
 	}
 }
 
+// syncBatchSize is how many files Sync parses together and writes in one
+// transaction.
+const syncBatchSize = 256
+
+// Sync indexes every file changed since it was last synced and drops
+// deleted ones. The first copy of a duplicate ID, in path order, keeps it.
 func (s *Syncer) Sync() error {
-	if err := os.MkdirAll(s.Root, 0755); err != nil {
+	paths, err := s.files()
+	if err != nil {
+		return err
+	}
+	synced, err := s.DB.GetFileSyncTimes()
+	if err != nil {
+		return fmt.Errorf("failed to get sync status: %w", err)
+	}
+
+	var changed []string
+	walked := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		walked[path] = true
+		if info.ModTime().After(synced[path]) {
+			changed = append(changed, path)
+		}
+	}
+	if err := s.prune(walked); err != nil {
 		return err
 	}
-	return filepath.WalkDir(s.Root, func(path string, d fs.DirEntry, err error) error {
+
+	claimed := make(map[string]string)
+	for start := 0; start < len(changed); start += syncBatchSize {
+		files, err := parseFiles(s.Root, changed[start:min(start+syncBatchSize, len(changed))])
+		if err != nil {
+			return err
+		}
+		for i := range files {
+			if err := s.repair(&files[i], claimed); err != nil {
+				return err
+			}
+		}
+		if err := s.DB.SyncFiles(files); err != nil {
+			return fmt.Errorf("failed to sync entries: %w", err)
+		}
+	}
+	return nil
+}
+
+// prune drops indexed files that are gone from disk.
+func (s *Syncer) prune(walked map[string]bool) error {
+	indexed, err := s.DB.GetIndexedPaths()
+	if err != nil {
+		return fmt.Errorf("failed to get indexed files: %w", err)
+	}
+	for _, path := range indexed {
+		if walked[path] {
+			continue
+		}
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			continue
+		}
+		if err := s.DB.RemoveFile(path); err != nil {
+			return fmt.Errorf("failed to remove %s from index: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// Reindex drops the index and rebuilds it from the Markdown files.
+func (s *Syncer) Reindex() error {
+	if err := s.DB.Reset(); err != nil {
+		return fmt.Errorf("failed to reset index: %w", err)
+	}
+	return s.Sync()
+}
+
+// files lists the journal's Markdown files in path order, skipping hidden
+// directories.
+func (s *Syncer) files() ([]string, error) {
+	if err := os.MkdirAll(s.Root, 0755); err != nil {
+		return nil, err
+	}
+	var paths []string
+	err := filepath.WalkDir(s.Root, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
@@ -40,18 +132,40 @@ func (s *Syncer) Sync() error {
 			}
 			return nil
 		}
-		if filepath.Ext(path) != ".md" {
-			return nil
+		if filepath.Ext(path) == ".md" {
+			paths = append(paths, path)
 		}
+		return nil
+	})
+	return paths, err
+}
 
-		info, err := d.Info()
-		if err != nil {
+// SyncPaths re-indexes the given journal files, relative to Root, dropping
+// any that no longer exist from the index.
+func (s *Syncer) SyncPaths(paths []string) error {
+	for _, rel := range paths {
+		if filepath.Ext(rel) != ".md" || strings.HasPrefix(rel, ".") {
+			continue
+		}
+		path := filepath.Join(s.Root, rel)
+		if _, err := os.Stat(path); err != nil {
+			if os.IsNotExist(err) {
+				if err := s.DB.RemoveFile(path); err != nil {
+					return fmt.Errorf("failed to remove %s from index: %w", path, err)
+				}
+				continue
+			}
 			return err
 		}
-		return s.syncFileWithInfo(path, info)
-	})
+		if err := s.indexFile(path); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
+// SyncFile re-indexes a single file if its mtime or content changed since it
+// was last synced.
 func (s *Syncer) SyncFile(path string) error {
 	info, err := os.Stat(path)
 	if err != nil {
@@ -60,62 +174,252 @@ func (s *Syncer) SyncFile(path string) error {
 		}
 		return err
 	}
-	return s.syncFileWithInfo(path, info)
+	unchanged, err := s.unchanged(path, info)
+	if err != nil || unchanged {
+		return err
+	}
+	return s.indexFile(path)
 }
 
-func (s *Syncer) syncFileWithInfo(path string, info fs.FileInfo) error {
-	lastSynced, err := s.DB.GetFileLastSync(path)
+// unchanged reports whether the file at path is as it was last synced: not
+// modified since, and with the same content.
+func (s *Syncer) unchanged(path string, info fs.FileInfo) (bool, error) {
+	synced, err := s.DB.GetFileLastSync(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to get sync status for %s: %w", path, err)
+	}
+	if info.ModTime().After(synced) {
+		return false, nil
+	}
+	hash, err := s.DB.GetFileHash(path)
+	if err != nil || hash == "" {
+		return false, err
+	}
+	data, err := os.ReadFile(path)
 	if err != nil {
-		return fmt.Errorf("failed to get sync status for %s: %w", path, err)
+		return false, err
 	}
+	return fileHash(data) == hash, nil
+}
 
-	if info.ModTime().After(lastSynced) {
-		entries, err := parser.ParseRaw(path)
+func (s *Syncer) indexFile(path string) error {
+	f, err := parseFile(s.Root, path)
+	if err != nil {
+		return err
+	}
+	if err := s.repair(&f, make(map[string]string)); err != nil {
+		return err
+	}
+	if err := s.DB.SyncFiles([]storage.FileEntries{f}); err != nil {
+		return fmt.Errorf("failed to sync entries for %s: %w", path, err)
+	}
+	return nil
+}
+
+func fileHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// parseFiles parses files in the journal at root on a bounded pool of
+// workers and returns them in the order given.
+func parseFiles(root string, paths []string) ([]storage.FileEntries, error) {
+	files := make([]storage.FileEntries, len(paths))
+	errs := make([]error, len(paths))
+
+	jobs := make(chan int)
+	var wg gosync.WaitGroup
+	for w := 0; w < min(runtime.GOMAXPROCS(0), len(paths)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				files[i], errs[i] = parseFile(root, paths[i])
+			}
+		}()
+	}
+	for i := range paths {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, err := range errs {
 		if err != nil {
-			return fmt.Errorf("failed to parse %s: %w", path, err)
+			return nil, err
 		}
+	}
+	return files, nil
+}
+
+// parseFile reads a file's entries, dated by its path or, failing that, its
+// modification time.
+func parseFile(root, path string) (storage.FileEntries, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return storage.FileEntries{}, err
+	}
+	entries, err := parser.ParseRawData(path, data)
+	if err != nil {
+		return storage.FileEntries{}, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
 
-		filename := filepath.Base(path)
-		dateStr := strings.TrimSuffix(filename, filepath.Ext(filename))
-		fileDate, err := time.Parse(time.DateOnly, dateStr)
+	fileDate, ok := storage.DayFromPath(root, path)
+	if !ok {
+		info, err := os.Stat(path)
 		if err != nil {
-			fileDate = info.ModTime()
+			return storage.FileEntries{}, err
 		}
+		fileDate = clock.Day(info.ModTime())
+	}
 
-		dirty := false
-		for i := range entries {
-			if entries[i].Type == models.EntryTypeIgnore {
-				continue
-			}
-			entries[i].CreatedAt = fileDate
-			entries[i].UpdatedAt = time.Now()
+	for i := range entries {
+		e := &entries[i]
+		if e.Type == models.EntryTypeIgnore {
+			continue
+		}
+		e.Date = fileDate
+		if e.CreatedAt.IsZero() {
+			e.CreatedAt = fileDate
+		}
+		if e.UpdatedAt.IsZero() {
+			e.UpdatedAt = e.CreatedAt
+		}
+	}
+	return storage.FileEntries{Path: path, Entries: entries, Hash: fileHash(data)}, nil
+}
 
-			if entries[i].ID == "" {
-				entries[i].ID = id.New()
-				dirty = true
-			}
+// repair gives missing and duplicate IDs fresh ones, stamps hand-edited
+// tasks and writes them back. claimed maps IDs seen so far to their file.
+func (s *Syncer) repair(f *storage.FileEntries, claimed map[string]string) error {
+	path, entries := f.Path, f.Entries
+	var ids []string
+	for _, e := range entries {
+		if e.Type != models.EntryTypeIgnore && e.ID != "" {
+			ids = append(ids, e.ID)
 		}
+	}
+	indexed, err := s.DB.GetEntriesByIDs(ids)
+	if err != nil {
+		return fmt.Errorf("failed to look up IDs for %s: %w", path, err)
+	}
+	dups, err := s.newDuplicates(path, entries, indexed, claimed)
+	if err != nil {
+		return err
+	}
 
-		if dirty {
-			var sb strings.Builder
-			for _, e := range entries {
-				if e.Type == models.EntryTypeIgnore {
-					sb.WriteString(e.RawContent)
-				} else {
-					sb.WriteString(e.RawString())
-				}
-				sb.WriteString("\n")
-			}
-			if err := os.WriteFile(path, []byte(sb.String()), 0644); err != nil {
-				return fmt.Errorf("failed to write back IDs to %s: %w", path, err)
+	dirty, repairedIDs := false, false
+	for i := range entries {
+		if entries[i].Type == models.EntryTypeIgnore {
+			continue
+		}
+		if entries[i].ID == "" {
+			entries[i].ID = id.New()
+			dirty, repairedIDs = true, true
+		} else if first, ok := dups[&entries[i]]; ok {
+			old := entries[i].ID
+			entries[i].ID = id.New()
+			dirty, repairedIDs = true, true
+			fmt.Fprintf(s.Log, "Duplicate ID #%s at %s:%d (first at %s); assigned #%s\n",
+				old, path, entries[i].LineNumber, first, entries[i].ID)
+		}
+		claimed[entries[i].ID] = fmt.Sprintf("%s:%d", path, entries[i].LineNumber)
+	}
+	if stampEdits(path, entries, indexed) {
+		dirty = true
+	}
+
+	if dirty {
+		var sb strings.Builder
+		for _, e := range entries {
+			if e.Type == models.EntryTypeIgnore {
+				sb.WriteString(e.RawContent)
+			} else {
+				sb.WriteString(e.RawString())
 			}
-			fmt.Printf("Auto-repaired IDs in: %s\n", path)
+			sb.WriteString("\n")
+		}
+		if err := os.WriteFile(path, []byte(sb.String()), 0644); err != nil {
+			return fmt.Errorf("failed to write back IDs to %s: %w", path, err)
 		}
+		f.Hash = fileHash([]byte(sb.String()))
+		if repairedIDs {
+			fmt.Fprintf(s.Log, "Auto-repaired IDs in: %s\n", path)
+		}
+	}
+	return nil
+}
 
-		if err := s.DB.SyncEntries(path, entries); err != nil {
-			return fmt.Errorf("failed to sync entries for %s: %w", path, err)
+// stampEdits timestamps tasks whose status was changed in an editor since
+// the last sync. It reports whether any were.
+func stampEdits(path string, entries []models.Entry, indexed map[string]models.Entry) bool {
+	stamped := false
+	for i := range entries {
+		e := &entries[i]
+		old, ok := indexed[e.ID]
+		if e.Type != models.EntryTypeTask || !ok || old.FilePath != path {
+			continue
+		}
+		if old.Status != e.Status && old.UpdatedAt.Equal(e.UpdatedAt) {
+			e.SetStatus(e.Status)
+			stamped = true
 		}
 	}
+	return stamped
+}
 
-	return nil
+// newDuplicates maps each entry whose ID is already taken to where the
+// first occurrence is. A line moved from another file keeps its ID.
+func (s *Syncer) newDuplicates(path string, entries []models.Entry, indexed map[string]models.Entry, claimed map[string]string) (map[*models.Entry]string, error) {
+	dups := make(map[*models.Entry]string)
+	seen := make(map[string]int)
+	onDisk := make(map[string]map[string]int)
+	var stale []string
+	for i := range entries {
+		e := &entries[i]
+		if e.Type == models.EntryTypeIgnore || e.ID == "" {
+			continue
+		}
+		if line, ok := seen[e.ID]; ok {
+			dups[e] = fmt.Sprintf("line %d", line)
+			continue
+		}
+		seen[e.ID] = e.LineNumber
+		if first, ok := claimed[e.ID]; ok {
+			dups[e] = first
+			continue
+		}
+
+		found, ok := indexed[e.ID]
+		other := found.FilePath
+		if !ok || other == path {
+			continue
+		}
+		if onDisk[other] == nil {
+			onDisk[other] = fileIDs(other)
+		}
+		if line, ok := onDisk[other][e.ID]; ok {
+			dups[e] = fmt.Sprintf("%s:%d", other, line)
+		} else {
+			stale = append(stale, e.ID)
+		}
+	}
+
+	if err := s.DB.RemoveEntries(stale); err != nil {
+		return nil, fmt.Errorf("failed to drop moved entries: %w", err)
+	}
+	return dups, nil
+}
+
+// fileIDs maps the entry IDs in a file to their line numbers. A file that
+// can't be read has none.
+func fileIDs(path string) map[string]int {
+	ids := make(map[string]int)
+	entries, _ := parser.Parse(path)
+	for _, e := range entries {
+		if _, ok := ids[e.ID]; !ok && e.ID != "" {
+			ids[e.ID] = e.LineNumber
+		}
+	}
+	return ids
 }
@@ -1,11 +1,16 @@
 package sync
 
 import (
+	"bytes"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/samakintunde/bujo/internal/models"
 	"github.com/samakintunde/bujo/internal/storage"
 )
 
@@ -192,3 +197,355 @@ func TestSync_PreservesExistingIDs(t *testing.T) {
 		t.Errorf("entry ID = %q, want %q", entries[0].ID, "existing123")
 	}
 }
+
+func TestSync_KeepsTimestamps(t *testing.T) {
+	dir, syncer := setupSyncer(t)
+
+	mdPath := filepath.Join(dir, "2024-01-15.md")
+	content := `- [x] Timed <!-- {"id":"t1","ct":"2024-01-14T20:00:00Z","ut":"2024-01-15T10:00:00Z","done":"2024-01-15T10:00:00Z"} -->
+- [ ] Untimed <!-- {"id":"t2"} -->
+`
+	if err := os.WriteFile(mdPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fileDate := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	for range 2 {
+		if err := syncer.SyncFile(mdPath); err != nil {
+			t.Fatalf("SyncFile() error: %v", err)
+		}
+		entries, err := syncer.DB.GetEntriesByFile(mdPath)
+		if err != nil {
+			t.Fatalf("GetEntriesByFile() error: %v", err)
+		}
+
+		timed, untimed := entries[0], entries[1]
+		if !timed.Date.Equal(fileDate) || !untimed.Date.Equal(fileDate) {
+			t.Errorf("Date = %v, %v, want the file's date", timed.Date, untimed.Date)
+		}
+		if !timed.CreatedAt.Equal(time.Date(2024, 1, 14, 20, 0, 0, 0, time.UTC)) {
+			t.Errorf("CreatedAt = %v, want the metadata's", timed.CreatedAt)
+		}
+		if want := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC); !timed.UpdatedAt.Equal(want) || !timed.CompletedAt.Equal(want) {
+			t.Errorf("UpdatedAt, CompletedAt = %v, %v, want %v", timed.UpdatedAt, timed.CompletedAt, want)
+		}
+		if !untimed.CreatedAt.Equal(fileDate) || !untimed.UpdatedAt.Equal(fileDate) || !untimed.CompletedAt.IsZero() {
+			t.Errorf("untimed entry = %v, %v, %v, want created and updated on the file's date", untimed.CreatedAt, untimed.UpdatedAt, untimed.CompletedAt)
+		}
+	}
+}
+
+func TestSync_DatesLogsByLayout(t *testing.T) {
+	dir, syncer := setupSyncer(t)
+	if err := storage.ConfigureLayout("YYYY", "DD.MM"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = storage.ConfigureLayout("YYYY/MM", "YYYY-MM-DD") })
+
+	mdPath := filepath.Join(dir, "2024", "15.01.md")
+	if err := os.MkdirAll(filepath.Dir(mdPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(mdPath, []byte("- [ ] Task <!-- {\"id\":\"t1\"} -->\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := syncer.Sync(); err != nil {
+		t.Fatalf("Sync() error: %v", err)
+	}
+
+	entries, err := syncer.DB.GetEntriesByFile(mdPath)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("GetEntriesByFile() = %v, %v, want the task", entries, err)
+	}
+	if want := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC); !entries[0].Date.Equal(want) {
+		t.Errorf("Date = %v, want %v from the path", entries[0].Date, want)
+	}
+}
+
+func TestSync_StampsTaskTickedByHand(t *testing.T) {
+	dir, syncer := setupSyncer(t)
+	syncer.Log = io.Discard
+
+	mdPath := filepath.Join(dir, "2024-01-15.md")
+	open := `- [ ] Tick me <!-- {"id":"t1","ct":"2024-01-15T09:00:00Z","ut":"2024-01-15T09:00:00Z"} -->` + "\n"
+	if err := os.WriteFile(mdPath, []byte(open), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := syncer.SyncFile(mdPath); err != nil {
+		t.Fatalf("SyncFile() error: %v", err)
+	}
+
+	if err := os.WriteFile(mdPath, []byte(strings.Replace(open, "[ ]", "[x]", 1)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := syncer.SyncFile(mdPath); err != nil {
+		t.Fatalf("SyncFile() error: %v", err)
+	}
+
+	entries, err := syncer.DB.GetEntriesByFile(mdPath)
+	if err != nil {
+		t.Fatalf("GetEntriesByFile() error: %v", err)
+	}
+	done := entries[0]
+	if done.CompletedAt.IsZero() || !done.UpdatedAt.Equal(done.CompletedAt) {
+		t.Errorf("UpdatedAt, CompletedAt = %v, %v, want both stamped", done.UpdatedAt, done.CompletedAt)
+	}
+	content, _ := os.ReadFile(mdPath)
+	if !strings.Contains(string(content), `"done":"`) {
+		t.Errorf("completion time not written back: %s", content)
+	}
+}
+
+func TestSync_RepairsDuplicateIDs(t *testing.T) {
+	dir, syncer := setupSyncer(t)
+	var log bytes.Buffer
+	syncer.Log = &log
+
+	first := filepath.Join(dir, "2024-01-15.md")
+	second := filepath.Join(dir, "2024-01-16.md")
+	if err := os.WriteFile(first, []byte(`- [ ] Original <!-- {"id":"AAA"} -->
+- [ ] Pasted twice <!-- {"id":"AAA"} -->
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(second, []byte(`- [ ] Copied <!-- {"id":"AAA","mig":1} -->
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := syncer.Sync(); err != nil {
+		t.Fatalf("Sync() error: %v", err)
+	}
+
+	original, err := syncer.DB.GetEntryByID("AAA")
+	if err != nil || original.FilePath != first || original.LineNumber != 1 {
+		t.Errorf("AAA = %s:%d (%v), want the first occurrence", original.FilePath, original.LineNumber, err)
+	}
+	ids := make(map[string]bool)
+	for _, path := range []string{first, second} {
+		entries, err := syncer.DB.GetEntriesByFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range entries {
+			if ids[e.ID] {
+				t.Errorf("ID %s indexed twice", e.ID)
+			}
+			ids[e.ID] = true
+		}
+	}
+	if len(ids) != 3 {
+		t.Errorf("indexed %d distinct IDs, want 3", len(ids))
+	}
+
+	content, _ := os.ReadFile(second)
+	if strings.Contains(string(content), `"id":"AAA"`) || !strings.Contains(string(content), `"mig":1`) {
+		t.Errorf("copy not rewritten with a fresh ID and its metadata: %s", content)
+	}
+	if got := strings.Count(log.String(), "Duplicate ID #AAA"); got != 2 {
+		t.Errorf("logged %d repairs, want 2:\n%s", got, log.String())
+	}
+}
+
+func TestSync_MovedLineKeepsID(t *testing.T) {
+	dir, syncer := setupSyncer(t)
+	syncer.Log = io.Discard
+
+	from := filepath.Join(dir, "2024-01-16.md")
+	to := filepath.Join(dir, "2024-01-15.md")
+	line := `- [ ] Moving <!-- {"id":"AAA"} -->` + "\n"
+	if err := os.WriteFile(from, []byte(line), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := syncer.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Move the line to an earlier day, which is synced before the old one.
+	if err := os.WriteFile(to, []byte(line), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(from, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := syncer.SyncFile(to); err != nil {
+		t.Fatalf("SyncFile() error: %v", err)
+	}
+
+	e, err := syncer.DB.GetEntryByID("AAA")
+	if err != nil || e.FilePath != to {
+		t.Errorf("AAA indexed in %s (%v), want %s", e.FilePath, err, to)
+	}
+	if content, _ := os.ReadFile(to); string(content) != line {
+		t.Errorf("moved line rewritten: %s", content)
+	}
+}
+
+func TestSync_IndexesAcrossBatches(t *testing.T) {
+	dir, syncer := setupSyncer(t)
+	root := filepath.Join(dir, "journal")
+	syncer.Root = root
+	days := syncBatchSize + 10
+	writeJournal(t, root, days)
+
+	if err := syncer.Sync(); err != nil {
+		t.Fatalf("Sync() error: %v", err)
+	}
+	paths, err := syncer.DB.GetIndexedPaths()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != days {
+		t.Errorf("indexed %d files, want %d", len(paths), days)
+	}
+	day := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, days-1)
+	last := filepath.Join(root, day.Format("2006"), day.Format("01"), day.Format(time.DateOnly)+".md")
+	entries, err := syncer.DB.GetEntriesByFile(last)
+	if err != nil || len(entries) != 14 {
+		t.Errorf("%s: %d entries (%v), want 14", last, len(entries), err)
+	}
+}
+
+func TestSync_DropsRemovedFiles(t *testing.T) {
+	dir, syncer := setupSyncer(t)
+	kept := filepath.Join(dir, "2024-01-15.md")
+	removed := filepath.Join(dir, "2024-01-16.md")
+	for _, path := range []string{kept, removed} {
+		if err := os.WriteFile(path, []byte("- [ ] Task\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := syncer.Sync(); err != nil {
+		t.Fatalf("Sync() error: %v", err)
+	}
+
+	if err := os.Remove(removed); err != nil {
+		t.Fatal(err)
+	}
+	// A link to nothing is walked but can't be read, like a file deleted
+	// mid-sync.
+	if err := os.Symlink(filepath.Join(dir, "gone.md"), filepath.Join(dir, "2024-01-17.md")); err != nil {
+		t.Fatal(err)
+	}
+	if err := syncer.Sync(); err != nil {
+		t.Fatalf("Sync() error: %v", err)
+	}
+
+	paths, err := syncer.DB.GetIndexedPaths()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 1 || paths[0] != kept {
+		t.Errorf("indexed paths = %v, want only %s", paths, kept)
+	}
+}
+
+func TestSyncFile_SkipsUnchanged(t *testing.T) {
+	dir, syncer := setupSyncer(t)
+	path := filepath.Join(dir, "2024-01-15.md")
+	task := models.NewEntry(models.EntryTypeTask, "Write report")
+	if err := os.WriteFile(path, []byte(task.RawString()+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := syncer.SyncFile(path); err != nil {
+		t.Fatalf("SyncFile() error: %v", err)
+	}
+	synced, err := syncer.DB.GetFileLastSync(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := syncer.SyncFile(path); err != nil {
+		t.Fatalf("SyncFile() error: %v", err)
+	}
+	if again, _ := syncer.DB.GetFileLastSync(path); !again.Equal(synced) {
+		t.Errorf("SyncFile() re-indexed an unchanged file (last sync %v, was %v)", again, synced)
+	}
+
+	// An edit within the filesystem's mtime resolution still shows up.
+	task.Content = "Write the report"
+	if err := os.WriteFile(path, []byte(task.RawString()+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	old := synced.Add(-time.Second)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+	if err := syncer.SyncFile(path); err != nil {
+		t.Fatalf("SyncFile() error: %v", err)
+	}
+	entries, err := syncer.DB.GetEntriesByFile(path)
+	if err != nil || len(entries) != 1 || entries[0].Content != "Write the report" {
+		t.Errorf("entries = %+v (%v), want the edited task", entries, err)
+	}
+}
+
+// writeJournal generates a journal of daily logs going back the given number
+// of days, each with a mix of tasks, events and notes that already have IDs.
+func writeJournal(tb testing.TB, root string, days int) {
+	tb.Helper()
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for d := 0; d < days; d++ {
+		day := start.AddDate(0, 0, d)
+		path := filepath.Join(root, day.Format("2006"), day.Format("01"), day.Format(time.DateOnly)+".md")
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			tb.Fatal(err)
+		}
+		var b strings.Builder
+		for i := 0; i < 12; i++ {
+			e := models.NewEntry(models.EntryTypeTask, fmt.Sprintf("Task %d #work due:%s", i, day.AddDate(0, 0, 3).Format(time.DateOnly)))
+			if i%3 == 0 {
+				e.Status = models.EntryStatusCompleted
+			}
+			b.WriteString(e.RawString() + "\n")
+		}
+		b.WriteString(models.NewEntry(models.EntryTypeEvent, "Standup 9:30-9:45am").RawString() + "\n")
+		b.WriteString(models.NewEntry(models.EntryTypeNote, "Notes from the day").RawString() + "\n")
+		if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+			tb.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkSyncFull indexes three years of daily logs from scratch.
+func BenchmarkSyncFull(b *testing.B) {
+	dir := b.TempDir()
+	db, err := storage.NewDBStore(filepath.Join(dir, "db"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer db.Close()
+	root := filepath.Join(dir, "journal")
+	writeJournal(b, root, 3*365)
+	syncer := NewSyncer(root, db)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := syncer.Reindex(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkSyncUnchanged is the startup cost when nothing changed.
+func BenchmarkSyncUnchanged(b *testing.B) {
+	dir := b.TempDir()
+	db, err := storage.NewDBStore(filepath.Join(dir, "db"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer db.Close()
+	root := filepath.Join(dir, "journal")
+	writeJournal(b, root, 3*365)
+	syncer := NewSyncer(root, db)
+	if err := syncer.Sync(); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := syncer.Sync(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
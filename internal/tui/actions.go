@@ -1,12 +1,15 @@
 package tui
 
 import (
+	"fmt"
 	"path/filepath"
 	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/samakintunde/bujo/internal/clock"
 	"github.com/samakintunde/bujo/internal/models"
+	"github.com/samakintunde/bujo/internal/storage"
 )
 
 func (a *App) cycleEntryStatus() tea.Cmd {
@@ -20,15 +23,8 @@ func (a *App) cycleEntryStatus() tea.Cmd {
 		return nil
 	}
 
-	var newStatus models.EntryStatus
-	switch entry.Status {
-	case models.EntryStatusOpen:
-		newStatus = models.EntryStatusCompleted
-	case models.EntryStatusCompleted:
-		newStatus = models.EntryStatusCancelled
-	case models.EntryStatusCancelled:
-		newStatus = models.EntryStatusOpen
-	default:
+	newStatus, ok := models.NextStatus(entry.Status)
+	if !ok {
 		return nil
 	}
 
@@ -40,24 +36,32 @@ func (a *App) cycleEntryStatus() tea.Cmd {
 
 func (a *App) addEntry(text string) tea.Cmd {
 	return func() tea.Msg {
-		entryType := models.EntryTypeTask
-		content := text
-
-		if strings.HasPrefix(text, "!") {
-			entryType = models.EntryTypeEvent
-			content = strings.TrimPrefix(text, "!")
-			content = strings.TrimSpace(content)
-		} else if strings.HasPrefix(text, "-") {
-			entryType = models.EntryTypeNote
-			content = strings.TrimPrefix(text, "-")
-			content = strings.TrimSpace(content)
-		}
-
+		entryType, content := entryPrefix(text, models.EntryType(a.cfg.Journal.GetDefaultEntryType()))
 		_, err := a.service.AddEntry(content, entryType, a.currentDate)
 		return entryAddedMsg{err: err}
 	}
 }
 
+// typePrefixes pick an entry's type in the add prompt. Each needs a space
+// after it so "!!" and "!!!" stay priority markers on a task.
+var typePrefixes = []struct {
+	prefix    string
+	entryType models.EntryType
+}{
+	{". ", models.EntryTypeTask},
+	{"! ", models.EntryTypeEvent},
+	{"- ", models.EntryTypeNote},
+}
+
+func entryPrefix(text string, fallback models.EntryType) (models.EntryType, string) {
+	for _, p := range typePrefixes {
+		if content, ok := strings.CutPrefix(text, p.prefix); ok {
+			return p.entryType, strings.TrimSpace(content)
+		}
+	}
+	return fallback, text
+}
+
 func (a *App) loadEntries(targetID ...string) tea.Cmd {
 	return func() tea.Msg {
 		tid := ""
@@ -70,10 +74,25 @@ func (a *App) loadEntries(targetID ...string) tea.Cmd {
 	}
 }
 
-func (a *App) loadReviewTasks(daysBack int) tea.Cmd {
+func (a *App) loadReviewTasks(filter storage.StaleFilter) tea.Cmd {
 	return func() tea.Msg {
-		tasks, err := a.service.GetStaleTasks(daysBack)
-		return reviewTasksLoadedMsg{tasks: tasks, err: err}
+		tasks, err := a.service.GetStaleTasksMatching(filter)
+		if err != nil {
+			return reviewTasksLoadedMsg{err: err}
+		}
+
+		chains := make(map[string][]models.Entry)
+		for _, task := range tasks {
+			if !a.isChronic(task) {
+				continue
+			}
+			chain, err := a.service.GetMigrationChain(task.ID)
+			if err != nil {
+				return reviewTasksLoadedMsg{err: err}
+			}
+			chains[task.ID] = chain
+		}
+		return reviewTasksLoadedMsg{tasks: tasks, chains: chains}
 	}
 }
 
@@ -84,12 +103,11 @@ func (a *App) migrateCurrentReviewTask() tea.Cmd {
 
 	task := a.reviewTasks[a.reviewCursor]
 	return func() tea.Msg {
-		_, err := a.service.MigrateTask(task)
+		newEntry, err := a.service.MigrateTask(task)
 		if err != nil {
 			return entryUpdatedMsg{err: err}
 		}
-		a.reviewSummary.Migrated++
-		return reviewActionCompleteMsg{}
+		return reviewDecided(task, models.ReviewDecisionMigrated, a.extractDateFromPath(newEntry.FilePath), newEntry.ID)
 	}
 }
 
@@ -104,8 +122,7 @@ func (a *App) completeCurrentReviewTask() tea.Cmd {
 		if err != nil {
 			return entryUpdatedMsg{err: err}
 		}
-		a.reviewSummary.Completed++
-		return reviewActionCompleteMsg{}
+		return reviewDecided(task, models.ReviewDecisionCompleted, "", "")
 	}
 }
 
@@ -120,8 +137,7 @@ func (a *App) cancelCurrentReviewTask() tea.Cmd {
 		if err != nil {
 			return entryUpdatedMsg{err: err}
 		}
-		a.reviewSummary.Cancelled++
-		return reviewActionCompleteMsg{}
+		return reviewDecided(task, models.ReviewDecisionCancelled, "", "")
 	}
 }
 
@@ -137,16 +153,123 @@ func (a *App) scheduleCurrentReviewTask(targetDate string) tea.Cmd {
 			return entryUpdatedMsg{err: err}
 		}
 
-		_, err = a.service.ScheduleTask(task, parsed)
+		newEntry, err := a.service.ScheduleTask(task, parsed)
 		if err != nil {
 			return entryUpdatedMsg{err: err}
 		}
-		a.reviewSummary.Scheduled++
-		return reviewActionCompleteMsg{}
+		return reviewDecided(task, models.ReviewDecisionScheduled, targetDate, newEntry.ID)
+	}
+}
+
+func (a *App) moveCurrentReviewTaskToSomeday() tea.Cmd {
+	if len(a.reviewTasks) == 0 || a.reviewCursor >= len(a.reviewTasks) {
+		return nil
+	}
+
+	task := a.reviewTasks[a.reviewCursor]
+	return func() tea.Msg {
+		newEntry, err := a.service.MoveToCollection(task, somedayCollection)
+		if err != nil {
+			return entryUpdatedMsg{err: err}
+		}
+		return reviewDecided(task, models.ReviewDecisionSomeday, somedayCollection, newEntry.ID)
+	}
+}
+
+// reviewStep remembers a decision made on a review task so it can be undone:
+// the task as it was before the decision and the ID of any copy it created.
+type reviewStep struct {
+	index     int
+	original  models.Entry
+	createdID string
+	decision  models.ReviewDecision
+}
+
+type reviewActionCompleteMsg struct {
+	step reviewStep
+}
+
+type reviewUndoneMsg struct {
+	step reviewStep
+	err  error
+}
+
+func reviewDecided(task models.Entry, decision models.ReviewDecisionType, target, createdID string) reviewActionCompleteMsg {
+	return reviewActionCompleteMsg{step: reviewStep{
+		original:  task,
+		createdID: createdID,
+		decision: models.ReviewDecision{
+			EntryID:   task.ID,
+			Content:   task.Content,
+			Decision:  decision,
+			Target:    target,
+			DecidedAt: time.Now(),
+		},
+	}}
+}
+
+func (a *App) undoReviewStep(step reviewStep) tea.Cmd {
+	return func() tea.Msg {
+		if step.decision.Decision == models.ReviewDecisionSkipped {
+			return reviewUndoneMsg{step: step}
+		}
+		err := a.service.RevertTask(step.original, step.createdID)
+		return reviewUndoneMsg{step: step, err: err}
+	}
+}
+
+type reviewSavedMsg struct {
+	err error
+}
+
+// finishReviewSession persists the current review session, if any
+// decisions were made, and clears it.
+func (a *App) finishReviewSession() tea.Cmd {
+	session := a.reviewSession
+	a.reviewSession = nil
+	if session == nil || len(session.Decisions) == 0 {
+		return nil
+	}
+
+	logPath := a.cfg.GetReviewLogPath()
+	return func() tea.Msg {
+		session.FinishedAt = time.Now()
+		return reviewSavedMsg{err: a.service.RecordReview(*session, logPath)}
+	}
+}
+
+// parseReviewFilter reads filter text such as "#work type:migrated".
+func parseReviewFilter(text string) (storage.StaleFilter, error) {
+	var filter storage.StaleFilter
+	for _, field := range strings.Fields(text) {
+		switch {
+		case strings.HasPrefix(field, "#") && len(field) > 1:
+			filter.Tags = append(filter.Tags, strings.TrimPrefix(field, "#"))
+		case strings.HasPrefix(field, "type:"):
+			kind := storage.StaleKind(strings.TrimPrefix(field, "type:"))
+			switch kind {
+			case storage.StaleKindNew, storage.StaleKindMigrated, storage.StaleKindScheduled:
+				filter.Kind = kind
+			default:
+				return storage.StaleFilter{}, fmt.Errorf("unknown type %q (use new, migrated or scheduled)", kind)
+			}
+		default:
+			return storage.StaleFilter{}, fmt.Errorf("unknown filter %q (use #tag or type:...)", field)
+		}
 	}
+	return filter, nil
 }
 
-type reviewActionCompleteMsg struct{}
+func formatReviewFilter(filter storage.StaleFilter) string {
+	var parts []string
+	for _, tag := range filter.Tags {
+		parts = append(parts, "#"+tag)
+	}
+	if filter.Kind != storage.StaleKindAny {
+		parts = append(parts, "type:"+string(filter.Kind))
+	}
+	return strings.Join(parts, " ")
+}
 
 func (a *App) migrateEntryFromDailyView(entry models.Entry) tea.Cmd {
 	return func() tea.Msg {
@@ -167,6 +290,88 @@ func (a *App) scheduleEntryFromDailyView(entry models.Entry, targetDate string)
 	}
 }
 
+// updateSelectionStatus sets the status of every selected task and leaves
+// visual mode.
+func (a *App) updateSelectionStatus(status models.EntryStatus) tea.Cmd {
+	var tasks []models.Entry
+	for _, entry := range a.selectedEntries() {
+		if entry.Type == models.EntryTypeTask && entry.Status != status &&
+			entry.Status != models.EntryStatusMigrated && entry.Status != models.EntryStatusScheduled {
+			tasks = append(tasks, entry)
+		}
+	}
+	a.exitVisual()
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	return func() tea.Msg {
+		err := a.service.UpdateEntriesStatus(tasks, status)
+		return entryUpdatedMsg{err: err}
+	}
+}
+
+func (a *App) migrateSelection() tea.Cmd {
+	tasks := a.selectedOpenTasks()
+	a.exitVisual()
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	return func() tea.Msg {
+		_, err := a.service.MigrateTasks(tasks)
+		return entryUpdatedMsg{err: err}
+	}
+}
+
+func (a *App) scheduleSelection(targetDate time.Time) tea.Cmd {
+	tasks := a.selectedOpenTasks()
+	a.exitVisual()
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	return func() tea.Msg {
+		_, err := a.service.ScheduleTasks(tasks, targetDate)
+		return entryUpdatedMsg{err: err}
+	}
+}
+
+// fileNeighbour returns the line of the entry above (dir -1) or below (dir
+// 1) entry in its file, or 0 if there is none.
+func (a *App) fileNeighbour(entry models.Entry, dir int) int {
+	line := 0
+	for _, e := range a.entries {
+		if e.FilePath != entry.FilePath {
+			continue
+		}
+		if dir < 0 && e.LineNumber < entry.LineNumber && e.LineNumber > line {
+			line = e.LineNumber
+		}
+		if dir > 0 && e.LineNumber > entry.LineNumber && (line == 0 || e.LineNumber < line) {
+			line = e.LineNumber
+		}
+	}
+	return line
+}
+
+// moveEntryWithinDay moves entry to lineNum in the current day, keeping the
+// cursor on it.
+func (a *App) moveEntryWithinDay(entry models.Entry, lineNum int) tea.Cmd {
+	date := a.currentDate
+	return func() tea.Msg {
+		_, err := a.service.MoveEntry(entry, date, lineNum)
+		return entryMovedMsg{targetID: entry.ID, err: err}
+	}
+}
+
+func (a *App) moveEntryToDate(entry models.Entry, date time.Time) tea.Cmd {
+	return func() tea.Msg {
+		_, err := a.service.MoveEntry(entry, date, 0)
+		return entryMovedMsg{err: err}
+	}
+}
+
 func (a *App) loadMigrationChain(entryID string, direction int) tea.Cmd {
 	return func() tea.Msg {
 		chain, err := a.service.GetMigrationChain(entryID)
@@ -195,24 +400,68 @@ func (a *App) loadMigrationChain(entryID string, direction int) tea.Cmd {
 	}
 }
 
+func tickClock() tea.Cmd {
+	return tea.Tick(time.Minute, func(time.Time) tea.Msg {
+		return clockTickMsg{}
+	})
+}
+
+// waitForGitError delivers the next commit failure, or nil once commits
+// succeed again. Update calls it again after each one.
+func (a *App) waitForGitError() tea.Cmd {
+	return func() tea.Msg {
+		return gitErrorMsg{err: <-a.service.GitErrors()}
+	}
+}
+
+func (a *App) loadHistory(entryID string) tea.Cmd {
+	return func() tea.Msg {
+		log, err := a.service.EntryHistory(entryID)
+		return historyLoadedMsg{log: log, err: err}
+	}
+}
+
+func (a *App) loadAgenda() tea.Cmd {
+	load := a.loadAgendas
+	return func() tea.Msg {
+		agendas, err := load()
+		return agendasLoadedMsg{agendas: agendas, err: err}
+	}
+}
+
+func (a *App) loadStats() tea.Cmd {
+	return func() tea.Msg {
+		today := clock.Today()
+		from := today.AddDate(0, 0, -7*max(maxStatsWeeks, heatmapWeeks))
+
+		activity, err := a.service.GetDailyActivity(from, today)
+		if err != nil {
+			return statsLoadedMsg{err: err}
+		}
+		top, err := a.service.GetMostRescheduled(statsTopTasks)
+		return statsLoadedMsg{activity: activity, top: top, err: err}
+	}
+}
+
 func (a *App) checkFirstOpenToday() tea.Cmd {
 	return func() tea.Msg {
 		lastOpened, _ := a.service.GetLastOpenedAt()
-		today := time.Now()
-
-		isFirstOpen := lastOpened.Year() != today.Year() ||
-			lastOpened.Month() != today.Month() ||
-			lastOpened.Day() != today.Day()
+		isFirstOpen := !clock.IsToday(lastOpened)
 
 		staleCount, _ := a.service.CountStaleTasks(0)
 
-		_ = a.service.SetLastOpenedAt(today)
+		_ = a.service.SetLastOpenedAt(clock.Now())
 
 		return initCheckMsg{isFirstOpenToday: isFirstOpen, staleTaskCount: staleCount}
 	}
 }
 
-func extractDateFromPath(filePath string) string {
+// extractDateFromPath returns the date of the daily log at filePath, or the
+// file's name for other files such as collections.
+func (a *App) extractDateFromPath(filePath string) string {
+	if date, ok := storage.DayFromPath(a.fs.Root, filePath); ok {
+		return date.Format(time.DateOnly)
+	}
 	base := filepath.Base(filePath)
 	ext := filepath.Ext(base)
 	return strings.TrimSuffix(base, ext)
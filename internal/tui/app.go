@@ -7,6 +7,9 @@ import (
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/samakintunde/bujo/internal/clock"
+	"github.com/samakintunde/bujo/internal/config"
+	"github.com/samakintunde/bujo/internal/git"
 	"github.com/samakintunde/bujo/internal/models"
 	"github.com/samakintunde/bujo/internal/service"
 	"github.com/samakintunde/bujo/internal/storage"
@@ -22,26 +25,49 @@ const (
 	StateReviewScope
 	StateReviewTask
 	StateReviewPrompt
+	StateStats
+	StateVisual
+	StateHistory
+	StateJournalPicker
+	StateAgenda
 )
 
+const (
+	defaultStatsWeeks = 4
+	maxStatsWeeks     = 12
+	heatmapWeeks      = 12
+	statsTopTasks     = 5
+)
+
+// somedayCollection receives chronic tasks that aren't worth doing now.
+const somedayCollection = "someday"
+
 type App struct {
 	state       AppState
 	currentDate time.Time
 	entries     []models.Entry
 	cursor      int
 
-	keys       KeyMap
-	reviewKeys ReviewKeyMap
-	scopeKeys  ScopeKeyMap
+	keys        KeyMap
+	reviewKeys  ReviewKeyMap
+	scopeKeys   ScopeKeyMap
+	statsKeys   StatsKeyMap
+	visualKeys  VisualKeyMap
+	historyKeys HistoryKeyMap
+	journalKeys JournalKeyMap
 
 	input     textinput.Model
 	inputErr  string
 	inputMode string
 
 	reviewTasks   []models.Entry
+	reviewChains  map[string][]models.Entry
 	reviewCursor  int
 	reviewScope   int
+	reviewFilter  storage.StaleFilter
 	reviewSummary ReviewSummary
+	reviewSession *models.ReviewSession
+	reviewHistory []reviewStep
 
 	staleTaskCount int
 	message        string
@@ -49,6 +75,33 @@ type App struct {
 	migrationChain      []models.Entry
 	migrationChainIndex int
 
+	statsActivity []storage.DayActivity
+	statsTop      []storage.RescheduledTask
+	statsCursor   int
+	statsWeeks    int
+
+	// visualAnchor is where the current range selection started, or -1 when
+	// only individually toggled entries are selected.
+	visualAnchor int
+	visualMarked map[string]bool
+
+	historyEntry  models.Entry
+	historyLog    []git.LogEntry
+	historyScroll int
+
+	// journals are the configured workspaces; loadAgendas reads all of them.
+	// switchTo is the journal to reopen the app in once it quits.
+	journals    []string
+	loadAgendas func() ([]*service.Agenda, error)
+	agendas     []*service.Agenda
+	switchTo    string
+
+	// gitWarning is the latest commit failure, until a commit succeeds again.
+	gitWarning error
+	// repairs receives the syncer's reports of IDs it fixed in the files.
+	repairs *repairLog
+
+	cfg     *config.Config
 	db      *storage.DBStore
 	fs      *storage.FSStore
 	syncer  *sync.Syncer
@@ -64,35 +117,67 @@ type ReviewSummary struct {
 	Scheduled int
 	Completed int
 	Cancelled int
+	Someday   int
 	Skipped   int
 }
 
-func NewApp(db *storage.DBStore, fs *storage.FSStore, syncer *sync.Syncer) *App {
+// NewApp creates the TUI. It takes over syncer's log to show repairs in the
+// status bar.
+func NewApp(cfg *config.Config, db *storage.DBStore, fs *storage.FSStore, syncer *sync.Syncer) *App {
 	ti := textinput.New()
 	ti.Placeholder = "Enter text..."
 	ti.CharLimit = 256
 	ti.Width = 40
 
 	svc := service.NewJournalService(fs, db, syncer)
+	ApplyTheme(cfg.Theme)
+	repairs := &repairLog{}
+	syncer.Log = repairs
 
 	return &App{
 		state:       StateDailyView,
-		currentDate: time.Now(),
+		currentDate: clock.Today(),
 		entries:     []models.Entry{},
 		cursor:      0,
 		keys:        DefaultKeyMap,
 		reviewKeys:  DefaultReviewKeyMap,
 		scopeKeys:   DefaultScopeKeyMap,
+		statsKeys:   DefaultStatsKeyMap,
+		visualKeys:  DefaultVisualKeyMap,
+		historyKeys: DefaultHistoryKeyMap,
+		journalKeys: DefaultJournalKeyMap,
+		statsWeeks:  defaultStatsWeeks,
 		input:       ti,
+		cfg:         cfg,
 		db:          db,
 		fs:          fs,
 		syncer:      syncer,
 		service:     svc,
+		repairs:     repairs,
 		width:       80,
 		height:      24,
 	}
 }
 
+// SetCommitter makes the app commit through c, so that git never runs on
+// the UI goroutine.
+func (a *App) SetCommitter(c *service.Committer) {
+	a.service.SetCommitter(c)
+}
+
+// SetJournals lets the user switch between the named journals and see an
+// agenda of all of them, read through load.
+func (a *App) SetJournals(names []string, load func() ([]*service.Agenda, error)) {
+	a.journals = names
+	a.loadAgendas = load
+}
+
+// SwitchTo is the journal the user chose to switch to when the app quit, or
+// empty if they just quit.
+func (a *App) SwitchTo() string {
+	return a.switchTo
+}
+
 type entriesLoadedMsg struct {
 	entries  []models.Entry
 	err      error
@@ -100,14 +185,33 @@ type entriesLoadedMsg struct {
 }
 
 type reviewTasksLoadedMsg struct {
-	tasks []models.Entry
-	err   error
+	tasks  []models.Entry
+	chains map[string][]models.Entry
+	err    error
 }
 
 type entryUpdatedMsg struct {
 	err error
 }
 
+type entryMovedMsg struct {
+	targetID string
+	err      error
+}
+
+type historyLoadedMsg struct {
+	log []git.LogEntry
+	err error
+}
+
+// clockTickMsg re-renders the view so that now/next event highlighting
+// follows the clock.
+type clockTickMsg struct{}
+
+type gitErrorMsg struct {
+	err error
+}
+
 type entryAddedMsg struct {
 	err error
 }
@@ -117,6 +221,17 @@ type initCheckMsg struct {
 	staleTaskCount   int
 }
 
+type statsLoadedMsg struct {
+	activity []storage.DayActivity
+	top      []storage.RescheduledTask
+	err      error
+}
+
+type agendasLoadedMsg struct {
+	agendas []*service.Agenda
+	err     error
+}
+
 type chainLoadedMsg struct {
 	chain []models.Entry
 	index int
@@ -124,7 +239,7 @@ type chainLoadedMsg struct {
 }
 
 func (a *App) Init() tea.Cmd {
-	return tea.Batch(a.loadEntries(), a.checkFirstOpenToday())
+	return tea.Batch(a.loadEntries(), a.checkFirstOpenToday(), a.waitForGitError(), tickClock())
 }
 
 func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -156,6 +271,10 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		} else if a.cursor >= len(a.entries) {
 			a.cursor = max(0, len(a.entries)-1)
 		}
+		// Loading syncs the day, which may have repaired it.
+		if repaired := a.repairs.take(); repaired != "" {
+			a.message = repaired
+		}
 		return a, nil
 
 	case initCheckMsg:
@@ -171,7 +290,9 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			a.state = StateDailyView
 		} else {
 			a.reviewTasks = msg.tasks
+			a.reviewChains = msg.chains
 			a.reviewCursor = 0
+			a.reviewHistory = nil
 			if len(msg.tasks) == 0 {
 				a.state = StateDailyView
 			} else {
@@ -186,6 +307,32 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return a, a.loadEntries()
 
+	case entryMovedMsg:
+		if msg.err != nil {
+			a.err = msg.err
+			a.message = ""
+		}
+		a.state = StateDailyView
+		return a, a.loadEntries(msg.targetID)
+
+	case clockTickMsg:
+		return a, tickClock()
+
+	case gitErrorMsg:
+		a.gitWarning = msg.err
+		return a, a.waitForGitError()
+	case historyLoadedMsg:
+		if msg.err != nil {
+			a.state = StateDailyView
+			a.err = msg.err
+			return a, nil
+		}
+		a.historyLog = msg.log
+		if a.historyLog == nil {
+			a.historyLog = []git.LogEntry{}
+		}
+		return a, nil
+
 	case entryAddedMsg:
 		if msg.err != nil {
 			a.err = msg.err
@@ -195,8 +342,50 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return a, a.loadEntries()
 
 	case reviewActionCompleteMsg:
+		step := msg.step
+		step.index = a.reviewIndexOf(step.original.ID)
+		a.recordReviewStep(step)
 		return a.advanceReview()
 
+	case reviewUndoneMsg:
+		if msg.err != nil {
+			a.err = msg.err
+			return a, nil
+		}
+		a.removeReviewStep(msg.step)
+		a.reviewTasks[msg.step.index] = msg.step.original
+		a.reviewCursor = msg.step.index
+		a.message = fmt.Sprintf("Undid %s: %s", msg.step.decision.Decision, msg.step.decision.Content)
+		return a, nil
+
+	case reviewSavedMsg:
+		if msg.err != nil {
+			a.err = msg.err
+		}
+		return a, nil
+
+	case statsLoadedMsg:
+		if msg.err != nil {
+			a.err = msg.err
+			a.state = StateDailyView
+			return a, nil
+		}
+		a.statsActivity = msg.activity
+		a.statsTop = msg.top
+		if a.statsCursor >= len(a.statsTop) {
+			a.statsCursor = max(0, len(a.statsTop)-1)
+		}
+		return a, nil
+
+	case agendasLoadedMsg:
+		if msg.err != nil {
+			a.err = msg.err
+			a.state = StateDailyView
+			return a, nil
+		}
+		a.agendas = msg.agendas
+		return a, nil
+
 	case chainLoadedMsg:
 		if msg.err != nil {
 			a.err = msg.err
@@ -204,7 +393,7 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			a.migrationChain = msg.chain
 			a.migrationChainIndex = msg.index
 			entry := msg.chain[msg.index]
-			parsed, err := time.Parse(time.DateOnly, extractDateFromPath(entry.FilePath))
+			parsed, err := time.Parse(time.DateOnly, a.extractDateFromPath(entry.FilePath))
 			if err == nil {
 				a.currentDate = parsed
 				return a, a.loadEntries(entry.ID)
@@ -216,7 +405,7 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return a.handleKeyMsg(msg)
 	}
 
-	if a.state == StateAddEntry || a.state == StateDatePicker {
+	if a.state == StateAddEntry || a.state == StateDatePicker || a.inputMode == "review_filter" {
 		var cmd tea.Cmd
 		a.input, cmd = a.input.Update(msg)
 		return a, cmd
@@ -239,6 +428,16 @@ func (a *App) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return a.handleReviewTaskKeys(msg)
 	case StateReviewPrompt:
 		return a.handleReviewPromptKeys(msg)
+	case StateStats:
+		return a.handleStatsKeys(msg)
+	case StateVisual:
+		return a.handleVisualKeys(msg)
+	case StateHistory:
+		return a.handleHistoryKeys(msg)
+	case StateJournalPicker:
+		return a.handleJournalPickerKeys(msg)
+	case StateAgenda:
+		return a.handleAgendaKeys(msg)
 	}
 	return a, nil
 }
@@ -287,7 +486,7 @@ func (a *App) handleDailyViewKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return a, a.loadEntries()
 
 	case key.Matches(msg, a.keys.Today):
-		a.currentDate = time.Now()
+		a.currentDate = clock.Today()
 		a.clearChainState()
 		return a, a.loadEntries()
 
@@ -306,6 +505,9 @@ func (a *App) handleDailyViewKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		a.state = StateAddEntry
 		a.input.Reset()
 		a.input.Placeholder = "New entry (prefix: ! for event, - for note)"
+		if a.cfg.Journal.GetDefaultEntryType() != string(models.EntryTypeTask) {
+			a.input.Placeholder = "New " + a.cfg.Journal.GetDefaultEntryType() + " (prefix: . for task, ! for event, - for note)"
+		}
 		a.input.Focus()
 		a.inputErr = ""
 		return a, textinput.Blink
@@ -314,10 +516,28 @@ func (a *App) handleDailyViewKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		a.state = StateReviewScope
 		a.reviewSummary = ReviewSummary{}
 
+	case key.Matches(msg, a.keys.Visual):
+		if len(a.entries) > 0 {
+			a.state = StateVisual
+			a.visualAnchor = a.cursor
+			a.visualMarked = make(map[string]bool)
+			a.clearChainState()
+		}
+
+	case key.Matches(msg, a.keys.Journal):
+		if len(a.journals) > 1 {
+			a.state = StateJournalPicker
+		}
+
+	case key.Matches(msg, a.keys.Stats):
+		a.state = StateStats
+		a.statsCursor = 0
+		return a, a.loadStats()
+
 	case key.Matches(msg, a.keys.Migrate):
 		if !a.isToday() && len(a.entries) > 0 && a.cursor < len(a.entries) {
 			entry := a.entries[a.cursor]
-			if entry.Type == models.EntryTypeTask && entry.Status == models.EntryStatusOpen {
+			if entry.IsOpenTask() {
 				return a, a.migrateEntryFromDailyView(entry)
 			}
 		}
@@ -325,7 +545,7 @@ func (a *App) handleDailyViewKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case key.Matches(msg, a.keys.Schedule):
 		if len(a.entries) > 0 && a.cursor < len(a.entries) {
 			entry := a.entries[a.cursor]
-			if entry.Type == models.EntryTypeTask && entry.Status == models.EntryStatusOpen {
+			if entry.IsOpenTask() {
 				a.state = StateDatePicker
 				a.inputMode = "schedule_daily"
 				a.input.Reset()
@@ -335,6 +555,41 @@ func (a *App) handleDailyViewKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			}
 		}
 
+	case key.Matches(msg, a.keys.MoveUp):
+		if a.cursor < len(a.entries) {
+			if line := a.fileNeighbour(a.entries[a.cursor], -1); line > 0 {
+				a.clearChainState()
+				return a, a.moveEntryWithinDay(a.entries[a.cursor], line)
+			}
+		}
+
+	case key.Matches(msg, a.keys.MoveDown):
+		if a.cursor < len(a.entries) {
+			if line := a.fileNeighbour(a.entries[a.cursor], 1); line > 0 {
+				a.clearChainState()
+				return a, a.moveEntryWithinDay(a.entries[a.cursor], line)
+			}
+		}
+
+	case key.Matches(msg, a.keys.Move):
+		if len(a.entries) > 0 && a.cursor < len(a.entries) {
+			a.state = StateDatePicker
+			a.inputMode = "move_daily"
+			a.input.Reset()
+			a.input.Placeholder = "Move to YYYY-MM-DD"
+			a.input.Focus()
+			return a, textinput.Blink
+		}
+
+	case key.Matches(msg, a.keys.History):
+		if len(a.entries) > 0 && a.cursor < len(a.entries) {
+			a.state = StateHistory
+			a.historyEntry = a.entries[a.cursor]
+			a.historyLog = nil
+			a.historyScroll = 0
+			return a, a.loadHistory(a.historyEntry.ID)
+		}
+
 	case key.Matches(msg, a.keys.ChainPrev):
 		if len(a.entries) > 0 && a.cursor < len(a.entries) {
 			entry := a.entries[a.cursor]
@@ -379,6 +634,8 @@ func (a *App) handleDatePickerKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case key.Matches(msg, a.keys.Cancel):
 		if a.inputMode == "schedule" {
 			a.state = StateReviewTask
+		} else if a.inputMode == "schedule_visual" {
+			a.state = StateVisual
 		} else {
 			a.state = StateDailyView
 		}
@@ -402,6 +659,26 @@ func (a *App) handleDatePickerKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			return a, a.scheduleCurrentReviewTask(dateStr)
 		}
 
+		if a.inputMode == "schedule_visual" {
+			cmd := a.scheduleSelection(parsed)
+			a.input.Reset()
+			a.inputErr = ""
+			a.inputMode = ""
+			return a, cmd
+		}
+
+		if a.inputMode == "move_daily" {
+			a.input.Reset()
+			a.inputErr = ""
+			a.inputMode = ""
+			if a.cursor >= len(a.entries) {
+				a.state = StateDailyView
+				return a, nil
+			}
+			a.message = "Moved to " + parsed.Format(time.DateOnly)
+			return a, a.moveEntryToDate(a.entries[a.cursor], parsed)
+		}
+
 		if a.inputMode == "schedule_daily" {
 			if len(a.entries) > 0 && a.cursor < len(a.entries) {
 				entry := a.entries[a.cursor]
@@ -430,38 +707,123 @@ func (a *App) handleDatePickerKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 }
 
 func (a *App) handleReviewScopeKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if a.inputMode == "review_filter" {
+		return a.handleReviewFilterKeys(msg)
+	}
+
 	switch {
 	case key.Matches(msg, a.scopeKeys.Cancel):
 		a.state = StateDailyView
 		return a, nil
 
-	case key.Matches(msg, a.scopeKeys.Option1):
-		a.reviewScope = 1
-		return a, a.loadReviewTasks(1)
+	case key.Matches(msg, a.scopeKeys.Filter):
+		a.inputMode = "review_filter"
+		a.inputErr = ""
+		a.input.Reset()
+		a.input.Placeholder = "#tag type:new|migrated|scheduled"
+		a.input.SetValue(formatReviewFilter(a.reviewFilter))
+		a.input.Focus()
+		return a, textinput.Blink
+
+	case key.Matches(msg, a.scopeKeys.Select):
+		scopes := a.cfg.Review.GetScopes()
+		idx := int(msg.String()[0] - '1')
+		if idx < 0 || idx >= len(scopes) {
+			return a, nil
+		}
+		a.reviewScope = idx
+		a.reviewFilter.DaysBack = scopes[idx].Days
+		a.reviewSession = models.NewReviewSession(scopes[idx].Label, formatReviewFilter(a.reviewFilter))
+		return a, a.loadReviewTasks(a.reviewFilter)
+	}
 
-	case key.Matches(msg, a.scopeKeys.Option2):
-		a.reviewScope = 2
-		return a, a.loadReviewTasks(2)
+	return a, nil
+}
 
-	case key.Matches(msg, a.scopeKeys.Option3):
-		a.reviewScope = 3
-		return a, a.loadReviewTasks(7)
+func (a *App) handleReviewFilterKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, a.keys.Cancel):
+		a.inputMode = ""
+		a.inputErr = ""
+		a.input.Reset()
+		return a, nil
 
-	case key.Matches(msg, a.scopeKeys.Option4):
-		a.reviewScope = 4
-		return a, a.loadReviewTasks(0)
+	case key.Matches(msg, a.keys.Confirm):
+		filter, err := parseReviewFilter(a.input.Value())
+		if err != nil {
+			a.inputErr = err.Error()
+			return a, nil
+		}
+		a.reviewFilter = filter
+		a.inputMode = ""
+		a.inputErr = ""
+		a.input.Reset()
+		return a, nil
 	}
 
-	return a, nil
+	var cmd tea.Cmd
+	a.input, cmd = a.input.Update(msg)
+	return a, cmd
 }
 
 func (a *App) handleReviewTaskKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	a.message = ""
+
 	switch {
 	case key.Matches(msg, a.reviewKeys.Cancel):
 		a.state = StateDailyView
-		a.currentDate = time.Now()
-		return a, a.loadEntries()
+		a.currentDate = clock.Today()
+		return a, tea.Batch(a.loadEntries(), a.finishReviewSession())
+
+	case key.Matches(msg, a.reviewKeys.Previous):
+		if a.reviewCursor > 0 {
+			a.reviewCursor--
+		}
+		return a, nil
 
+	case key.Matches(msg, a.reviewKeys.Undo):
+		step, ok := a.reviewStepAt(a.reviewCursor)
+		if !ok {
+			if len(a.reviewHistory) == 0 {
+				a.message = "Nothing to undo."
+				return a, nil
+			}
+			step = a.reviewHistory[len(a.reviewHistory)-1]
+		}
+		return a, a.undoReviewStep(step)
+
+	case key.Matches(msg, a.reviewKeys.Keep):
+		if a.reviewCursor >= len(a.reviewTasks) {
+			return a, nil
+		}
+		if _, decided := a.reviewStepAt(a.reviewCursor); decided {
+			return a.advanceReview()
+		}
+		task := a.reviewTasks[a.reviewCursor]
+		if a.isChronic(task) {
+			a.message = "This task keeps getting pushed back. Decide now."
+			return a, nil
+		}
+		a.recordReviewStep(reviewStep{
+			index:    a.reviewCursor,
+			original: task,
+			decision: models.ReviewDecision{
+				EntryID:   task.ID,
+				Content:   task.Content,
+				Decision:  models.ReviewDecisionSkipped,
+				DecidedAt: time.Now(),
+			},
+		})
+		return a.advanceReview()
+	}
+
+	actions := []key.Binding{a.reviewKeys.Migrate, a.reviewKeys.Complete, a.reviewKeys.Delete, a.reviewKeys.Someday, a.reviewKeys.Schedule}
+	if _, decided := a.reviewStepAt(a.reviewCursor); decided && key.Matches(msg, actions...) {
+		a.message = "Already decided. Press u to undo it first."
+		return a, nil
+	}
+
+	switch {
 	case key.Matches(msg, a.reviewKeys.Migrate):
 		return a, a.migrateCurrentReviewTask()
 
@@ -471,9 +833,8 @@ func (a *App) handleReviewTaskKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case key.Matches(msg, a.reviewKeys.Delete):
 		return a, a.cancelCurrentReviewTask()
 
-	case key.Matches(msg, a.reviewKeys.Keep):
-		a.reviewSummary.Skipped++
-		return a.advanceReview()
+	case key.Matches(msg, a.reviewKeys.Someday):
+		return a, a.moveCurrentReviewTaskToSomeday()
 
 	case key.Matches(msg, a.reviewKeys.Schedule):
 		a.state = StateDatePicker
@@ -487,15 +848,236 @@ func (a *App) handleReviewTaskKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return a, nil
 }
 
+func (a *App) handleJournalPickerKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, a.journalKeys.Cancel):
+		a.state = StateDailyView
+
+	case key.Matches(msg, a.journalKeys.Agenda):
+		a.state = StateAgenda
+		a.agendas = nil
+		return a, a.loadAgenda()
+
+	case key.Matches(msg, a.journalKeys.Select):
+		idx := int(msg.String()[0] - '1')
+		if idx < 0 || idx >= len(a.journals) {
+			return a, nil
+		}
+		if a.journals[idx] == a.cfg.Workspace {
+			a.state = StateDailyView
+			return a, nil
+		}
+		a.switchTo = a.journals[idx]
+		return a, tea.Quit
+	}
+	return a, nil
+}
+
+// handleAgendaKeys only leaves the agenda: it is read-only.
+func (a *App) handleAgendaKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if key.Matches(msg, a.journalKeys.Cancel) {
+		a.state = StateDailyView
+	}
+	return a, nil
+}
+
+func (a *App) handleStatsKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, a.statsKeys.Cancel):
+		a.state = StateDailyView
+		return a, nil
+
+	case key.Matches(msg, a.statsKeys.Up):
+		if a.statsCursor > 0 {
+			a.statsCursor--
+		}
+
+	case key.Matches(msg, a.statsKeys.Down):
+		if a.statsCursor < len(a.statsTop)-1 {
+			a.statsCursor++
+		}
+
+	case key.Matches(msg, a.statsKeys.More):
+		if a.statsWeeks < maxStatsWeeks {
+			a.statsWeeks++
+		}
+
+	case key.Matches(msg, a.statsKeys.Fewer):
+		if a.statsWeeks > 1 {
+			a.statsWeeks--
+		}
+
+	case key.Matches(msg, a.statsKeys.Jump):
+		if a.statsCursor >= len(a.statsTop) {
+			return a, nil
+		}
+		task := a.statsTop[a.statsCursor]
+		parsed, err := time.Parse(time.DateOnly, a.extractDateFromPath(task.FilePath))
+		if err != nil {
+			return a, nil
+		}
+		a.currentDate = parsed
+		a.state = StateDailyView
+		a.clearChainState()
+		return a, a.loadEntries(task.ID)
+	}
+
+	return a, nil
+}
+
+func (a *App) handleHistoryKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, a.historyKeys.Cancel):
+		a.state = StateDailyView
+
+	case key.Matches(msg, a.historyKeys.Up):
+		if a.historyScroll > 0 {
+			a.historyScroll--
+		}
+
+	case key.Matches(msg, a.historyKeys.Down):
+		if a.historyScroll < len(a.historyLines())-1 {
+			a.historyScroll++
+		}
+	}
+	return a, nil
+}
+
+func (a *App) handleVisualKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	a.message = ""
+	a.err = nil
+
+	switch {
+	case key.Matches(msg, a.visualKeys.Cancel):
+		a.exitVisual()
+
+	case key.Matches(msg, a.visualKeys.Up):
+		if a.cursor > 0 {
+			a.cursor--
+		}
+
+	case key.Matches(msg, a.visualKeys.Down):
+		if a.cursor < len(a.entries)-1 {
+			a.cursor++
+		}
+
+	case key.Matches(msg, a.visualKeys.Range):
+		a.fixVisualRange()
+		a.visualAnchor = a.cursor
+
+	case key.Matches(msg, a.visualKeys.Toggle):
+		if a.cursor >= len(a.entries) {
+			return a, nil
+		}
+		selected := a.isSelected(a.cursor)
+		a.fixVisualRange()
+		a.visualMarked[a.entries[a.cursor].ID] = !selected
+
+	case key.Matches(msg, a.visualKeys.Complete):
+		return a, a.updateSelectionStatus(models.EntryStatusCompleted)
+
+	case key.Matches(msg, a.visualKeys.Delete):
+		return a, a.updateSelectionStatus(models.EntryStatusCancelled)
+
+	case key.Matches(msg, a.visualKeys.Reopen):
+		return a, a.updateSelectionStatus(models.EntryStatusOpen)
+
+	case key.Matches(msg, a.visualKeys.Migrate):
+		if a.isToday() {
+			a.message = "Tasks are already on today's log."
+			return a, nil
+		}
+		return a, a.migrateSelection()
+
+	case key.Matches(msg, a.visualKeys.Schedule):
+		if len(a.selectedOpenTasks()) == 0 {
+			a.message = "No open tasks selected."
+			return a, nil
+		}
+		a.state = StateDatePicker
+		a.inputMode = "schedule_visual"
+		a.input.Reset()
+		a.input.Placeholder = "Schedule to YYYY-MM-DD"
+		a.input.Focus()
+		return a, textinput.Blink
+	}
+
+	return a, nil
+}
+
+// isSelected reports whether the entry at index is in the visual selection,
+// either inside the active range or toggled individually.
+func (a *App) isSelected(index int) bool {
+	if a.state != StateVisual && a.inputMode != "schedule_visual" {
+		return false
+	}
+	if a.visualAnchor >= 0 {
+		lo, hi := min(a.visualAnchor, a.cursor), max(a.visualAnchor, a.cursor)
+		if index >= lo && index <= hi {
+			return true
+		}
+	}
+	return index < len(a.entries) && a.visualMarked[a.entries[index].ID]
+}
+
+// fixVisualRange folds the active range into the toggled entries so the
+// cursor can move on without changing the selection.
+func (a *App) fixVisualRange() {
+	if a.visualAnchor < 0 {
+		return
+	}
+	lo, hi := min(a.visualAnchor, a.cursor), max(a.visualAnchor, a.cursor)
+	for i := lo; i <= hi && i < len(a.entries); i++ {
+		a.visualMarked[a.entries[i].ID] = true
+	}
+	a.visualAnchor = -1
+}
+
+func (a *App) selectedEntries() []models.Entry {
+	var selected []models.Entry
+	for i, entry := range a.entries {
+		if a.isSelected(i) {
+			selected = append(selected, entry)
+		}
+	}
+	return selected
+}
+
+func (a *App) selectedOpenTasks() []models.Entry {
+	var tasks []models.Entry
+	for _, entry := range a.selectedEntries() {
+		if entry.IsOpenTask() {
+			tasks = append(tasks, entry)
+		}
+	}
+	return tasks
+}
+
+func (a *App) exitVisual() {
+	a.state = StateDailyView
+	a.visualAnchor = -1
+	a.visualMarked = nil
+}
+
+// advanceReview moves to the next task that has no decision yet, ending the
+// review when there is none left.
 func (a *App) advanceReview() (tea.Model, tea.Cmd) {
-	if a.reviewCursor >= len(a.reviewTasks)-1 {
+	next := a.reviewCursor + 1
+	for next < len(a.reviewTasks) {
+		if _, decided := a.reviewStepAt(next); !decided {
+			break
+		}
+		next++
+	}
+	if next >= len(a.reviewTasks) {
 		a.state = StateDailyView
-		a.currentDate = time.Now()
-		a.message = fmt.Sprintf("Review complete. Migrated: %d, Completed: %d, Cancelled: %d, Skipped: %d",
-			a.reviewSummary.Migrated, a.reviewSummary.Completed, a.reviewSummary.Cancelled, a.reviewSummary.Skipped)
-		return a, a.loadEntries()
+		a.currentDate = clock.Today()
+		a.message = fmt.Sprintf("Review complete. Migrated: %d, Scheduled: %d, Completed: %d, Cancelled: %d, Someday: %d, Skipped: %d",
+			a.reviewSummary.Migrated, a.reviewSummary.Scheduled, a.reviewSummary.Completed,
+			a.reviewSummary.Cancelled, a.reviewSummary.Someday, a.reviewSummary.Skipped)
+		return a, tea.Batch(a.loadEntries(), a.finishReviewSession())
 	}
-	a.reviewCursor++
+	a.reviewCursor = next
 	return a, nil
 }
 
@@ -513,10 +1095,93 @@ func (a *App) View() string {
 		return a.renderReviewTask()
 	case StateReviewPrompt:
 		return a.renderReviewPrompt()
+	case StateStats:
+		return a.renderStats()
+	case StateVisual:
+		return a.renderDailyView()
+	case StateHistory:
+		return a.renderHistory()
+	case StateJournalPicker:
+		return a.renderJournalPicker()
+	case StateAgenda:
+		return a.renderAgenda()
 	}
 	return ""
 }
 
+// recordReviewStep tallies a decision in the summary and the session that
+// will be saved when the review ends, and remembers it for undo.
+func (a *App) recordReviewStep(step reviewStep) {
+	a.tallyReviewDecision(step.decision.Decision, 1)
+	if a.reviewSession != nil {
+		a.reviewSession.Decisions = append(a.reviewSession.Decisions, step.decision)
+	}
+	a.reviewHistory = append(a.reviewHistory, step)
+}
+
+// removeReviewStep reverses recordReviewStep for an undone decision.
+func (a *App) removeReviewStep(step reviewStep) {
+	a.tallyReviewDecision(step.decision.Decision, -1)
+	if a.reviewSession != nil {
+		decisions := a.reviewSession.Decisions
+		for i := len(decisions) - 1; i >= 0; i-- {
+			if decisions[i].EntryID == step.decision.EntryID {
+				a.reviewSession.Decisions = append(decisions[:i], decisions[i+1:]...)
+				break
+			}
+		}
+	}
+	for i, s := range a.reviewHistory {
+		if s.index == step.index {
+			a.reviewHistory = append(a.reviewHistory[:i], a.reviewHistory[i+1:]...)
+			break
+		}
+	}
+}
+
+func (a *App) tallyReviewDecision(d models.ReviewDecisionType, delta int) {
+	switch d {
+	case models.ReviewDecisionMigrated:
+		a.reviewSummary.Migrated += delta
+	case models.ReviewDecisionScheduled:
+		a.reviewSummary.Scheduled += delta
+	case models.ReviewDecisionCompleted:
+		a.reviewSummary.Completed += delta
+	case models.ReviewDecisionCancelled:
+		a.reviewSummary.Cancelled += delta
+	case models.ReviewDecisionSomeday:
+		a.reviewSummary.Someday += delta
+	case models.ReviewDecisionSkipped:
+		a.reviewSummary.Skipped += delta
+	}
+}
+
+// reviewStepAt returns the decision made on the review task at index, if any.
+func (a *App) reviewStepAt(index int) (reviewStep, bool) {
+	for _, step := range a.reviewHistory {
+		if step.index == index {
+			return step, true
+		}
+	}
+	return reviewStep{}, false
+}
+
+func (a *App) reviewIndexOf(id string) int {
+	for i, task := range a.reviewTasks {
+		if task.ID == id {
+			return i
+		}
+	}
+	return a.reviewCursor
+}
+
+// isChronic reports whether a task has been pushed back often enough that
+// review should force a decision instead of allowing a skip.
+func (a *App) isChronic(task models.Entry) bool {
+	return task.MigrationCount >= a.cfg.Review.GetMigrationThreshold() ||
+		task.RescheduleCount >= a.cfg.Review.GetRescheduleThreshold()
+}
+
 func (a *App) clearChainState() {
 	a.migrationChain = nil
 	a.migrationChainIndex = 0
@@ -1,11 +1,17 @@
 package tui
 
 import (
+	"os"
+	"strings"
 	"testing"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/samakintunde/bujo/internal/clock"
+	"github.com/samakintunde/bujo/internal/config"
+	"github.com/samakintunde/bujo/internal/git"
 	"github.com/samakintunde/bujo/internal/models"
+	"github.com/samakintunde/bujo/internal/service"
 	"github.com/samakintunde/bujo/internal/storage"
 	"github.com/samakintunde/bujo/internal/sync"
 )
@@ -22,7 +28,7 @@ func setupTestApp(t *testing.T) (*App, func()) {
 	}
 	syncer := sync.NewSyncer(dir, db)
 
-	app := NewApp(db, fs, syncer)
+	app := NewApp(&config.Config{}, db, fs, syncer)
 
 	cleanup := func() {
 		db.Close()
@@ -56,6 +62,8 @@ func TestStateTransitions(t *testing.T) {
 		{"daily to add", StateDailyView, "a", StateAddEntry},
 		{"daily to datepicker", StateDailyView, "d", StateDatePicker},
 		{"daily to review", StateDailyView, "r", StateReviewScope},
+		{"daily to stats", StateDailyView, "S", StateStats},
+		{"stats back", StateStats, "esc", StateDailyView},
 		{"add cancel", StateAddEntry, "esc", StateDailyView},
 		{"datepicker cancel", StateDatePicker, "esc", StateDailyView},
 		{"review scope cancel", StateReviewScope, "esc", StateDailyView},
@@ -257,6 +265,417 @@ func TestQuitKey(t *testing.T) {
 	}
 }
 
+func TestStatsJumpToTask(t *testing.T) {
+	app, cleanup := setupTestApp(t)
+	defer cleanup()
+
+	app.state = StateStats
+	app.statsTop = []storage.RescheduledTask{
+		{ID: "a", Content: "First", FilePath: "/journal/2024/03/2024-03-04.md", MigrationCount: 4},
+		{ID: "b", Content: "Second", FilePath: "/journal/2024/03/2024-03-09.md", MigrationCount: 2},
+	}
+
+	downKey := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")}
+	newModel, _ := app.Update(downKey)
+	app = newModel.(*App)
+	if app.statsCursor != 1 {
+		t.Fatalf("statsCursor after j = %d, want 1", app.statsCursor)
+	}
+
+	newModel, cmd := app.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	app = newModel.(*App)
+
+	if app.state != StateDailyView {
+		t.Errorf("state after enter = %v, want StateDailyView", app.state)
+	}
+	if got := app.currentDate.Format(time.DateOnly); got != "2024-03-09" {
+		t.Errorf("currentDate after enter = %s, want 2024-03-09", got)
+	}
+	if cmd == nil {
+		t.Fatal("enter should return a load command")
+	}
+	if msg, ok := cmd().(entriesLoadedMsg); !ok || msg.targetID != "b" {
+		t.Errorf("load command returned %#v, want entriesLoadedMsg targeting b", msg)
+	}
+}
+
+func TestReviewChronicTaskCannotBeSkipped(t *testing.T) {
+	app, cleanup := setupTestApp(t)
+	defer cleanup()
+
+	app.cfg.Review.MigrationThreshold = 2
+	app.state = StateReviewTask
+	app.reviewTasks = []models.Entry{
+		{ID: "1", Type: models.EntryTypeTask, Content: "Chronic", MigrationCount: 2},
+		{ID: "2", Type: models.EntryTypeTask, Content: "Fresh"},
+	}
+
+	keepKey := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("k")}
+	newModel, _ := app.Update(keepKey)
+	app = newModel.(*App)
+
+	if app.reviewCursor != 0 {
+		t.Errorf("reviewCursor after skipping chronic task = %d, want 0", app.reviewCursor)
+	}
+	if app.reviewSummary.Skipped != 0 {
+		t.Errorf("Skipped = %d, want 0", app.reviewSummary.Skipped)
+	}
+	if app.message == "" {
+		t.Error("expected a prompt to decide on the chronic task")
+	}
+
+	app.reviewCursor = 1
+	newModel, _ = app.Update(keepKey)
+	app = newModel.(*App)
+
+	if app.reviewSummary.Skipped != 1 {
+		t.Errorf("Skipped after skipping fresh task = %d, want 1", app.reviewSummary.Skipped)
+	}
+}
+
+func TestReviewUndoAndPrevious(t *testing.T) {
+	app, cleanup := setupTestApp(t)
+	defer cleanup()
+
+	yesterday := time.Now().AddDate(0, 0, -1)
+	for _, content := range []string{"First", "Second", "Third"} {
+		if _, err := app.service.AddEntry(content, models.EntryTypeTask, yesterday); err != nil {
+			t.Fatalf("AddEntry() error: %v", err)
+		}
+	}
+	tasks, err := app.service.GetStaleTasks(0)
+	if err != nil || len(tasks) != 3 {
+		t.Fatalf("GetStaleTasks() = %d tasks, err %v", len(tasks), err)
+	}
+
+	app.state = StateReviewTask
+	app.reviewTasks = tasks
+
+	press := func(k string) {
+		t.Helper()
+		newModel, cmd := app.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+		app = newModel.(*App)
+		for cmd != nil {
+			newModel, cmd = app.Update(cmd())
+			app = newModel.(*App)
+		}
+	}
+
+	press("m")
+	press("k")
+	if app.reviewCursor != 2 || app.reviewSummary.Migrated != 1 || app.reviewSummary.Skipped != 1 {
+		t.Fatalf("after migrate+skip: cursor %d, summary %+v", app.reviewCursor, app.reviewSummary)
+	}
+
+	press("p")
+	press("p")
+	if app.reviewCursor != 0 {
+		t.Fatalf("reviewCursor after going back = %d, want 0", app.reviewCursor)
+	}
+
+	press("x")
+	if app.reviewSummary.Completed != 0 || app.message == "" {
+		t.Errorf("acting on a decided task should be refused, summary %+v", app.reviewSummary)
+	}
+
+	press("u")
+	if app.reviewSummary.Migrated != 0 {
+		t.Errorf("Migrated after undo = %d, want 0", app.reviewSummary.Migrated)
+	}
+	if stale, _ := app.service.CountStaleTasks(0); stale != 3 {
+		t.Errorf("stale tasks after undo = %d, want 3", stale)
+	}
+	today, _ := app.service.GetEntriesByDate(time.Now())
+	if len(today) != 0 {
+		t.Errorf("migrated copy should be removed, today has %d entries", len(today))
+	}
+
+	press("x")
+	if app.reviewSummary.Completed != 1 {
+		t.Errorf("Completed = %d, want 1", app.reviewSummary.Completed)
+	}
+	if app.reviewCursor != 2 {
+		t.Errorf("reviewCursor should skip decided tasks, got %d", app.reviewCursor)
+	}
+
+	press("u")
+	if app.reviewSummary.Completed != 0 || app.reviewCursor != 0 {
+		t.Errorf("undo last: cursor %d, summary %+v", app.reviewCursor, app.reviewSummary)
+	}
+}
+
+func TestVisualSelection(t *testing.T) {
+	app, cleanup := setupTestApp(t)
+	defer cleanup()
+
+	for _, content := range []string{"One", "Two", "Three", "Four"} {
+		if _, err := app.service.AddEntry(content, models.EntryTypeTask, app.currentDate); err != nil {
+			t.Fatalf("AddEntry() error: %v", err)
+		}
+	}
+	newModel, _ := app.Update(app.loadEntries()())
+	app = newModel.(*App)
+
+	press := func(k string) tea.Cmd {
+		t.Helper()
+		newModel, cmd := app.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+		app = newModel.(*App)
+		return cmd
+	}
+
+	press("v")
+	if app.state != StateVisual {
+		t.Fatalf("state = %v, want StateVisual", app.state)
+	}
+	press("j")
+	press("j")
+	press(" ")
+	press("j")
+	press(" ")
+
+	var got []string
+	for _, e := range app.selectedEntries() {
+		got = append(got, e.Content)
+	}
+	if strings.Join(got, ",") != "One,Two,Four" {
+		t.Fatalf("selection = %v, want [One Two Four]", got)
+	}
+
+	cmd := press("x")
+	if app.state != StateDailyView {
+		t.Errorf("state after batch action = %v, want StateDailyView", app.state)
+	}
+	if cmd == nil {
+		t.Fatal("batch complete should return a command")
+	}
+	newModel, cmd = app.Update(cmd())
+	app = newModel.(*App)
+	newModel, _ = app.Update(cmd())
+	app = newModel.(*App)
+
+	completed := 0
+	for _, e := range app.entries {
+		if e.Status == models.EntryStatusCompleted {
+			completed++
+		}
+	}
+	if completed != 3 {
+		t.Errorf("completed entries = %d, want 3", completed)
+	}
+}
+
+func TestMoveEntryKeys(t *testing.T) {
+	app, cleanup := setupTestApp(t)
+	defer cleanup()
+
+	for _, content := range []string{"One", "Two", "Three"} {
+		if _, err := app.service.AddEntry(content, models.EntryTypeTask, app.currentDate); err != nil {
+			t.Fatalf("AddEntry() error: %v", err)
+		}
+	}
+	newModel, _ := app.Update(app.loadEntries()())
+	app = newModel.(*App)
+
+	press := func(k string) {
+		t.Helper()
+		newModel, cmd := app.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+		app = newModel.(*App)
+		for cmd != nil {
+			newModel, cmd = app.Update(cmd())
+			app = newModel.(*App)
+		}
+	}
+	contents := func() string {
+		var names []string
+		for _, e := range app.entries {
+			names = append(names, e.Content)
+		}
+		return strings.Join(names, ",")
+	}
+
+	press("J")
+	if got := contents(); got != "Two,One,Three" {
+		t.Errorf("after J: %s, want Two,One,Three", got)
+	}
+	if app.cursor != 1 {
+		t.Errorf("cursor should follow the moved entry, got %d", app.cursor)
+	}
+
+	press("J")
+	press("K")
+	press("K")
+	if got := contents(); got != "One,Two,Three" || app.cursor != 0 {
+		t.Errorf("after J K K: %s cursor %d, want One,Two,Three cursor 0", got, app.cursor)
+	}
+}
+
+// fileOrder lists the contents of the current day's entries in file order.
+func fileOrder(t *testing.T, app *App) string {
+	t.Helper()
+	entries, err := app.db.GetEntriesByFile(app.fs.GetDayPath(app.currentDate.Format(time.DateOnly)))
+	if err != nil {
+		t.Fatalf("GetEntriesByFile() error: %v", err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Content)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestMoveEntryKeysFollowFileOrder(t *testing.T) {
+	app, cleanup := setupTestApp(t)
+	defer cleanup()
+
+	for _, add := range []struct {
+		content   string
+		entryType models.EntryType
+	}{
+		{"15:00 Late", models.EntryTypeEvent},
+		{"Task", models.EntryTypeTask},
+		{"14:00 Early", models.EntryTypeEvent},
+	} {
+		if _, err := app.service.AddEntry(add.content, add.entryType, app.currentDate); err != nil {
+			t.Fatalf("AddEntry() error: %v", err)
+		}
+	}
+	app.Update(app.loadEntries()())
+	press := func(k string) {
+		t.Helper()
+		_, cmd := app.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+		for cmd != nil {
+			_, cmd = app.Update(cmd())
+		}
+	}
+
+	// Events are shown by time, so the task sits between them on screen
+	// and in the file alike; K moves it above its neighbour in the file.
+	app.cursor = 1
+	press("K")
+	if got := fileOrder(t, app); got != "Task,15:00 Late,14:00 Early" {
+		t.Errorf("file after K = %s, want Task,15:00 Late,14:00 Early", got)
+	}
+	press("J")
+	press("J")
+	if got := fileOrder(t, app); got != "15:00 Late,14:00 Early,Task" {
+		t.Errorf("file after J J = %s, want 15:00 Late,14:00 Early,Task", got)
+	}
+}
+
+func TestMoveEntryKeysOnPrioritySortedDay(t *testing.T) {
+	app, cleanup := setupTestApp(t)
+	defer cleanup()
+
+	for _, content := range []string{"A", "!! B"} {
+		if _, err := app.service.AddEntry(content, models.EntryTypeTask, app.currentDate); err != nil {
+			t.Fatalf("AddEntry() error: %v", err)
+		}
+	}
+	app.Update(app.loadEntries()())
+	if app.entries[0].Content != "!! B" {
+		t.Fatalf("entries = %+v, want the priority task first", app.entries)
+	}
+
+	// A is first in the file, so K has nowhere to move it.
+	app.cursor = 1
+	_, cmd := app.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("K")})
+	if cmd != nil {
+		t.Error("K on the first entry in the file should not move anything")
+	}
+	if got := fileOrder(t, app); got != "A,!! B" {
+		t.Errorf("file after K = %s, want it unchanged", got)
+	}
+}
+
+func TestHistoryPanel(t *testing.T) {
+	app, cleanup := setupTestApp(t)
+	defer cleanup()
+
+	app.entries = []models.Entry{{ID: "1", Type: models.EntryTypeTask, Content: "Traced"}}
+
+	newModel, cmd := app.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("H")})
+	app = newModel.(*App)
+	if app.state != StateHistory || cmd == nil {
+		t.Fatalf("H should open the history panel and load it, state %v", app.state)
+	}
+
+	newModel, _ = app.Update(historyLoadedMsg{log: []git.LogEntry{
+		{Subject: "feat(bujo): add task #1", Diff: []string{"+- [ ] Traced"}},
+		{Subject: "feat(bujo): update task #1 to completed"},
+	}})
+	app = newModel.(*App)
+	if got := len(app.historyLines()); got != 3 {
+		t.Errorf("historyLines() = %d lines, want 3", got)
+	}
+	if !strings.Contains(app.View(), "add task #1") {
+		t.Error("history view should list the commits")
+	}
+
+	for range 5 {
+		newModel, _ = app.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")})
+		app = newModel.(*App)
+	}
+	if app.historyScroll != 2 {
+		t.Errorf("historyScroll = %d, want 2 (clamped)", app.historyScroll)
+	}
+
+	newModel, _ = app.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	app = newModel.(*App)
+	if app.state != StateDailyView {
+		t.Errorf("state after esc = %v, want StateDailyView", app.state)
+	}
+}
+
+func TestParseReviewFilter(t *testing.T) {
+	filter, err := parseReviewFilter("#work type:migrated #urgent")
+	if err != nil {
+		t.Fatalf("parseReviewFilter() error: %v", err)
+	}
+	if len(filter.Tags) != 2 || filter.Tags[0] != "work" || filter.Tags[1] != "urgent" {
+		t.Errorf("Tags = %v, want [work urgent]", filter.Tags)
+	}
+	if filter.Kind != storage.StaleKindMigrated {
+		t.Errorf("Kind = %q, want migrated", filter.Kind)
+	}
+	if got := formatReviewFilter(filter); got != "#work #urgent type:migrated" {
+		t.Errorf("formatReviewFilter() = %q", got)
+	}
+
+	for _, bad := range []string{"work", "type:event", "#"} {
+		if _, err := parseReviewFilter(bad); err == nil {
+			t.Errorf("parseReviewFilter(%q) should fail", bad)
+		}
+	}
+}
+
+func TestReviewScopeFromConfig(t *testing.T) {
+	app, cleanup := setupTestApp(t)
+	defer cleanup()
+
+	app.cfg.Review.Scopes = []config.ReviewScope{
+		{Label: "Fortnight", Days: 14},
+	}
+	app.state = StateReviewScope
+
+	newModel, cmd := app.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("2")})
+	app = newModel.(*App)
+	if cmd != nil {
+		t.Error("selecting an unconfigured scope should do nothing")
+	}
+
+	newModel, cmd = app.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("1")})
+	app = newModel.(*App)
+	if cmd == nil {
+		t.Fatal("selecting a scope should load review tasks")
+	}
+	if app.reviewFilter.DaysBack != 14 {
+		t.Errorf("DaysBack = %d, want 14", app.reviewFilter.DaysBack)
+	}
+	if app.reviewSession == nil || app.reviewSession.Scope != "Fortnight" {
+		t.Errorf("reviewSession = %+v, want Fortnight session", app.reviewSession)
+	}
+}
+
 func TestCursorMovementClearsChainState(t *testing.T) {
 	app, cleanup := setupTestApp(t)
 	defer cleanup()
@@ -369,6 +788,181 @@ func TestStatusBarNoChainHintForRegularEntries(t *testing.T) {
 	}
 }
 
+func TestGitWarning(t *testing.T) {
+	app, cleanup := setupTestApp(t)
+	defer cleanup()
+
+	err := &git.Error{Kind: git.ErrLocked, Args: []string{"commit"}, Stderr: "fatal: Unable to create '.git/index.lock': File exists."}
+	_, cmd := app.Update(gitErrorMsg{err: err})
+	if cmd == nil {
+		t.Error("app stopped listening for git errors after the first one")
+	}
+
+	view := app.renderDailyView()
+	if !strings.Contains(view, "git commit: repository is locked") {
+		t.Errorf("daily view missing git warning:\n%s", view)
+	}
+	if app.state != StateDailyView {
+		t.Errorf("state = %v, want the daily view to stay usable", app.state)
+	}
+
+	// A later commit went through.
+	app.Update(gitErrorMsg{})
+	if view := app.renderDailyView(); strings.Contains(view, "repository is locked") {
+		t.Errorf("git warning outlived a successful commit:\n%s", view)
+	}
+}
+
+func TestJournalSwitcher(t *testing.T) {
+	app, cleanup := setupTestApp(t)
+	defer cleanup()
+	app.cfg.Workspace = "personal"
+	press := func(k string) tea.Cmd {
+		_, cmd := app.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+		return cmd
+	}
+
+	press("w")
+	if app.state != StateDailyView {
+		t.Fatalf("state = %v, want no picker with a single journal", app.state)
+	}
+
+	app.SetJournals([]string{"personal", "work"}, func() ([]*service.Agenda, error) {
+		return []*service.Agenda{{Journal: "work", Events: []models.Entry{{Content: "09:30 Standup", Type: models.EntryTypeEvent}}}}, nil
+	})
+	press("w")
+	if app.state != StateJournalPicker {
+		t.Fatalf("state = %v, want StateJournalPicker", app.state)
+	}
+	if press("1") != nil || app.state != StateDailyView || app.SwitchTo() != "" {
+		t.Errorf("picking the current journal should go back to it, got state %v, switch %q", app.state, app.SwitchTo())
+	}
+
+	press("w")
+	cmd := press("a")
+	if app.state != StateAgenda || cmd == nil {
+		t.Fatalf("state = %v, want StateAgenda loading the agendas", app.state)
+	}
+	app.Update(cmd())
+	if view := app.renderAgenda(); !strings.Contains(view, "work") || !strings.Contains(view, "Standup") {
+		t.Errorf("agenda missing the work journal:\n%s", view)
+	}
+	app.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if app.state != StateDailyView {
+		t.Errorf("state = %v, want esc to leave the agenda", app.state)
+	}
+
+	press("w")
+	cmd = press("2")
+	if cmd == nil {
+		t.Fatal("switching journals should quit")
+	}
+	if _, ok := cmd().(tea.QuitMsg); !ok || app.SwitchTo() != "work" {
+		t.Errorf("SwitchTo() = %q, want work", app.SwitchTo())
+	}
+}
+
+func TestNowAndNext(t *testing.T) {
+	entries := []models.Entry{
+		{ID: "standup", Type: models.EntryTypeEvent, StartTime: "09:30", EndTime: "09:45"},
+		{ID: "task", Type: models.EntryTypeTask},
+		{ID: "lunch", Type: models.EntryTypeEvent, StartTime: "12:00"},
+		{ID: "retro", Type: models.EntryTypeEvent, StartTime: "16:00", EndTime: "17:00"},
+	}
+	at := func(clock string) time.Time {
+		t, _ := time.Parse("2006-01-02 15:04", "2025-03-04 "+clock)
+		return t
+	}
+
+	tests := []struct {
+		clock       string
+		wantCurrent int
+		wantNext    int
+	}{
+		{"08:00", -1, 0},
+		{"09:40", 0, 2},
+		{"12:59", 2, 3},
+		{"13:00", -1, 3},
+		{"16:30", 3, -1},
+		{"18:00", -1, -1},
+	}
+	for _, tt := range tests {
+		current, next := nowAndNext(entries, at(tt.clock))
+		if current != tt.wantCurrent || next != tt.wantNext {
+			t.Errorf("at %s: now/next = %d/%d, want %d/%d", tt.clock, current, next, tt.wantCurrent, tt.wantNext)
+		}
+	}
+}
+
+func TestNowAndNextAfterMidnight(t *testing.T) {
+	if err := clock.Configure(time.UTC, 4); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = clock.Configure(time.Local, 0) })
+
+	entries := []models.Entry{
+		{ID: "party", Type: models.EntryTypeEvent, StartTime: "22:00", EndTime: "01:00"},
+		{ID: "taxi", Type: models.EntryTypeEvent, StartTime: "01:30"},
+	}
+	at := func(hhmm string) time.Time {
+		t, _ := time.Parse("2006-01-02 15:04", "2025-03-05 "+hhmm)
+		return t
+	}
+
+	tests := []struct {
+		clock       string
+		wantCurrent int
+		wantNext    int
+	}{
+		{"23:30", 0, 1},
+		{"00:30", 0, 1},
+		{"01:15", -1, 1},
+		{"02:00", 1, -1},
+	}
+	for _, tt := range tests {
+		current, next := nowAndNext(entries, at(tt.clock))
+		if current != tt.wantCurrent || next != tt.wantNext {
+			t.Errorf("at %s: now/next = %d/%d, want %d/%d", tt.clock, current, next, tt.wantCurrent, tt.wantNext)
+		}
+	}
+}
+
+func TestRenderEntryDeadline(t *testing.T) {
+	app, cleanup := setupTestApp(t)
+	defer cleanup()
+
+	overdue := models.Entry{Type: models.EntryTypeTask, Status: models.EntryStatusOpen, Content: "Report due:2020-01-01", DueDate: "2020-01-01"}
+	if line := app.renderEntry(overdue, false); !strings.Contains(line, "overdue") {
+		t.Errorf("overdue task rendered without indicator: %q", line)
+	}
+
+	done := overdue
+	done.Status = models.EntryStatusCompleted
+	if line := app.renderEntry(done, false); strings.Contains(line, "overdue") {
+		t.Errorf("completed task flagged overdue: %q", line)
+	}
+
+	today := models.Entry{Type: models.EntryTypeTask, Status: models.EntryStatusOpen, Content: "Slides", DueDate: time.Now().Format(time.DateOnly)}
+	if line := app.renderEntry(today, false); !strings.Contains(line, "due today") {
+		t.Errorf("task due today rendered without indicator: %q", line)
+	}
+}
+
+func TestRenderEntryCustomStatus(t *testing.T) {
+	if err := models.ConfigureStatuses([]models.StatusDef{{Status: "waiting", Markdown: "w", Display: "⧗"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = models.ConfigureStatuses(nil, nil) })
+
+	app, cleanup := setupTestApp(t)
+	defer cleanup()
+
+	entry := models.Entry{Type: models.EntryTypeTask, Status: "waiting", Content: "Hear back from Sam"}
+	if line := app.renderEntry(entry, false); !strings.Contains(line, "⧗ Hear back from Sam") {
+		t.Errorf("custom status rendered without its signifier: %q", line)
+	}
+}
+
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(s) > 0 && containsHelper(s, substr))
 }
@@ -390,3 +984,68 @@ func isToday(t time.Time) bool {
 func sameDay(a, b time.Time) bool {
 	return a.Year() == b.Year() && a.Month() == b.Month() && a.Day() == b.Day()
 }
+
+func TestRepairsShowInStatusBar(t *testing.T) {
+	app, cleanup := setupTestApp(t)
+	defer cleanup()
+
+	path, err := app.fs.EnsureDayPath(app.currentDate.Format(time.DateOnly))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("- [ ] Typed by hand\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	app.Update(app.loadEntries()())
+	if !strings.Contains(app.message, "Auto-repaired IDs in") {
+		t.Errorf("message = %q, want the repair", app.message)
+	}
+	if app.repairs.take() != "" {
+		t.Error("repair was not taken from the log")
+	}
+}
+
+func TestEntryPrefix(t *testing.T) {
+	tests := []struct {
+		text        string
+		wantType    models.EntryType
+		wantContent string
+	}{
+		{". buy milk", models.EntryTypeTask, "buy milk"},
+		{"! standup 09:30", models.EntryTypeEvent, "standup 09:30"},
+		{"- idea", models.EntryTypeNote, "idea"},
+		{"!! call bank", models.EntryTypeNote, "!! call bank"},
+		{"!!! file taxes", models.EntryTypeNote, "!!! file taxes"},
+		{"* renew passport", models.EntryTypeNote, "* renew passport"},
+		{"plain", models.EntryTypeNote, "plain"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			gotType, gotContent := entryPrefix(tt.text, models.EntryTypeNote)
+			if gotType != tt.wantType || gotContent != tt.wantContent {
+				t.Errorf("entryPrefix(%q) = %v, %q; want %v, %q", tt.text, gotType, gotContent, tt.wantType, tt.wantContent)
+			}
+		})
+	}
+}
+
+func TestAddEntryKeepsPriorityMarkers(t *testing.T) {
+	app, cleanup := setupTestApp(t)
+	defer cleanup()
+
+	if msg := app.addEntry("!! call bank")().(entryAddedMsg); msg.err != nil {
+		t.Fatalf("addEntry() error: %v", msg.err)
+	}
+	entries, err := app.service.GetEntriesByDate(app.currentDate)
+	if err != nil {
+		t.Fatalf("GetEntriesByDate() error: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	if e := entries[0]; e.Type != models.EntryTypeTask || e.Priority != 2 || e.Content != "!! call bank" {
+		t.Errorf("entry = %v %d %q, want a priority 2 task", e.Type, e.Priority, e.Content)
+	}
+}
@@ -24,6 +24,13 @@ type KeyMap struct {
 	Review    key.Binding
 	ChainPrev key.Binding
 	ChainNext key.Binding
+	Stats     key.Binding
+	Visual    key.Binding
+	MoveUp    key.Binding
+	MoveDown  key.Binding
+	Move      key.Binding
+	History   key.Binding
+	Journal   key.Binding
 
 	// General
 	Confirm key.Binding
@@ -94,6 +101,34 @@ var DefaultKeyMap = KeyMap{
 		key.WithKeys("]"),
 		key.WithHelp("]", "chain next"),
 	),
+	Stats: key.NewBinding(
+		key.WithKeys("S"),
+		key.WithHelp("S", "stats"),
+	),
+	Visual: key.NewBinding(
+		key.WithKeys("v"),
+		key.WithHelp("v", "select"),
+	),
+	MoveUp: key.NewBinding(
+		key.WithKeys("K", "shift+up"),
+		key.WithHelp("K", "move entry up"),
+	),
+	MoveDown: key.NewBinding(
+		key.WithKeys("J", "shift+down"),
+		key.WithHelp("J", "move entry down"),
+	),
+	Move: key.NewBinding(
+		key.WithKeys("M"),
+		key.WithHelp("M", "move to date"),
+	),
+	History: key.NewBinding(
+		key.WithKeys("H"),
+		key.WithHelp("H", "entry history"),
+	),
+	Journal: key.NewBinding(
+		key.WithKeys("w"),
+		key.WithHelp("w", "switch journal"),
+	),
 	Confirm: key.NewBinding(
 		key.WithKeys("enter"),
 		key.WithHelp("enter", "confirm"),
@@ -118,7 +153,10 @@ type ReviewKeyMap struct {
 	Schedule key.Binding
 	Complete key.Binding
 	Delete   key.Binding
+	Someday  key.Binding
 	Keep     key.Binding
+	Previous key.Binding
+	Undo     key.Binding
 	Cancel   key.Binding
 }
 
@@ -139,10 +177,22 @@ var DefaultReviewKeyMap = ReviewKeyMap{
 		key.WithKeys("d"),
 		key.WithHelp("d", "cancel task"),
 	),
+	Someday: key.NewBinding(
+		key.WithKeys("o"),
+		key.WithHelp("o", "move to someday"),
+	),
 	Keep: key.NewBinding(
 		key.WithKeys("k"),
 		key.WithHelp("k", "keep/skip"),
 	),
+	Previous: key.NewBinding(
+		key.WithKeys("p"),
+		key.WithHelp("p", "previous task"),
+	),
+	Undo: key.NewBinding(
+		key.WithKeys("u"),
+		key.WithHelp("u", "undo decision"),
+	),
 	Cancel: key.NewBinding(
 		key.WithKeys("esc", "q"),
 		key.WithHelp("esc", "exit review"),
@@ -151,29 +201,157 @@ var DefaultReviewKeyMap = ReviewKeyMap{
 
 // ScopeKeyMap for review scope selection
 type ScopeKeyMap struct {
-	Option1 key.Binding
-	Option2 key.Binding
-	Option3 key.Binding
-	Option4 key.Binding
-	Cancel  key.Binding
+	Select key.Binding
+	Filter key.Binding
+	Cancel key.Binding
 }
 
 var DefaultScopeKeyMap = ScopeKeyMap{
-	Option1: key.NewBinding(
-		key.WithKeys("1"),
-		key.WithHelp("1", "last active day"),
+	Select: key.NewBinding(
+		key.WithKeys("1", "2", "3", "4", "5", "6", "7", "8", "9"),
+		key.WithHelp("1-9", "select scope"),
+	),
+	Filter: key.NewBinding(
+		key.WithKeys("/"),
+		key.WithHelp("/", "filter"),
+	),
+	Cancel: key.NewBinding(
+		key.WithKeys("esc", "q"),
+		key.WithHelp("esc", "cancel"),
+	),
+}
+
+// StatsKeyMap for the statistics dashboard
+type StatsKeyMap struct {
+	Up     key.Binding
+	Down   key.Binding
+	Jump   key.Binding
+	More   key.Binding
+	Fewer  key.Binding
+	Cancel key.Binding
+}
+
+var DefaultStatsKeyMap = StatsKeyMap{
+	Up: key.NewBinding(
+		key.WithKeys("up", "k"),
+		key.WithHelp("↑/k", "up"),
+	),
+	Down: key.NewBinding(
+		key.WithKeys("down", "j"),
+		key.WithHelp("↓/j", "down"),
+	),
+	Jump: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "go to task"),
+	),
+	More: key.NewBinding(
+		key.WithKeys("+", "="),
+		key.WithHelp("+", "more weeks"),
+	),
+	Fewer: key.NewBinding(
+		key.WithKeys("-"),
+		key.WithHelp("-", "fewer weeks"),
+	),
+	Cancel: key.NewBinding(
+		key.WithKeys("esc", "q", "S"),
+		key.WithHelp("esc", "back"),
+	),
+}
+
+// HistoryKeyMap for the entry history panel
+type HistoryKeyMap struct {
+	Up     key.Binding
+	Down   key.Binding
+	Cancel key.Binding
+}
+
+var DefaultHistoryKeyMap = HistoryKeyMap{
+	Up: key.NewBinding(
+		key.WithKeys("up", "k"),
+		key.WithHelp("↑/k", "scroll up"),
+	),
+	Down: key.NewBinding(
+		key.WithKeys("down", "j"),
+		key.WithHelp("↓/j", "scroll down"),
+	),
+	Cancel: key.NewBinding(
+		key.WithKeys("esc", "q", "H"),
+		key.WithHelp("esc", "back"),
 	),
-	Option2: key.NewBinding(
-		key.WithKeys("2"),
-		key.WithHelp("2", "last 2 days"),
+}
+
+// VisualKeyMap for selecting several entries in the daily view
+type VisualKeyMap struct {
+	Up       key.Binding
+	Down     key.Binding
+	Toggle   key.Binding
+	Range    key.Binding
+	Complete key.Binding
+	Delete   key.Binding
+	Reopen   key.Binding
+	Migrate  key.Binding
+	Schedule key.Binding
+	Cancel   key.Binding
+}
+
+var DefaultVisualKeyMap = VisualKeyMap{
+	Up: key.NewBinding(
+		key.WithKeys("up", "k"),
+		key.WithHelp("↑/k", "up"),
 	),
-	Option3: key.NewBinding(
-		key.WithKeys("3"),
-		key.WithHelp("3", "last week"),
+	Down: key.NewBinding(
+		key.WithKeys("down", "j"),
+		key.WithHelp("↓/j", "down"),
 	),
-	Option4: key.NewBinding(
-		key.WithKeys("4"),
-		key.WithHelp("4", "all stale"),
+	Toggle: key.NewBinding(
+		key.WithKeys(" "),
+		key.WithHelp("space", "toggle entry"),
+	),
+	Range: key.NewBinding(
+		key.WithKeys("v"),
+		key.WithHelp("v", "start range"),
+	),
+	Complete: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "complete"),
+	),
+	Delete: key.NewBinding(
+		key.WithKeys("d"),
+		key.WithHelp("d", "cancel tasks"),
+	),
+	Reopen: key.NewBinding(
+		key.WithKeys("o"),
+		key.WithHelp("o", "reopen"),
+	),
+	Migrate: key.NewBinding(
+		key.WithKeys("m"),
+		key.WithHelp("m", "migrate to today"),
+	),
+	Schedule: key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "schedule"),
+	),
+	Cancel: key.NewBinding(
+		key.WithKeys("esc", "q"),
+		key.WithHelp("esc", "exit selection"),
+	),
+}
+
+// JournalKeyMap for the journal switcher
+type JournalKeyMap struct {
+	Select key.Binding
+	Agenda key.Binding
+	Cancel key.Binding
+}
+
+var DefaultJournalKeyMap = JournalKeyMap{
+	Select: key.NewBinding(
+		key.WithKeys("1", "2", "3", "4", "5", "6", "7", "8", "9"),
+		key.WithHelp("1-9", "switch journal"),
+	),
+	Agenda: key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("a", "all journals"),
 	),
 	Cancel: key.NewBinding(
 		key.WithKeys("esc", "q"),
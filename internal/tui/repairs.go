@@ -0,0 +1,40 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	gosync "sync"
+)
+
+// repairLog collects the syncer's repair reports for the status bar.
+type repairLog struct {
+	mu    gosync.Mutex
+	lines []string
+}
+
+func (r *repairLog) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, line := range strings.Split(string(p), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			r.lines = append(r.lines, line)
+		}
+	}
+	return len(p), nil
+}
+
+// take returns the repairs reported since it was last called, as one line.
+func (r *repairLog) take() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	lines := r.lines
+	r.lines = nil
+	switch len(lines) {
+	case 0:
+		return ""
+	case 1:
+		return lines[0]
+	default:
+		return fmt.Sprintf("%s (and %d more repairs)", lines[len(lines)-1], len(lines)-1)
+	}
+}
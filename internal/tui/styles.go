@@ -1,6 +1,9 @@
 package tui
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"github.com/charmbracelet/lipgloss"
+	"github.com/samakintunde/bujo/internal/config"
+)
 
 // Color palette
 var (
@@ -43,6 +46,10 @@ var (
 	// Navigation hints in header
 	NavHintStyle = lipgloss.NewStyle().
 			Foreground(colorMuted)
+
+	// Workspace name in header
+	JournalBadgeStyle = lipgloss.NewStyle().
+				Foreground(colorPrimary)
 )
 
 // Entry list styles
@@ -62,10 +69,36 @@ var (
 			Foreground(colorPrimary).
 			Bold(true)
 
+	// Event happening now / starting next
+	EventNowStyle = lipgloss.NewStyle().
+			Foreground(colorSuccess).
+			Bold(true)
+
+	EventNextStyle = lipgloss.NewStyle().
+			Foreground(colorWarning)
+
+	// Deadline indicators
+	OverdueStyle = lipgloss.NewStyle().
+			Foreground(colorDanger).
+			Bold(true)
+
+	DueTodayStyle = lipgloss.NewStyle().
+			Foreground(colorWarning)
+
+	// Open task marked with a priority
+	PriorityStyle = lipgloss.NewStyle().
+			Foreground(colorPrimary).
+			Bold(true)
+
 	// Chain indicator
 	ChainStyle = lipgloss.NewStyle().
 			Foreground(colorSecondary).
 			MarginLeft(1)
+
+	// Visual selection marker
+	VisualSelectedStyle = lipgloss.NewStyle().
+				Foreground(colorPrimary).
+				Bold(true)
 )
 
 // Entry signifier styles (by status)
@@ -86,6 +119,10 @@ var (
 				Foreground(colorMuted).
 				Strikethrough(true)
 
+	// Statuses added in the config
+	SignifierCustomStyle = lipgloss.NewStyle().
+				Foreground(colorWarning)
+
 	SignifierEventStyle = lipgloss.NewStyle().
 				Foreground(colorWarning)
 
@@ -109,6 +146,10 @@ var (
 
 	DescStyle = lipgloss.NewStyle().
 			Foreground(colorMuted)
+
+	// Non-blocking git warning under the status bar
+	GitWarningStyle = lipgloss.NewStyle().
+			Foreground(colorWarning)
 )
 
 // Modal styles
@@ -188,3 +229,52 @@ var (
 		Italic(true).
 		Align(lipgloss.Center)
 )
+
+// Stats dashboard styles
+var (
+	StatsSectionStyle = lipgloss.NewStyle().
+				Bold(true).
+				Foreground(colorSecondary).
+				MarginTop(1)
+
+	SparklineStyle = lipgloss.NewStyle().
+			Foreground(colorSuccess)
+
+	// Heatmap intensity levels, from no activity to busiest
+	HeatmapStyles = []lipgloss.Style{
+		lipgloss.NewStyle().Foreground(lipgloss.Color("#374151")),
+		lipgloss.NewStyle().Foreground(lipgloss.Color("#065F46")),
+		lipgloss.NewStyle().Foreground(lipgloss.Color("#047857")),
+		lipgloss.NewStyle().Foreground(lipgloss.Color("#10B981")),
+		lipgloss.NewStyle().Foreground(lipgloss.Color("#6EE7B7")),
+	}
+)
+
+// History panel styles
+var (
+	HistoryDateStyle = lipgloss.NewStyle().
+				Foreground(colorSubtle)
+
+	HistoryAddedStyle = lipgloss.NewStyle().
+				Foreground(colorSuccess)
+
+	HistoryRemovedStyle = lipgloss.NewStyle().
+				Foreground(colorDanger)
+)
+
+// ApplyTheme recolours the accented styles for a journal's theme. An empty
+// accent restores the default.
+func ApplyTheme(theme config.ThemeConfig) {
+	accent := lipgloss.TerminalColor(colorPrimary)
+	if theme.Accent != "" {
+		accent = lipgloss.Color(theme.Accent)
+	}
+	DateStyle = DateStyle.Foreground(accent)
+	JournalBadgeStyle = JournalBadgeStyle.Foreground(accent)
+	CursorStyle = CursorStyle.Foreground(accent)
+	PriorityStyle = PriorityStyle.Foreground(accent)
+	VisualSelectedStyle = VisualSelectedStyle.Foreground(accent)
+	ModalStyle = ModalStyle.BorderForeground(accent)
+	ModalTitleStyle = ModalTitleStyle.Foreground(accent)
+	InputPromptStyle = InputPromptStyle.Foreground(accent)
+}
@@ -1,11 +1,17 @@
 package tui
 
 import (
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/samakintunde/bujo/internal/clock"
+	"github.com/samakintunde/bujo/internal/git"
 	"github.com/samakintunde/bujo/internal/models"
+	"github.com/samakintunde/bujo/internal/service"
+	"github.com/samakintunde/bujo/internal/storage"
 )
 
 func (a *App) renderDailyView() string {
@@ -17,6 +23,11 @@ func (a *App) renderDailyView() string {
 	b.WriteString("\n")
 	b.WriteString(a.renderStatusBar())
 
+	if a.gitWarning != nil {
+		b.WriteString("\n")
+		b.WriteString(GitWarningStyle.Render("! " + gitWarningText(a.gitWarning) + " (see bujo git status)"))
+	}
+
 	if a.message != "" {
 		b.WriteString("\n")
 		b.WriteString(SignifierCompletedStyle.Render(a.message))
@@ -30,6 +41,16 @@ func (a *App) renderDailyView() string {
 	return AppStyle.Render(b.String())
 }
 
+// gitWarningText shortens a commit failure to fit the status bar.
+func gitWarningText(err error) string {
+	var gitErr *git.Error
+	if errors.As(err, &gitErr) {
+		return gitErr.Summary()
+	}
+	text, _, _ := strings.Cut(err.Error(), "\n")
+	return text
+}
+
 func (a *App) renderHeader() string {
 	dateStr := a.currentDate.Format("02, January, 2006")
 
@@ -46,6 +67,9 @@ func (a *App) renderHeader() string {
 	}
 
 	dateDisplay := DateStyle.Render(dateStr) + todayBadge
+	if a.cfg.Workspace != "" {
+		dateDisplay = JournalBadgeStyle.Render("["+a.cfg.Workspace+"]") + " " + dateDisplay
+	}
 	navHint := NavHintStyle.Render("[h←] [→l]")
 
 	if len(a.migrationChain) > 0 {
@@ -54,14 +78,72 @@ func (a *App) renderHeader() string {
 	}
 
 	header := fmt.Sprintf("%s  %s", dateDisplay, navHint)
+	if agenda := a.renderNowNext(); agenda != "" {
+		header += "\n" + agenda
+	}
 	return HeaderStyle.Render(header)
 }
 
+// defaultEventLength is how long an event without an end time is "now".
+const defaultEventLength = time.Hour
+
+// nowAndNext returns the index of the event happening at now and of the
+// next one to start, or -1.
+func nowAndNext(entries []models.Entry, now time.Time) (current, next int) {
+	current, next = -1, -1
+	wall, _ := clock.Minutes(clock.TimeOfDay(now))
+	for i, e := range entries {
+		if e.Type != models.EntryTypeEvent {
+			continue
+		}
+		start, end, ok := e.EventSpan()
+		if !ok {
+			continue
+		}
+		if e.EndTime == "" {
+			end = start + int(defaultEventLength.Minutes())
+		}
+		switch {
+		case start <= wall && wall < end:
+			if current == -1 {
+				current = i
+			}
+		case start > wall:
+			if next == -1 {
+				next = i
+			}
+		}
+	}
+	return current, next
+}
+
+func (a *App) renderNowNext() string {
+	if !a.isToday() {
+		return ""
+	}
+	current, next := nowAndNext(a.entries, clock.Now())
+	var parts []string
+	if current >= 0 {
+		e := a.entries[current]
+		parts = append(parts, EventNowStyle.Render("Now")+" "+EntryStyle.Render(e.TimeRange()+" "+e.Content))
+	}
+	if next >= 0 {
+		e := a.entries[next]
+		parts = append(parts, EventNextStyle.Render("Next")+" "+NavHintStyle.Render(e.TimeRange()+" "+e.Content))
+	}
+	return strings.Join(parts, NavHintStyle.Render("  ·  "))
+}
+
 func (a *App) renderEntryList() string {
 	if len(a.entries) == 0 {
 		return EmptyStateStyle.Render("No entries for this day. Press 'a' to add one.")
 	}
 
+	current, next := -1, -1
+	if a.isToday() {
+		current, next = nowAndNext(a.entries, clock.Now())
+	}
+
 	var b strings.Builder
 	for i, entry := range a.entries {
 		cursor := "  "
@@ -70,6 +152,17 @@ func (a *App) renderEntryList() string {
 		}
 
 		line := a.renderEntry(entry, i == a.cursor)
+		switch i {
+		case current:
+			line += EventNowStyle.Render(" ◀ now")
+		case next:
+			line += EventNextStyle.Render(" ◀ next")
+		}
+		if a.isSelected(i) {
+			line = VisualSelectedStyle.Render("▌") + line
+		} else if a.state == StateVisual {
+			line = " " + line
+		}
 		b.WriteString(cursor + line + "\n")
 	}
 	return b.String()
@@ -81,6 +174,15 @@ func (a *App) renderEntry(entry models.Entry, selected bool) string {
 
 	if entry.Status == models.EntryStatusCancelled {
 		content = SignifierCancelledStyle.Render(content)
+	} else if entry.IsOpenTask() && entry.Priority > 0 {
+		content = PriorityStyle.Render(content)
+	}
+
+	if entry.Type == models.EntryTypeEvent && entry.StartTime != "" {
+		// Times kept in metadata aren't part of the content; show them.
+		if start, _ := models.ParseEventTime(entry.Content); start == "" {
+			content = entry.TimeRange() + " " + content
+		}
 	}
 
 	line := fmt.Sprintf("%s %s", signifier, content)
@@ -90,6 +192,17 @@ func (a *App) renderEntry(entry models.Entry, selected bool) string {
 		line += ChainStyle.Render(" 🔗")
 	}
 
+	today := clock.Today()
+	switch {
+	case entry.IsOverdue(today):
+		line += OverdueStyle.Render(" ! overdue " + entry.DueDate)
+	case entry.IsDueOn(today):
+		line += DueTodayStyle.Render(" due today")
+	case entry.IsOpenTask() && entry.DueDate != "" && models.ParseDueDate(entry.Content) == "":
+		// A deadline kept in metadata isn't part of the content; show it.
+		line += NavHintStyle.Render(" due " + entry.DueDate)
+	}
+
 	if selected {
 		return SelectedEntryStyle.Render(line)
 	}
@@ -111,6 +224,12 @@ func (a *App) getSignifierStyled(entry models.Entry) string {
 		case models.EntryStatusCancelled:
 			return SignifierCancelledStyle.Render("-")
 		}
+		if def, ok := models.LookupStatus(entry.Status); ok {
+			return SignifierCustomStyle.Render(def.Display)
+		}
+		if mark, ok := models.CheckboxMark(entry.RawContent); ok {
+			return SignifierCustomStyle.Render(mark)
+		}
 	case models.EntryTypeEvent:
 		return SignifierEventStyle.Render("*")
 	case models.EntryTypeNote:
@@ -120,15 +239,35 @@ func (a *App) getSignifierStyled(entry models.Entry) string {
 }
 
 func (a *App) renderStatusBar() string {
+	if a.state == StateVisual {
+		keys := []string{
+			VisualSelectedStyle.Render(fmt.Sprintf("-- SELECT (%d) --", len(a.selectedEntries()))),
+			KeyStyle.Render("v") + DescStyle.Render(" range"),
+			KeyStyle.Render("space") + DescStyle.Render(" toggle"),
+			KeyStyle.Render("x") + DescStyle.Render(" done"),
+			KeyStyle.Render("d") + DescStyle.Render(" cancel"),
+			KeyStyle.Render("o") + DescStyle.Render(" reopen"),
+			KeyStyle.Render("m") + DescStyle.Render("igrate"),
+			KeyStyle.Render("s") + DescStyle.Render("chedule"),
+			KeyStyle.Render("esc") + DescStyle.Render(" exit"),
+		}
+		return StatusBarStyle.Render(strings.Join(keys, "  "))
+	}
+
 	keys := []string{
 		KeyStyle.Render("a") + DescStyle.Render("dd"),
 		KeyStyle.Render("space") + DescStyle.Render(" toggle"),
 		KeyStyle.Render("m") + DescStyle.Render("igrate"),
 		KeyStyle.Render("s") + DescStyle.Render("chedule"),
+		KeyStyle.Render("v") + DescStyle.Render(" select"),
 		KeyStyle.Render("r") + DescStyle.Render("eview"),
+		KeyStyle.Render("S") + DescStyle.Render("tats"),
 		KeyStyle.Render("d") + DescStyle.Render("ate"),
-		KeyStyle.Render("q") + DescStyle.Render("uit"),
 	}
+	if len(a.journals) > 1 {
+		keys = append(keys, KeyStyle.Render("w")+DescStyle.Render(" journal"))
+	}
+	keys = append(keys, KeyStyle.Render("q")+DescStyle.Render("uit"))
 
 	if len(a.entries) > 0 && a.cursor < len(a.entries) {
 		e := a.entries[a.cursor]
@@ -187,19 +326,32 @@ func (a *App) renderReviewScope() string {
 	title := ModalTitleStyle.Render("Review tasks from:")
 	b.WriteString(title + "\n\n")
 
-	options := []string{
-		fmt.Sprintf("[1] Last active day  (%d tasks)", a.countStaleTasks(1)),
-		fmt.Sprintf("[2] Last 2 days      (%d tasks)", a.countStaleTasks(2)),
-		fmt.Sprintf("[3] Last week        (%d tasks)", a.countStaleTasks(7)),
-		fmt.Sprintf("[4] All stale        (%d tasks)", a.countStaleTasks(0)),
+	scopes := a.cfg.Review.GetScopes()
+	width := 0
+	for _, scope := range scopes {
+		width = max(width, len(scope.Label))
 	}
-
-	for _, opt := range options {
+	for i, scope := range scopes {
+		filter := a.reviewFilter
+		filter.DaysBack = scope.Days
+		opt := fmt.Sprintf("[%d] %-*s  (%d tasks)", i+1, width, scope.Label, a.countStaleTasks(filter))
 		b.WriteString(ModalOptionStyle.Render(opt) + "\n")
 	}
 
 	b.WriteString("\n")
-	b.WriteString(ModalHintStyle.Render("[1-4] Select  [Esc] Cancel"))
+	if a.inputMode == "review_filter" {
+		b.WriteString(InputPromptStyle.Render("Filter: ") + a.input.View())
+		if a.inputErr != "" {
+			b.WriteString("\n" + InputErrorStyle.Render(a.inputErr))
+		}
+		b.WriteString("\n")
+		b.WriteString(ModalHintStyle.Render("[Enter] Apply  [Esc] Cancel"))
+	} else {
+		if text := formatReviewFilter(a.reviewFilter); text != "" {
+			b.WriteString(ReviewMetaStyle.Render("Filter: "+text) + "\n")
+		}
+		b.WriteString(ModalHintStyle.Render(fmt.Sprintf("[1-%d] Select  [/] Filter  [Esc] Cancel", len(scopes))))
+	}
 
 	return AppStyle.Render(ModalStyle.Render(b.String()))
 }
@@ -215,31 +367,86 @@ func (a *App) renderReviewTask() string {
 	header := ReviewHeaderStyle.Render(fmt.Sprintf("REVIEW: %d stale tasks", len(a.reviewTasks)))
 	b.WriteString(header + "\n\n")
 
+	chronic := a.isChronic(task)
+	if chronic {
+		warning := fmt.Sprintf("🐢 Moved %d times. Is it worth doing? Decide now.", task.MigrationCount+task.RescheduleCount)
+		b.WriteString(TurtleStyle.Render(warning) + "\n\n")
+	}
+
 	taskContent := ReviewTaskStyle.Render(task.Content)
+	if task.Priority > 0 {
+		taskContent = PriorityStyle.Render(task.Content)
+	}
 
-	daysAgo := int(time.Since(task.CreatedAt).Hours() / 24)
-	fromDate := ReviewMetaStyle.Render(fmt.Sprintf("From: %s (%d days ago)", task.CreatedAt.Format("2006-01-02"), daysAgo))
+	daysAgo := int(time.Since(task.Date).Hours() / 24)
+	fromDate := ReviewMetaStyle.Render(fmt.Sprintf("From: %s (%d days ago)", task.Date.Format("2006-01-02"), daysAgo))
 
 	migrated := ReviewMetaStyle.Render(fmt.Sprintf("Migrated: %d times", task.MigrationCount))
-	if task.MigrationCount > 3 {
-		migrated = migrated + " " + TurtleStyle.Render("🐢")
+	if task.RescheduleCount > 0 {
+		migrated += ReviewMetaStyle.Render(fmt.Sprintf(", rescheduled: %d times", task.RescheduleCount))
 	}
 
 	b.WriteString(taskContent + "\n")
 	b.WriteString(fromDate + "\n")
 	b.WriteString(migrated + "\n\n")
 
-	actions := []string{
-		KeyStyle.Render("[m]") + " Migrate to today",
-		KeyStyle.Render("[s]") + " Schedule for later",
-		KeyStyle.Render("[x]") + " Mark complete",
-		KeyStyle.Render("[d]") + " Cancel task",
-		KeyStyle.Render("[k]") + " Keep (skip)",
+	if chain := a.reviewChains[task.ID]; chronic && len(chain) > 0 {
+		b.WriteString(ReviewMetaStyle.Render("History:") + "\n")
+		for _, e := range chain {
+			line := fmt.Sprintf("  %s %s", a.extractDateFromPath(e.FilePath), e.DisplayString())
+			if e.ID == task.ID {
+				line += " (now)"
+			}
+			b.WriteString(ReviewMetaStyle.Render(line) + "\n")
+		}
+		b.WriteString("\n")
+	}
+
+	var actions []string
+	if step, decided := a.reviewStepAt(a.reviewCursor); decided {
+		done := fmt.Sprintf("Decided: %s", step.decision.Decision)
+		if step.decision.Target != "" {
+			done += " → " + step.decision.Target
+		}
+		b.WriteString(ReviewMetaStyle.Render(done) + "\n\n")
+		actions = []string{
+			KeyStyle.Render("[u]") + " Undo decision",
+			KeyStyle.Render("[k]") + " Next task",
+		}
+	} else if chronic {
+		actions = []string{
+			KeyStyle.Render("[m]") + " Do it today",
+			KeyStyle.Render("[s]") + " Schedule for a specific day",
+			KeyStyle.Render("[x]") + " Mark complete",
+			KeyStyle.Render("[d]") + " Cancel task",
+			KeyStyle.Render("[o]") + " Move to someday",
+		}
+	} else {
+		actions = []string{
+			KeyStyle.Render("[m]") + " Migrate to today",
+			KeyStyle.Render("[s]") + " Schedule for later",
+			KeyStyle.Render("[x]") + " Mark complete",
+			KeyStyle.Render("[d]") + " Cancel task",
+			KeyStyle.Render("[o]") + " Move to someday",
+			KeyStyle.Render("[k]") + " Keep (skip)",
+		}
+	}
+	if a.reviewCursor > 0 {
+		actions = append(actions, KeyStyle.Render("[p]")+" Previous task")
+	}
+	if len(a.reviewHistory) > 0 {
+		if _, decided := a.reviewStepAt(a.reviewCursor); !decided {
+			actions = append(actions, KeyStyle.Render("[u]")+" Undo last decision")
+		}
 	}
 	for _, action := range actions {
 		b.WriteString(ReviewActionStyle.Render(action) + "\n")
 	}
 
+	if a.message != "" {
+		b.WriteString("\n" + InputErrorStyle.Render(a.message) + "\n")
+	}
+
 	b.WriteString("\n")
 	progress := ReviewProgressStyle.Render(fmt.Sprintf("[%d/%d]", a.reviewCursor+1, len(a.reviewTasks)))
 	b.WriteString(progress)
@@ -247,15 +454,212 @@ func (a *App) renderReviewTask() string {
 	return AppStyle.Render(ReviewCardStyle.Render(b.String()))
 }
 
+// historyLines renders the history log one line per commit or diff line so
+// the panel can scroll through it.
+func (a *App) historyLines() []string {
+	var lines []string
+	for _, c := range a.historyLog {
+		date := HistoryDateStyle.Render(c.Date.In(clock.Location()).Format("2006-01-02 15:04"))
+		lines = append(lines, date+"  "+service.HistorySummary(c))
+		for _, d := range c.Diff {
+			style := HistoryAddedStyle
+			if strings.HasPrefix(d, "-") {
+				style = HistoryRemovedStyle
+			}
+			lines = append(lines, "    "+style.Render(d))
+		}
+	}
+	return lines
+}
+
+func (a *App) renderHistory() string {
+	var b strings.Builder
+
+	b.WriteString(ModalTitleStyle.Render("HISTORY: " + a.historyEntry.Content))
+	b.WriteString("\n\n")
+
+	lines := a.historyLines()
+	if a.historyLog == nil {
+		b.WriteString(EmptyStateStyle.Render("Loading..."))
+	} else if len(lines) == 0 {
+		b.WriteString(EmptyStateStyle.Render("No commits mention this entry."))
+	} else {
+		visible := max(a.height-8, 5)
+		end := min(a.historyScroll+visible, len(lines))
+		b.WriteString(strings.Join(lines[a.historyScroll:end], "\n"))
+	}
+
+	b.WriteString("\n\n")
+	b.WriteString(ModalHintStyle.Render("[j/k] Scroll  [Esc] Back"))
+
+	return AppStyle.Render(b.String())
+}
+
+var sparkChars = []rune("▁▂▃▄▅▆▇█")
+
+func (a *App) renderJournalPicker() string {
+	var b strings.Builder
+
+	b.WriteString(ModalTitleStyle.Render("Switch journal:") + "\n\n")
+	for i, name := range a.journals {
+		opt := fmt.Sprintf("[%d] %s", i+1, name)
+		if name == a.cfg.Workspace {
+			opt += NavHintStyle.Render("  (current)")
+		}
+		b.WriteString(ModalOptionStyle.Render(opt) + "\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(ModalHintStyle.Render(fmt.Sprintf("[1-%d] Switch  [a] All journals  [Esc] Cancel", len(a.journals))))
+
+	return AppStyle.Render(ModalStyle.Render(b.String()))
+}
+
+// renderAgenda shows today across every journal. It is read-only: entries
+// are changed from their own journal.
+func (a *App) renderAgenda() string {
+	var b strings.Builder
+
+	b.WriteString(ModalTitleStyle.Render("ALL JOURNALS  ") + DateStyle.Render(clock.Today().Format("02, January, 2006")))
+	b.WriteString("\n")
+
+	if a.agendas == nil {
+		b.WriteString(EmptyStateStyle.Render("Loading..."))
+		b.WriteString("\n")
+	}
+	for _, agenda := range a.agendas {
+		b.WriteString(StatsSectionStyle.Render(agenda.Journal))
+		b.WriteString("\n")
+		if agenda.IsEmpty() {
+			b.WriteString(EmptyStateStyle.Render("  Nothing on."))
+			b.WriteString("\n")
+			continue
+		}
+		for _, e := range slices.Concat(agenda.Events, agenda.Tasks) {
+			b.WriteString("  " + a.renderEntry(e, false) + "\n")
+		}
+		if len(agenda.Due) > 0 {
+			b.WriteString(NavHintStyle.Render("  Due soon") + "\n")
+			for _, e := range agenda.Due {
+				b.WriteString("  " + a.renderEntry(e, false) + NavHintStyle.Render(" on "+a.extractDateFromPath(e.FilePath)) + "\n")
+			}
+		}
+	}
+
+	b.WriteString("\n")
+	b.WriteString(ModalHintStyle.Render("[Esc] Back"))
+
+	return AppStyle.Render(b.String())
+}
+
+func (a *App) renderStats() string {
+	var b strings.Builder
+
+	b.WriteString(ModalTitleStyle.Render("STATS"))
+	b.WriteString("\n")
+
+	activity := make(map[string]storage.DayActivity, len(a.statsActivity))
+	for _, d := range a.statsActivity {
+		activity[d.Date] = d
+	}
+	today := clock.Today()
+
+	b.WriteString(StatsSectionStyle.Render(fmt.Sprintf("Completion per day (last %d weeks)", a.statsWeeks)))
+	b.WriteString("\n")
+	b.WriteString(renderSparkline(activity, today, a.statsWeeks*7))
+	b.WriteString("\n")
+
+	b.WriteString(StatsSectionStyle.Render(fmt.Sprintf("Activity (last %d weeks)", heatmapWeeks)))
+	b.WriteString("\n")
+	b.WriteString(renderHeatmap(activity, today, heatmapWeeks))
+	b.WriteString("\n")
+
+	b.WriteString(StatsSectionStyle.Render("Most migrated tasks"))
+	b.WriteString("\n")
+	if len(a.statsTop) == 0 {
+		b.WriteString(EmptyStateStyle.Render("Nothing has been migrated yet."))
+		b.WriteString("\n")
+	}
+	for i, task := range a.statsTop {
+		cursor := "  "
+		if i == a.statsCursor {
+			cursor = CursorStyle.Render("> ")
+		}
+		line := fmt.Sprintf("%2d× %s", task.MigrationCount+task.RescheduleCount, task.Content)
+		if i == a.statsCursor {
+			line = SelectedEntryStyle.Render(line)
+		} else {
+			line = EntryStyle.Render(line)
+		}
+		b.WriteString(cursor + line + "\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(ModalHintStyle.Render("[j/k] Select  [Enter] Go to task  [+/-] Weeks  [Esc] Back"))
+
+	return AppStyle.Render(b.String())
+}
+
+// renderSparkline draws one bar per day for the given number of days ending
+// today. Bar height is the day's completion rate; days without tasks are blank.
+func renderSparkline(activity map[string]storage.DayActivity, today time.Time, days int) string {
+	var bars strings.Builder
+	start := today.AddDate(0, 0, -(days - 1))
+	for i := 0; i < days; i++ {
+		d, ok := activity[start.AddDate(0, 0, i).Format(time.DateOnly)]
+		if !ok || d.Total == 0 {
+			bars.WriteRune(' ')
+			continue
+		}
+		level := d.Completed * (len(sparkChars) - 1) / d.Total
+		bars.WriteRune(sparkChars[level])
+	}
+
+	from := start.Format("Jan 2")
+	to := today.Format("Jan 2")
+	padding := max(1, days-len(from)-len(to))
+	labels := NavHintStyle.Render(from + strings.Repeat(" ", padding) + to)
+
+	return SparklineStyle.Render(bars.String()) + "\n" + labels
+}
+
+// renderHeatmap draws a weekday-by-week calendar of completed tasks, with
+// the current week in the rightmost column.
+func renderHeatmap(activity map[string]storage.DayActivity, today time.Time, weeks int) string {
+	start := today.AddDate(0, 0, -clock.WeekdayIndex(today)-7*(weeks-1))
+
+	busiest := 0
+	for _, d := range activity {
+		busiest = max(busiest, d.Completed)
+	}
+
+	var b strings.Builder
+	for row := range 7 {
+		label := start.AddDate(0, 0, row).Weekday().String()[:3]
+		b.WriteString(NavHintStyle.Render(label) + " ")
+		for col := 0; col < weeks; col++ {
+			date := start.AddDate(0, 0, col*7+row)
+			if date.After(today) {
+				b.WriteString("  ")
+				continue
+			}
+			level := 0
+			if d, ok := activity[date.Format(time.DateOnly)]; ok && d.Completed > 0 {
+				level = 1 + (d.Completed-1)*(len(HeatmapStyles)-1)/busiest
+			}
+			b.WriteString(HeatmapStyles[level].Render("■") + " ")
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
 func (a *App) isToday() bool {
-	now := time.Now()
-	return a.currentDate.Year() == now.Year() &&
-		a.currentDate.Month() == now.Month() &&
-		a.currentDate.Day() == now.Day()
+	return a.currentDate.Format(time.DateOnly) == clock.Today().Format(time.DateOnly)
 }
 
-func (a *App) countStaleTasks(daysBack int) int {
-	count, _ := a.db.CountStaleTasks(daysBack)
+func (a *App) countStaleTasks(filter storage.StaleFilter) int {
+	count, _ := a.db.CountStaleTasksMatching(filter)
 	return count
 }
 